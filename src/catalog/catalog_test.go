@@ -0,0 +1,151 @@
+package catalog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseCSV(t *testing.T) {
+	input := "ISBN,Title,Price,Authors,Topics,Page_Count,Format\n" +
+		"978-0-306-40615-7,The Book,12.5,Jane Doe; John Roe,Fiction > Fantasy;Classics,320,paperback\n" +
+		"0306406152,Bad Price,abc,,,,\n"
+	records, rowErrors, err := ParseCSV(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || len(rowErrors) != 1 {
+		t.Fatalf("expected 1 record and 1 row error, got %v and %v", len(records), len(rowErrors))
+	}
+	record := records[0]
+	if record.Row != 2 || record.ISBN != "978-0-306-40615-7" || record.Title != "The Book" || *record.Price != "12.5" {
+		t.Fatalf("unexpected record %+v", record)
+	}
+	if strings.Join(record.Authors, "|") != "Jane Doe|John Roe" {
+		t.Fatalf("unexpected authors %v", record.Authors)
+	}
+	if len(record.Topics) != 2 || strings.Join(record.Topics[0], "|") != "Fiction|Fantasy" {
+		t.Fatalf("unexpected topics %v", record.Topics)
+	}
+	if *record.PageCount != 320 || record.Format != "PAPERBACK" {
+		t.Fatalf("unexpected edition %+v", record)
+	}
+	if rowErrors[0].Row != 3 {
+		t.Fatalf("unexpected row error %v", rowErrors[0])
+	}
+}
+
+func TestParseCSVRequiredColumns(t *testing.T) {
+	_, _, err := ParseCSV(strings.NewReader("title,price\nA,1\n"))
+	if err == nil {
+		t.Fatal("expected an error for a missing isbn column")
+	}
+}
+
+func TestParseONIX(t *testing.T) {
+	input := `<?xml version="1.0" encoding="UTF-8"?>
+<ONIXMessage release="3.0" xmlns="http://ns.editeur.org/onix/3.0/reference">
+  <Header><Sender><SenderName>Publisher</SenderName></Sender></Header>
+  <Product>
+    <RecordReference>com.example.1</RecordReference>
+    <ProductIdentifier><ProductIDType>15</ProductIDType><IDValue>9780306406157</IDValue></ProductIdentifier>
+    <DescriptiveDetail>
+      <ProductForm>BB</ProductForm>
+      <TitleDetail><TitleType>01</TitleType><TitleElement><TitleText>The Book</TitleText><Subtitle>A Tale</Subtitle></TitleElement></TitleDetail>
+      <Contributor><ContributorRole>A01</ContributorRole><PersonName>Jane Doe</PersonName></Contributor>
+      <Contributor><ContributorRole>B06</ContributorRole><PersonName>A Translator</PersonName></Contributor>
+      <EditionStatement>Second edition</EditionStatement>
+      <Language><LanguageRole>01</LanguageRole><LanguageCode>eng</LanguageCode></Language>
+      <Extent><ExtentType>00</ExtentType><ExtentValue>320</ExtentValue><ExtentUnit>03</ExtentUnit></Extent>
+      <Subject><SubjectHeadingText>Fiction > Fantasy</SubjectHeadingText></Subject>
+    </DescriptiveDetail>
+    <CollateralDetail><TextContent><TextType>03</TextType><Text>A description</Text></TextContent></CollateralDetail>
+    <PublishingDetail>
+      <Publisher><PublisherName>Example Press</PublisherName></Publisher>
+      <PublishingDate><PublishingDateRole>01</PublishingDateRole><Date>20200131</Date></PublishingDate>
+    </PublishingDetail>
+    <ProductSupply><SupplyDetail><Price><PriceAmount>19.99</PriceAmount></Price></SupplyDetail></ProductSupply>
+  </Product>
+  <Product>
+    <ProductIdentifier><ProductIDType>15</ProductIDType><IDValue>9780306406158</IDValue></ProductIdentifier>
+    <DescriptiveDetail><ProductForm>ZZ</ProductForm></DescriptiveDetail>
+  </Product>
+</ONIXMessage>`
+	records, rowErrors, err := ParseONIX(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || len(rowErrors) != 1 {
+		t.Fatalf("expected 1 record and 1 row error, got %v and %v", len(records), len(rowErrors))
+	}
+	record := records[0]
+	if record.ISBN != "9780306406157" || record.Title != "The Book: A Tale" || record.Format != "HARDCOVER" {
+		t.Fatalf("unexpected record %+v", record)
+	}
+	if strings.Join(record.Authors, "|") != "Jane Doe" || record.Publisher != "Example Press" {
+		t.Fatalf("unexpected contributors %+v", record)
+	}
+	if record.Language != "en" || *record.PageCount != 320 || record.PublicationDate != "2020-01-31" || *record.Price != "19.99" {
+		t.Fatalf("unexpected metadata %+v", record)
+	}
+	if record.Description != "A description" || record.Edition != "Second edition" {
+		t.Fatalf("unexpected texts %+v", record)
+	}
+	if len(record.Topics) != 1 || strings.Join(record.Topics[0], "|") != "Fiction|Fantasy" {
+		t.Fatalf("unexpected topics %v", record.Topics)
+	}
+	if rowErrors[0].Row != 2 || rowErrors[0].ISBN != "9780306406158" {
+		t.Fatalf("unexpected row error %+v", rowErrors[0])
+	}
+}
+
+func TestExportCSVCanBeImported(t *testing.T) {
+	var buf strings.Builder
+	writer, _ := NewWriter("CSV", &buf)
+	pageCount := int64(320)
+	writer.Write(&ExportBook{
+		ID:        "b1",
+		ISBN:      "9780306406157",
+		Title:     "The Book",
+		Price:     "12.50",
+		Authors:   []string{"Jane Doe", "John Roe"},
+		Topics:    [][]string{{"Fiction", "Fantasy"}},
+		PageCount: &pageCount,
+	})
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	records, rowErrors, err := ParseCSV(strings.NewReader(buf.String()))
+	if err != nil || len(rowErrors) > 0 || len(records) != 1 {
+		t.Fatalf("unexpected import of the export: %v %v %v", records, rowErrors, err)
+	}
+	record := records[0]
+	if record.ISBN != "9780306406157" || *record.Price != "12.50" || len(record.Authors) != 2 || strings.Join(record.Topics[0], "|") != "Fiction|Fantasy" {
+		t.Fatalf("unexpected record %+v", record)
+	}
+}
+
+func TestExportMerchantXML(t *testing.T) {
+	var buf strings.Builder
+	writer, _ := NewWriter("MERCHANT_XML", &buf)
+	writer.Write(&ExportBook{
+		ID:    "b1",
+		Title: "The Book & Co",
+		Variants: []ExportVariant{
+			{SKU: "B1-HC", Format: "HARDCOVER", Price: "20.00", Currency: "EUR", Stock: 3},
+			{SKU: "B1-EB", Format: "EBOOK", Price: "9.99", Currency: "EUR", Stock: 0},
+		},
+	})
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	feed := buf.String()
+	for _, expected := range []string{
+		`<g:id>B1-HC</g:id><g:item_group_id>b1</g:item_group_id><g:title>The Book &amp; Co (Hardcover)</g:title>`,
+		`<g:price>9.99 EUR</g:price><g:availability>out_of_stock</g:availability>`,
+		"</channel>\n</rss>",
+	} {
+		if !strings.Contains(feed, expected) {
+			t.Fatalf("feed doesn't contain %v:\n%v", expected, feed)
+		}
+	}
+}
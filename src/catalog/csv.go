@@ -0,0 +1,105 @@
+package catalog
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// CSVColumns are the columns of a CSV import, the first line of the file names the columns
+// in any order and only isbn and title are required. Multiple authors or topics are
+// separated by ";" and the levels of a topic by ">".
+var CSVColumns = []string{
+	"isbn", "title", "description", "price", "authors", "topics", "publisher",
+	"publication_date", "language", "page_count", "format", "edition",
+}
+
+func ParseCSV(r io.Reader) ([]*Record, []*RowError, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil, fmt.Errorf("CSV file is empty")
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, required := range []string{"isbn", "title"} {
+		if _, ok := columns[required]; !ok {
+			return nil, nil, fmt.Errorf("CSV file has no %v column", required)
+		}
+	}
+	records := []*Record{}
+	rowErrors := []*RowError{}
+	for row := 2; ; row++ {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if _, ok := err.(*csv.ParseError); ok {
+				rowErrors = append(rowErrors, &RowError{Row: row, Message: err.Error()})
+				continue
+			}
+			return nil, nil, err
+		}
+		value := func(column string) string {
+			i, ok := columns[column]
+			if !ok || i >= len(fields) {
+				return ""
+			}
+			return strings.TrimSpace(fields[i])
+		}
+		record := &Record{
+			Row:             row,
+			ISBN:            value("isbn"),
+			Title:           value("title"),
+			Description:     value("description"),
+			Authors:         splitList(value("authors")),
+			Publisher:       value("publisher"),
+			PublicationDate: value("publication_date"),
+			Language:        value("language"),
+			Format:          strings.ToUpper(value("format")),
+			Edition:         value("edition"),
+		}
+		for _, topic := range splitList(value("topics")) {
+			record.Topics = append(record.Topics, splitList(strings.ReplaceAll(topic, TopicSeparator, ";")))
+		}
+		if price := value("price"); price != "" {
+			parsed, err := parsePrice(price)
+			if err != nil {
+				rowErrors = append(rowErrors, &RowError{Row: row, ISBN: record.ISBN, Message: err.Error()})
+				continue
+			}
+			record.Price = parsed
+		}
+		if pageCount := value("page_count"); pageCount != "" {
+			parsed, err := strconv.ParseInt(pageCount, 10, 64)
+			if err != nil {
+				rowErrors = append(rowErrors, &RowError{Row: row, ISBN: record.ISBN, Message: fmt.Sprintf("Invalid page count %v", pageCount)})
+				continue
+			}
+			record.PageCount = &parsed
+		}
+		records = append(records, record)
+	}
+	return records, rowErrors, nil
+}
+
+func splitList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ";") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
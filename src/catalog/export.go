@@ -0,0 +1,265 @@
+package catalog
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ExportBook is a book with its authors, topics and publisher resolved to names.
+type ExportBook struct {
+	ID              string          `json:"id"`
+	ISBN            string          `json:"isbn,omitempty"`
+	Title           string          `json:"title"`
+	Description     string          `json:"description,omitempty"`
+	Price           json.Number     `json:"price"`
+	Currency        string          `json:"currency"`
+	Authors         []string        `json:"authors"`
+	Topics          [][]string      `json:"topics"`
+	Publisher       string          `json:"publisher,omitempty"`
+	PublicationDate string          `json:"publicationDate,omitempty"`
+	Language        string          `json:"language,omitempty"`
+	PageCount       *int64          `json:"pageCount,omitempty"`
+	Format          string          `json:"format,omitempty"`
+	Edition         string          `json:"edition,omitempty"`
+	Variants        []ExportVariant `json:"variants"`
+	CoverURL        string          `json:"coverUrl,omitempty"`
+	Link            string          `json:"link,omitempty"`
+}
+
+type ExportVariant struct {
+	ID       string      `json:"id"`
+	SKU      string      `json:"sku"`
+	Format   string      `json:"format"`
+	Price    json.Number `json:"price"`
+	Currency string      `json:"currency"`
+	Stock    int64       `json:"stock"`
+	Weight   *int64      `json:"weight,omitempty"`
+}
+
+// Stock is the stock of all the variants of a book, a book without variants has no stock tracking.
+func (book *ExportBook) Stock() (int64, bool) {
+	if len(book.Variants) == 0 {
+		return 0, false
+	}
+	stock := int64(0)
+	for _, variant := range book.Variants {
+		stock += variant.Stock
+	}
+	return stock, true
+}
+
+// Writer writes the books of an export one at a time, Close completes the file.
+type Writer interface {
+	Write(book *ExportBook) error
+	Close() error
+}
+
+// NewWriter returns the writer of an export format: CSV, JSONL or MERCHANT_XML.
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case "CSV":
+		return NewCSVWriter(w)
+	case "JSONL":
+		return &jsonlWriter{encoder: json.NewEncoder(w)}, nil
+	case "MERCHANT_XML":
+		return NewMerchantWriter(w)
+	}
+	return nil, fmt.Errorf("Unsupported export format %v", format)
+}
+
+// csvWriter writes the columns of a CSV import, so an export can be edited and imported
+// back, followed by the id, stock, cover_url and currency columns.
+type csvWriter struct {
+	writer *csv.Writer
+}
+
+func NewCSVWriter(w io.Writer) (Writer, error) {
+	writer := csv.NewWriter(w)
+	err := writer.Write(append(append([]string{}, CSVColumns...), "id", "stock", "cover_url", "currency"))
+	if err != nil {
+		return nil, err
+	}
+	return &csvWriter{writer: writer}, nil
+}
+
+func (c *csvWriter) Write(book *ExportBook) error {
+	topics := []string{}
+	for _, path := range book.Topics {
+		topics = append(topics, strings.Join(path, " "+TopicSeparator+" "))
+	}
+	pageCount := ""
+	if book.PageCount != nil {
+		pageCount = strconv.FormatInt(*book.PageCount, 10)
+	}
+	stock := ""
+	if total, ok := book.Stock(); ok {
+		stock = strconv.FormatInt(total, 10)
+	}
+	return c.writer.Write([]string{
+		book.ISBN,
+		book.Title,
+		book.Description,
+		book.Price.String(),
+		strings.Join(book.Authors, "; "),
+		strings.Join(topics, "; "),
+		book.Publisher,
+		book.PublicationDate,
+		book.Language,
+		pageCount,
+		book.Format,
+		book.Edition,
+		book.ID,
+		stock,
+		book.CoverURL,
+		book.Currency,
+	})
+}
+
+func (c *csvWriter) Close() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+type jsonlWriter struct {
+	encoder *json.Encoder
+}
+
+func (j *jsonlWriter) Write(book *ExportBook) error {
+	return j.encoder.Encode(book)
+}
+
+func (j *jsonlWriter) Close() error {
+	return nil
+}
+
+// merchantWriter writes an RSS 2.0 product feed in the Google Merchant Center format,
+// every variant is an item of the group of its book.
+type merchantWriter struct {
+	w       io.Writer
+	encoder *xml.Encoder
+}
+
+const merchantHeader = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:g="http://base.google.com/ns/1.0">
+<channel>
+<title>Book Store</title>
+`
+
+const merchantFooter = `
+</channel>
+</rss>
+`
+
+var formatNames = map[string]string{
+	"HARDCOVER": "Hardcover",
+	"PAPERBACK": "Paperback",
+	"EBOOK":     "eBook",
+	"AUDIOBOOK": "Audiobook",
+}
+
+// merchantBooksCategory is the Google product category Media > Books.
+const merchantBooksCategory = "784"
+
+type merchantItem struct {
+	XMLName      xml.Name `xml:"item"`
+	ID           string   `xml:"g:id"`
+	ItemGroupID  string   `xml:"g:item_group_id,omitempty"`
+	Title        string   `xml:"g:title"`
+	Description  string   `xml:"g:description"`
+	Link         string   `xml:"g:link,omitempty"`
+	ImageLink    string   `xml:"g:image_link,omitempty"`
+	Price        string   `xml:"g:price"`
+	Availability string   `xml:"g:availability"`
+	Condition    string   `xml:"g:condition"`
+	GTIN         string   `xml:"g:gtin,omitempty"`
+	Brand        string   `xml:"g:brand,omitempty"`
+	Category     string   `xml:"g:google_product_category"`
+	ProductType  string   `xml:"g:product_type,omitempty"`
+}
+
+func NewMerchantWriter(w io.Writer) (Writer, error) {
+	_, err := io.WriteString(w, merchantHeader)
+	if err != nil {
+		return nil, err
+	}
+	return &merchantWriter{w: w, encoder: xml.NewEncoder(w)}, nil
+}
+
+func (m *merchantWriter) Write(book *ExportBook) error {
+	item := merchantItem{
+		ID:           book.ID,
+		Title:        book.Title,
+		Description:  book.Description,
+		Link:         book.Link,
+		ImageLink:    book.CoverURL,
+		Price:        merchantPrice(book.Price, book.Currency),
+		Availability: "in_stock",
+		Condition:    "new",
+		GTIN:         book.ISBN,
+		Brand:        book.Publisher,
+		Category:     merchantBooksCategory,
+	}
+	if len(book.Topics) > 0 {
+		item.ProductType = strings.Join(book.Topics[0], " > ")
+	}
+	if item.Description == "" {
+		item.Description = book.Title
+	}
+	if len(book.Variants) == 0 {
+		return m.encoder.Encode(item)
+	}
+	for _, variant := range book.Variants {
+		variantItem := item
+		variantItem.ID = variant.SKU
+		variantItem.ItemGroupID = book.ID
+		variantItem.Title = fmt.Sprintf("%v (%v)", book.Title, formatNames[variant.Format])
+		variantItem.Price = merchantPrice(variant.Price, variant.Currency)
+		if variant.Stock <= 0 {
+			variantItem.Availability = "out_of_stock"
+		}
+		err := m.encoder.Encode(variantItem)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func merchantPrice(price json.Number, currency string) string {
+	return price.String() + " " + currency
+}
+
+func (m *merchantWriter) Close() error {
+	err := m.encoder.Flush()
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(m.w, merchantFooter)
+	return err
+}
+
+var exportExtensions = map[string]string{
+	"CSV":          "csv",
+	"JSONL":        "jsonl",
+	"MERCHANT_XML": "xml",
+}
+
+var exportContentTypes = map[string]string{
+	"CSV":          "text/csv; charset=utf-8",
+	"JSONL":        "application/x-ndjson",
+	"MERCHANT_XML": "application/xml",
+}
+
+// ExportFileName is the name of the file of an export job.
+func ExportFileName(jobID string, format string) string {
+	return "catalog-" + jobID + "." + exportExtensions[format]
+}
+
+func ExportContentType(format string) string {
+	return exportContentTypes[format]
+}
@@ -0,0 +1,232 @@
+package catalog
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// onixProduct holds the parts of an ONIX 3.0 Product, with reference tag names, used by the import.
+type onixProduct struct {
+	RecordReference    string `xml:"RecordReference"`
+	ProductIdentifiers []struct {
+		Type  string `xml:"ProductIDType"`
+		Value string `xml:"IDValue"`
+	} `xml:"ProductIdentifier"`
+	DescriptiveDetail struct {
+		ProductForm  string `xml:"ProductForm"`
+		TitleDetails []struct {
+			Type     string `xml:"TitleType"`
+			Text     string `xml:"TitleElement>TitleText"`
+			Subtitle string `xml:"TitleElement>Subtitle"`
+		} `xml:"TitleDetail"`
+		Contributors []struct {
+			Roles          []string `xml:"ContributorRole"`
+			PersonName     string   `xml:"PersonName"`
+			CorporateName  string   `xml:"CorporateName"`
+			NamesBeforeKey string   `xml:"NamesBeforeKey"`
+			KeyNames       string   `xml:"KeyNames"`
+		} `xml:"Contributor"`
+		EditionStatement string `xml:"EditionStatement"`
+		Languages        []struct {
+			Role string `xml:"LanguageRole"`
+			Code string `xml:"LanguageCode"`
+		} `xml:"Language"`
+		Extents []struct {
+			Type  string `xml:"ExtentType"`
+			Value string `xml:"ExtentValue"`
+			Unit  string `xml:"ExtentUnit"`
+		} `xml:"Extent"`
+		Subjects []struct {
+			HeadingText string `xml:"SubjectHeadingText"`
+		} `xml:"Subject"`
+	} `xml:"DescriptiveDetail"`
+	TextContents []struct {
+		Type string `xml:"TextType"`
+		Text string `xml:"Text"`
+	} `xml:"CollateralDetail>TextContent"`
+	PublishingDetail struct {
+		Publishers []struct {
+			Name string `xml:"PublisherName"`
+		} `xml:"Publisher"`
+		Dates []struct {
+			Role string `xml:"PublishingDateRole"`
+			Date string `xml:"Date"`
+		} `xml:"PublishingDate"`
+	} `xml:"PublishingDetail"`
+	Prices []struct {
+		Amount string `xml:"PriceAmount"`
+	} `xml:"ProductSupply>SupplyDetail>Price"`
+}
+
+// onixForms maps ONIX list 150 product forms to book formats.
+var onixForms = map[string]string{
+	"BB": "HARDCOVER",
+	"BC": "PAPERBACK",
+	"EA": "EBOOK",
+	"ED": "EBOOK",
+	"AJ": "AUDIOBOOK",
+	"AN": "AUDIOBOOK",
+	"AC": "AUDIOBOOK",
+}
+
+// onixLanguages maps the ISO 639-2/B codes used by ONIX to ISO 639-1.
+var onixLanguages = map[string]string{
+	"ara": "ar", "chi": "zh", "cze": "cs", "dan": "da", "dut": "nl", "eng": "en", "fin": "fi",
+	"fre": "fr", "ger": "de", "gre": "el", "heb": "he", "hin": "hi", "hun": "hu", "ind": "id",
+	"ita": "it", "jpn": "ja", "kor": "ko", "nor": "no", "pol": "pl", "por": "pt", "rum": "ro",
+	"rus": "ru", "spa": "es", "swe": "sv", "tha": "th", "tur": "tr", "ukr": "uk", "vie": "vi",
+}
+
+// ParseONIX reads the products of an ONIX 3.0 message using reference tag names, products are
+// decoded one at a time so large messages don't have to fit in memory.
+func ParseONIX(r io.Reader) ([]*Record, []*RowError, error) {
+	decoder := xml.NewDecoder(r)
+	records := []*Record{}
+	rowErrors := []*RowError{}
+	row := 0
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("Invalid ONIX message: %v", err.Error())
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "Product" {
+			continue
+		}
+		row++
+		var product onixProduct
+		err = decoder.DecodeElement(&product, &start)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Invalid ONIX message: %v", err.Error())
+		}
+		record, err := product.record(row)
+		if err != nil {
+			rowErrors = append(rowErrors, &RowError{Row: row, ISBN: record.ISBN, Message: err.Error()})
+			continue
+		}
+		records = append(records, record)
+	}
+	if row == 0 {
+		return nil, nil, fmt.Errorf("ONIX message has no product")
+	}
+	return records, rowErrors, nil
+}
+
+func (product *onixProduct) record(row int) (*Record, error) {
+	record := &Record{Row: row}
+	for _, identifier := range product.ProductIdentifiers {
+		// 15 is an ISBN-13, 02 an ISBN-10
+		if identifier.Type == "15" || (identifier.Type == "02" && record.ISBN == "") {
+			record.ISBN = strings.TrimSpace(identifier.Value)
+		}
+	}
+	detail := product.DescriptiveDetail
+	for _, title := range detail.TitleDetails {
+		// 01 is the distinctive title of the product
+		if title.Type == "01" || record.Title == "" {
+			record.Title = strings.TrimSpace(title.Text)
+			if subtitle := strings.TrimSpace(title.Subtitle); subtitle != "" {
+				record.Title += ": " + subtitle
+			}
+		}
+	}
+	for _, contributor := range detail.Contributors {
+		if !contains(contributor.Roles, "A01") {
+			continue
+		}
+		name := strings.TrimSpace(contributor.PersonName)
+		if name == "" && contributor.KeyNames != "" {
+			name = strings.TrimSpace(contributor.NamesBeforeKey + " " + contributor.KeyNames)
+		}
+		if name == "" {
+			name = strings.TrimSpace(contributor.CorporateName)
+		}
+		if name != "" {
+			record.Authors = append(record.Authors, name)
+		}
+	}
+	if detail.ProductForm != "" {
+		format, ok := onixForms[detail.ProductForm]
+		if !ok {
+			return record, fmt.Errorf("Unsupported product form %v", detail.ProductForm)
+		}
+		record.Format = format
+	}
+	record.Edition = strings.TrimSpace(detail.EditionStatement)
+	for _, language := range detail.Languages {
+		// 01 is the language of the text
+		if language.Role != "01" {
+			continue
+		}
+		code, ok := onixLanguages[strings.ToLower(language.Code)]
+		if !ok {
+			return record, fmt.Errorf("Unsupported language %v", language.Code)
+		}
+		record.Language = code
+	}
+	for _, extent := range detail.Extents {
+		// 00 is the main content page count, 07 the total page count
+		if extent.Type != "00" && extent.Type != "07" {
+			continue
+		}
+		pageCount, err := strconv.ParseInt(strings.TrimSpace(extent.Value), 10, 64)
+		if err != nil {
+			return record, fmt.Errorf("Invalid page count %v", extent.Value)
+		}
+		if record.PageCount == nil || extent.Type == "00" {
+			record.PageCount = &pageCount
+		}
+	}
+	for _, subject := range detail.Subjects {
+		if text := strings.TrimSpace(subject.HeadingText); text != "" {
+			record.Topics = append(record.Topics, splitList(strings.ReplaceAll(text, TopicSeparator, ";")))
+		}
+	}
+	for _, text := range product.TextContents {
+		// 03 is the description
+		if text.Type == "03" {
+			record.Description = strings.TrimSpace(text.Text)
+		}
+	}
+	if len(product.PublishingDetail.Publishers) > 0 {
+		record.Publisher = strings.TrimSpace(product.PublishingDetail.Publishers[0].Name)
+	}
+	for _, date := range product.PublishingDetail.Dates {
+		// 01 is the publication date, formatted YYYYMMDD, YYYYMM or YYYY
+		if date.Role != "01" {
+			continue
+		}
+		value := strings.TrimSpace(date.Date)
+		switch len(value) {
+		case 8:
+			record.PublicationDate = value[:4] + "-" + value[4:6] + "-" + value[6:]
+		case 6:
+			record.PublicationDate = value[:4] + "-" + value[4:]
+		default:
+			record.PublicationDate = value
+		}
+	}
+	if len(product.Prices) > 0 {
+		price, err := parsePrice(strings.TrimSpace(product.Prices[0].Amount))
+		if err != nil {
+			return record, err
+		}
+		record.Price = price
+	}
+	return record, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
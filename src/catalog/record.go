@@ -0,0 +1,62 @@
+package catalog
+
+import (
+	"book-store/graph/model"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// TopicSeparator separates the levels of a topic path, e.g. "Fiction > Fantasy".
+const TopicSeparator = ">"
+
+// Record is a book read from an import file, the ISBN is the natural key of the book,
+// authors and the publisher are matched by name and topics by path.
+type Record struct {
+	// Row is the line of a CSV file or the position of the product in an ONIX message
+	Row         int
+	ISBN        string
+	Title       string
+	Description string
+	// Price is a decimal amount in major units of the store currency, e.g. 19.99
+	Price           *json.Number
+	Authors         []string
+	Topics          [][]string
+	Publisher       string
+	PublicationDate string
+	Language        string
+	PageCount       *int64
+	Format          string
+	Edition         string
+}
+
+// RowError is a problem with a single row of an import file, the other rows are still imported.
+type RowError struct {
+	Row     int
+	ISBN    string
+	Message string
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("Row %v: %v", e.Row, e.Message)
+}
+
+// Parse reads the records of a CSV file or an ONIX 3.0 message, depending on format.
+func Parse(format string, r io.Reader) ([]*Record, []*RowError, error) {
+	switch format {
+	case "CSV":
+		return ParseCSV(r)
+	case "ONIX":
+		return ParseONIX(r)
+	}
+	return nil, nil, fmt.Errorf("Unsupported import format %v", format)
+}
+
+// parsePrice checks a price is a positive decimal number, it is kept as written to not lose precision.
+func parsePrice(price string) (*json.Number, error) {
+	if !model.IsDecimal(price) {
+		return nil, fmt.Errorf("Invalid price %v", price)
+	}
+	number := json.Number(price)
+	return &number, nil
+}
@@ -0,0 +1,130 @@
+package cover
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"net/http"
+)
+
+const (
+	// MaxSize is the largest cover file accepted, in bytes
+	MaxSize = 10 << 20
+	// maxPixels protects against images which are small files but huge once decoded
+	maxPixels = 50_000_000
+	minSide   = 100
+)
+
+// Rendition is a resized copy of a cover, encoded as JPEG.
+type Rendition struct {
+	Size   string
+	Width  int
+	Height int
+	Data   []byte
+}
+
+// Sizes maps the rendition sizes to their largest width, in pixels.
+var Sizes = []struct {
+	Name  string
+	Width int
+}{
+	{"THUMBNAIL", 160},
+	{"MEDIUM", 480},
+	{"LARGE", 1200},
+}
+
+var allowedTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+// Process checks an uploaded cover and returns its renditions, an image is never enlarged
+// so a small cover may have renditions of the same dimensions.
+func Process(data []byte) ([]*Rendition, error) {
+	if len(data) > MaxSize {
+		return nil, fmt.Errorf("Cover image must not be larger than %v MB", MaxSize>>20)
+	}
+	contentType := http.DetectContentType(data)
+	if !allowedTypes[contentType] {
+		return nil, fmt.Errorf("Unsupported cover image type %v, expected JPEG, PNG or GIF", contentType)
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("Invalid cover image")
+	}
+	if config.Width*config.Height > maxPixels {
+		return nil, fmt.Errorf("Cover image has too many pixels")
+	}
+	if config.Width < minSide || config.Height < minSide {
+		return nil, fmt.Errorf("Cover image must be at least %vx%v pixels", minSide, minSide)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("Invalid cover image")
+	}
+	// flatten transparent images on white, JPEG has no alpha channel
+	bounds := img.Bounds()
+	source := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(source, source.Bounds(), &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+	draw.Draw(source, source.Bounds(), img, bounds.Min, draw.Over)
+
+	renditions := []*Rendition{}
+	for _, size := range Sizes {
+		width, height := source.Bounds().Dx(), source.Bounds().Dy()
+		if width > size.Width {
+			height = height * size.Width / width
+			width = size.Width
+		}
+		if height < 1 {
+			height = 1
+		}
+		var buf bytes.Buffer
+		err = jpeg.Encode(&buf, resize(source, width, height), &jpeg.Options{Quality: 85})
+		if err != nil {
+			return nil, err
+		}
+		renditions = append(renditions, &Rendition{Size: size.Name, Width: width, Height: height, Data: buf.Bytes()})
+	}
+	return renditions, nil
+}
+
+// resize scales an image down by averaging the source pixels covered by each target pixel.
+func resize(source *image.RGBA, width int, height int) *image.RGBA {
+	sw, sh := source.Bounds().Dx(), source.Bounds().Dy()
+	if sw == width && sh == height {
+		return source
+	}
+	target := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0, y1 := y*sh/height, (y+1)*sh/height
+		if y1 == y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < width; x++ {
+			x0, x1 := x*sw/width, (x+1)*sw/width
+			if x1 == x0 {
+				x1 = x0 + 1
+			}
+			var r, g, b, a, n int
+			for sy := y0; sy < y1; sy++ {
+				offset := source.PixOffset(x0, sy)
+				for sx := x0; sx < x1; sx++ {
+					r += int(source.Pix[offset])
+					g += int(source.Pix[offset+1])
+					b += int(source.Pix[offset+2])
+					a += int(source.Pix[offset+3])
+					n++
+					offset += 4
+				}
+			}
+			target.SetRGBA(x, y, color.RGBA{uint8(r / n), uint8(g / n), uint8(b / n), uint8(a / n)})
+		}
+	}
+	return target
+}
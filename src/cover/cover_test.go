@@ -0,0 +1,103 @@
+package cover
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestProcess(t *testing.T) {
+	// a transparent image
+	source := image.NewNRGBA(image.Rect(0, 0, 800, 1200))
+	renditions, err := Process(encodePNG(t, source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []struct {
+		size          string
+		width, height int
+	}{
+		{"THUMBNAIL", 160, 240},
+		{"MEDIUM", 480, 720},
+		// never enlarged
+		{"LARGE", 800, 1200},
+	}
+	if len(renditions) != len(expected) {
+		t.Fatalf("expected %v renditions, got %v", len(expected), len(renditions))
+	}
+	for i, rendition := range renditions {
+		if rendition.Size != expected[i].size || rendition.Width != expected[i].width || rendition.Height != expected[i].height {
+			t.Fatalf("unexpected rendition %v %vx%v", rendition.Size, rendition.Width, rendition.Height)
+		}
+		img, err := jpeg.Decode(bytes.NewReader(rendition.Data))
+		if err != nil {
+			t.Fatalf("expected a JPEG rendition: %v", err)
+		}
+		if img.Bounds().Dx() != rendition.Width || img.Bounds().Dy() != rendition.Height {
+			t.Fatalf("expected the JPEG to be %vx%v, got %v", rendition.Width, rendition.Height, img.Bounds())
+		}
+		// transparent pixels are flattened on white
+		if r, g, b, _ := img.At(10, 10).RGBA(); r>>8 < 250 || g>>8 < 250 || b>>8 < 250 {
+			t.Fatalf("expected a white background, got %v %v %v", r>>8, g>>8, b>>8)
+		}
+	}
+}
+
+func TestProcessRefused(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"too large", make([]byte, MaxSize+1)},
+		{"not an image", []byte("%PDF-1.4\n")},
+		{"truncated", encodePNG(t, image.NewRGBA(image.Rect(0, 0, 200, 200)))[:40]},
+		{"too small", encodePNG(t, image.NewRGBA(image.Rect(0, 0, 50, 200)))},
+	}
+	for _, test := range tests {
+		if _, err := Process(test.data); err == nil {
+			t.Fatalf("%v: expected the cover to be refused", test.name)
+		}
+	}
+}
+
+func TestResize(t *testing.T) {
+	source := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	// the left half is black and the right half white
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 4; x++ {
+			c := color.RGBA{0, 0, 0, 255}
+			if x >= 2 {
+				c = color.RGBA{255, 255, 255, 255}
+			}
+			source.SetRGBA(x, y, c)
+		}
+	}
+	if resize(source, 4, 2) != source {
+		t.Fatal("expected an image of the same size to be kept")
+	}
+	target := resize(source, 2, 1)
+	if target.Bounds().Dx() != 2 || target.Bounds().Dy() != 1 {
+		t.Fatalf("unexpected size %v", target.Bounds())
+	}
+	if c := target.RGBAAt(0, 0); c != (color.RGBA{0, 0, 0, 255}) {
+		t.Fatalf("expected a black pixel, got %v", c)
+	}
+	if c := target.RGBAAt(1, 0); c != (color.RGBA{255, 255, 255, 255}) {
+		t.Fatalf("expected a white pixel, got %v", c)
+	}
+	// a pixel covering black and white source pixels is their average
+	if c := resize(source, 1, 1).RGBAAt(0, 0); c != (color.RGBA{127, 127, 127, 255}) {
+		t.Fatalf("expected a grey pixel, got %v", c)
+	}
+}
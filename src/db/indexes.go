@@ -0,0 +1,43 @@
+package db
+
+import (
+	"context"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// EnsureIndexes creates the indexes the application relies on, creating an existing index is a no-op.
+func EnsureIndexes(database *mongo.Database) {
+	// books without an ISBN are left out of the unique index
+	isbnIndex := mongo.IndexModel{
+		Keys: bson.M{"isbn": 1},
+		Options: options.Index().SetUnique(true).
+			SetPartialFilterExpression(bson.M{"isbn": bson.M{"$type": "string"}}),
+	}
+	skuIndex := mongo.IndexModel{
+		Keys: bson.M{"variants.sku": 1},
+		Options: options.Index().SetUnique(true).
+			SetPartialFilterExpression(bson.M{"variants.sku": bson.M{"$type": "string"}}),
+	}
+	_, err := database.Collection("books").Indexes().CreateMany(context.Background(), []mongo.IndexModel{isbnIndex, skuIndex})
+	if err != nil {
+		log.Fatalf("Error when creating indexes: %v", err.Error())
+	}
+	// invoice numbers are taken once, and an order has a single invoice but may have several credit notes
+	sequenceIndex := mongo.IndexModel{
+		Keys:    bson.D{{Key: "kind", Value: 1}, {Key: "sequence", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+	orderInvoiceIndex := mongo.IndexModel{
+		Keys: bson.D{{Key: "orderId", Value: 1}, {Key: "kind", Value: 1}},
+		Options: options.Index().SetUnique(true).
+			SetPartialFilterExpression(bson.M{"kind": "INVOICE"}),
+	}
+	_, err = database.Collection("invoices").Indexes().CreateMany(context.Background(), []mongo.IndexModel{sequenceIndex, orderInvoiceIndex})
+	if err != nil {
+		log.Fatalf("Error when creating indexes: %v", err.Error())
+	}
+}
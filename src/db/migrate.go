@@ -0,0 +1,121 @@
+package db
+
+import (
+	"book-store/graph/model"
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// migration changes the existing data once, its name is recorded in the migrations collection when applied.
+type migration struct {
+	name  string
+	apply func(database *mongo.Database) error
+}
+
+var migrations = []migration{
+	{name: "money-decimal128", apply: migrateMoney},
+	{name: "order-currency", apply: migrateOrderCurrency},
+	{name: "order-tax", apply: migrateOrderTax},
+}
+
+// Migrate applies the migrations which weren't applied yet, in order.
+func Migrate(database *mongo.Database) {
+	for _, m := range migrations {
+		count, err := database.Collection("migrations").CountDocuments(context.Background(), bson.M{"_id": m.name})
+		if err != nil {
+			log.Fatalf("Error when reading migrations: %v", err.Error())
+		}
+		if count > 0 {
+			continue
+		}
+		err = m.apply(database)
+		if err != nil {
+			log.Fatalf("Error when applying migration %v: %v", m.name, err.Error())
+		}
+		_, err = database.Collection("migrations").InsertOne(context.Background(), bson.M{"_id": m.name, "applied": time.Now().Unix()})
+		if err != nil {
+			log.Fatalf("Error when recording migration %v: %v", m.name, err.Error())
+		}
+		log.Printf("Applied migration %v", m.name)
+	}
+}
+
+// numberTypes are the BSON types of the prices stored before they were Money.
+var numberTypes = bson.A{"double", "int", "long", "decimal"}
+
+// migrateMoney converts the float prices to Money in the store currency, rounded to its minor unit.
+// The updates only match numbers so a migration interrupted half way can be applied again.
+func migrateMoney(database *mongo.Database) error {
+	currency := model.StoreCurrency()
+	money := func(value string) bson.D {
+		return bson.D{
+			{Key: "amount", Value: bson.M{"$round": bson.A{bson.M{"$toDecimal": value}, model.CurrencyExponent(currency)}}},
+			{Key: "currency", Value: currency},
+		}
+	}
+	// moneyItems converts the price of every element of an array field
+	moneyItems := func(field string) bson.M {
+		return bson.M{"$map": bson.M{
+			"input": "$" + field,
+			"in": bson.M{"$cond": bson.A{
+				bson.M{"$in": bson.A{bson.M{"$type": "$$this.price"}, numberTypes}},
+				bson.M{"$mergeObjects": bson.A{"$$this", bson.M{"price": money("$$this.price")}}},
+				"$$this",
+			}},
+		}}
+	}
+	updates := []struct {
+		collection string
+		field      string
+		value      interface{}
+	}{
+		{"books", "price", money("$price")},
+		{"books", "variants", moneyItems("variants")},
+		{"orders", "total", money("$total")},
+		{"orders", "items", moneyItems("items")},
+		{"price-changes", "oldPrice", money("$oldPrice")},
+		{"price-changes", "newPrice", money("$newPrice")},
+		{"price-schedules", "price", money("$price")},
+		{"price-schedules", "previousPrice", money("$previousPrice")},
+	}
+	for _, update := range updates {
+		filter := bson.M{update.field: bson.M{"$type": numberTypes}}
+		if update.field == "variants" || update.field == "items" {
+			filter = bson.M{update.field + ".price": bson.M{"$type": numberTypes}}
+		}
+		pipeline := bson.A{bson.M{"$set": bson.M{update.field: update.value}}}
+		_, err := database.Collection(update.collection).UpdateMany(context.Background(), filter, pipeline)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateOrderCurrency sets the charged currency and the base total of the orders placed before
+// orders could be charged in another currency, they were charged in the base currency.
+func migrateOrderCurrency(database *mongo.Database) error {
+	filter := bson.M{"currency": bson.M{"$exists": false}}
+	pipeline := bson.A{bson.M{"$set": bson.M{"currency": "$total.currency", "baseTotal": "$total"}}}
+	_, err := database.Collection("orders").UpdateMany(context.Background(), filter, pipeline)
+	return err
+}
+
+// migrateOrderTax gives the orders placed before taxes were computed a breakdown without tax.
+func migrateOrderTax(database *mongo.Database) error {
+	zero, _ := primitive.ParseDecimal128("0")
+	filter := bson.M{"tax": bson.M{"$exists": false}}
+	pipeline := bson.A{bson.M{"$set": bson.M{"tax": bson.D{
+		{Key: "lines", Value: bson.M{"$literal": bson.A{}}},
+		{Key: "net", Value: "$total"},
+		{Key: "tax", Value: bson.D{{Key: "amount", Value: zero}, {Key: "currency", Value: "$total.currency"}}},
+		{Key: "gross", Value: "$total"},
+	}}}}
+	_, err := database.Collection("orders").UpdateMany(context.Background(), filter, pipeline)
+	return err
+}
@@ -0,0 +1,82 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ISO 3166-1 alpha-2 country codes
+var countryCodes = strings.Fields(`
+AD AE AF AG AI AL AM AO AQ AR AS AT AU AW AX AZ BA BB BD BE BF BG BH BI BJ BL BM BN BO BQ BR BS BT BV BW
+BY BZ CA CC CD CF CG CH CI CK CL CM CN CO CR CU CV CW CX CY CZ DE DJ DK DM DO DZ EC EE EG EH ER ES ET FI
+FJ FK FM FO FR GA GB GD GE GF GG GH GI GL GM GN GP GQ GR GS GT GU GW GY HK HM HN HR HT HU ID IE IL IM IN
+IO IQ IR IS IT JE JM JO JP KE KG KH KI KM KN KP KR KW KY KZ LA LB LC LI LK LR LS LT LU LV LY MA MC MD ME
+MF MG MH MK ML MM MN MO MP MQ MR MS MT MU MV MW MX MY MZ NA NC NE NF NG NI NL NO NP NR NU NZ OM PA PE PF
+PG PH PK PL PM PN PR PS PT PW PY QA RE RO RS RU RW SA SB SC SD SE SG SH SI SJ SK SL SM SN SO SR SS ST SV
+SX SY SZ TC TD TF TG TH TJ TK TL TM TN TO TR TT TV TW TZ UA UG UM US UY UZ VA VC VE VG VI VN VU WF WS YE
+YT ZA ZM ZW`)
+
+// postal code formats of the countries we ship to the most, other countries only get a loose check
+var postalCodePatterns = map[string]*regexp.Regexp{
+	"US": regexp.MustCompile(`^\d{5}(-\d{4})?$`),
+	"CA": regexp.MustCompile(`^[A-Za-z]\d[A-Za-z] ?\d[A-Za-z]\d$`),
+	"GB": regexp.MustCompile(`^[A-Za-z]{1,2}\d[A-Za-z\d]? ?\d[A-Za-z]{2}$`),
+	"DE": regexp.MustCompile(`^\d{5}$`),
+	"FR": regexp.MustCompile(`^\d{5}$`),
+	"IT": regexp.MustCompile(`^\d{5}$`),
+	"ES": regexp.MustCompile(`^\d{5}$`),
+	"NL": regexp.MustCompile(`^\d{4} ?[A-Za-z]{2}$`),
+	"JP": regexp.MustCompile(`^\d{3}-?\d{4}$`),
+	"AU": regexp.MustCompile(`^\d{4}$`),
+	"VN": regexp.MustCompile(`^\d{6}$`),
+}
+
+var defaultPostalCodePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9 -]{1,9}$`)
+
+func IsCountryCode(code string) bool {
+	for _, c := range countryCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+func ValidatePostalCode(country string, postalCode string) error {
+	pattern, ok := postalCodePatterns[country]
+	if !ok {
+		pattern = defaultPostalCodePattern
+	}
+	if !pattern.MatchString(postalCode) {
+		return fmt.Errorf("Invalid postal code %v for country %v", postalCode, country)
+	}
+	return nil
+}
+
+// Validate normalizes the country code and checks the required fields of an address.
+func (address *Address) Validate() error {
+	address.Country = strings.ToUpper(strings.TrimSpace(address.Country))
+	address.PostalCode = strings.TrimSpace(address.PostalCode)
+	if strings.TrimSpace(address.Name) == "" || strings.TrimSpace(address.Line1) == "" || strings.TrimSpace(address.City) == "" {
+		return fmt.Errorf("Name, line1 and city are required")
+	}
+	if !IsCountryCode(address.Country) {
+		return fmt.Errorf("Invalid country code %v", address.Country)
+	}
+	return ValidatePostalCode(address.Country, address.PostalCode)
+}
+
+// OrderAddress returns the copy of an address stored on an order.
+func (address Address) OrderAddress() *OrderAddress {
+	return &OrderAddress{
+		Name:       address.Name,
+		Line1:      address.Line1,
+		Line2:      address.Line2,
+		City:       address.City,
+		Region:     address.Region,
+		PostalCode: address.PostalCode,
+		Country:    address.Country,
+		Phone:      address.Phone,
+	}
+}
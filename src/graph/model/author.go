@@ -0,0 +1,107 @@
+package model
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var partialDatePattern = regexp.MustCompile(`^\d{4}(-\d{2}(-\d{2})?)?$`)
+
+// parsePartialDate accepts YYYY, YYYY-MM and YYYY-MM-DD, the missing parts default to the first month or day.
+func parsePartialDate(value string) (time.Time, error) {
+	if !partialDatePattern.MatchString(value) {
+		return time.Time{}, fmt.Errorf("Invalid date %v, expected YYYY, YYYY-MM or YYYY-MM-DD", value)
+	}
+	layout := "2006-01-02"[:len(value)]
+	date, err := time.Parse(layout, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Invalid date %v", value)
+	}
+	return date, nil
+}
+
+// Validate normalizes and checks the profile fields of an author.
+func (author *Author) Validate() error {
+	if strings.TrimSpace(author.Name) == "" {
+		return fmt.Errorf("Name is required")
+	}
+	var birth, death time.Time
+	var err error
+	if author.BirthDate != nil {
+		birth, err = parsePartialDate(*author.BirthDate)
+		if err != nil {
+			return err
+		}
+	}
+	if author.DeathDate != nil {
+		death, err = parsePartialDate(*author.DeathDate)
+		if err != nil {
+			return err
+		}
+	}
+	if author.BirthDate != nil && author.DeathDate != nil && death.Before(birth) {
+		return fmt.Errorf("Death date must not be before birth date")
+	}
+	if author.Nationality != nil {
+		nationality := strings.ToUpper(*author.Nationality)
+		if !IsCountryCode(nationality) {
+			return fmt.Errorf("Invalid nationality %v, expected an ISO 3166-1 alpha-2 country code", *author.Nationality)
+		}
+		author.Nationality = &nationality
+	}
+	if author.PhotoURL != nil {
+		photoURL, err := url.Parse(*author.PhotoURL)
+		if err != nil || (photoURL.Scheme != "http" && photoURL.Scheme != "https") || photoURL.Host == "" {
+			return fmt.Errorf("Invalid photo URL %v", *author.PhotoURL)
+		}
+	}
+	for _, externalID := range author.ExternalIds {
+		if strings.TrimSpace(externalID.Source) == "" || strings.TrimSpace(externalID.Value) == "" {
+			return fmt.Errorf("External identifiers need a source and a value")
+		}
+	}
+	return nil
+}
+
+func ExternalIDs(inputs []*ExternalIDInput) []*ExternalID {
+	externalIDs := []*ExternalID{}
+	for _, input := range inputs {
+		externalIDs = append(externalIDs, &ExternalID{Source: input.Source, Value: input.Value})
+	}
+	return externalIDs
+}
+
+// Optional turns an empty string into nil, it is how updates clear an optional field.
+func Optional(value *string) *string {
+	if value == nil || *value == "" {
+		return nil
+	}
+	return value
+}
+
+func (author *Author) ApplyUpdate(update AuthorUpdate) {
+	if update.Name != nil {
+		author.Name = *update.Name
+	}
+	if update.Bio != nil {
+		author.Bio = Optional(update.Bio)
+	}
+	if update.BirthDate != nil {
+		author.BirthDate = Optional(update.BirthDate)
+	}
+	if update.DeathDate != nil {
+		author.DeathDate = Optional(update.DeathDate)
+	}
+	if update.Nationality != nil {
+		author.Nationality = Optional(update.Nationality)
+	}
+	if update.PhotoURL != nil {
+		author.PhotoURL = Optional(update.PhotoURL)
+	}
+	if update.ExternalIds != nil {
+		author.ExternalIds = ExternalIDs(update.ExternalIds)
+	}
+}
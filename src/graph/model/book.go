@@ -0,0 +1,113 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// FieldError is a validation error of an input field, the field is returned in the error extensions.
+func FieldError(field string, format string, args ...interface{}) *gqlerror.Error {
+	return &gqlerror.Error{
+		Message: fmt.Sprintf(format, args...),
+		Extensions: map[string]interface{}{
+			"code":  "BAD_USER_INPUT",
+			"field": field,
+		},
+	}
+}
+
+var languagePattern = regexp.MustCompile(`^[a-z]{2}$`)
+
+// Validate normalizes a new book and returns an error for every invalid field.
+func (input *NewBook) Validate() []*gqlerror.Error {
+	var errs []*gqlerror.Error
+	input.Name = strings.TrimSpace(input.Name)
+	if input.Name == "" {
+		errs = append(errs, FieldError("name", "Name must not be empty"))
+	}
+	if !validCatalogPrice(input.Price) {
+		errs = append(errs, FieldError("price", "Price must be a positive amount in %v", StoreCurrency()))
+	}
+	input.Isbn = Optional(input.Isbn)
+	input.PublisherID = Optional(input.PublisherID)
+	input.PublicationDate = Optional(input.PublicationDate)
+	input.Language = Optional(input.Language)
+	input.Edition = Optional(input.Edition)
+	return append(errs, validateEdition(input.Isbn, input.PublicationDate, input.Language, input.PageCount)...)
+}
+
+// Validate normalizes a book update and returns an error for every invalid field.
+func (update *BookUpdate) Validate() []*gqlerror.Error {
+	var errs []*gqlerror.Error
+	if update.Name != nil {
+		name := strings.TrimSpace(*update.Name)
+		if name == "" {
+			errs = append(errs, FieldError("name", "Name must not be empty"))
+		}
+		update.Name = &name
+	}
+	if update.Price != nil && !validCatalogPrice(*update.Price) {
+		errs = append(errs, FieldError("price", "Price must be a positive amount in %v", StoreCurrency()))
+	}
+	errs = append(errs, validateEdition(update.Isbn, update.PublicationDate, update.Language, update.PageCount)...)
+	if id := firstCommon(update.AddingTopicsID, update.RemovingTopicsID); id != "" {
+		errs = append(errs, FieldError("removingTopicsId", "Topic %v can't be added and removed at once", id))
+	}
+	if id := firstCommon(update.AddingAuthorsID, update.RemovingAuthorsID); id != "" {
+		errs = append(errs, FieldError("removingAuthorsId", "Author %v can't be added and removed at once", id))
+	}
+	return errs
+}
+
+// validateEdition normalizes and checks the edition metadata of a book,
+// an empty string is accepted since it clears the field in an update.
+func validateEdition(isbn *string, publicationDate *string, language *string, pageCount *int64) []*gqlerror.Error {
+	var errs []*gqlerror.Error
+	if isbn != nil && *isbn != "" {
+		normalized, err := NormalizeISBN(*isbn)
+		if err != nil {
+			errs = append(errs, FieldError("isbn", err.Error()))
+		} else {
+			*isbn = normalized
+		}
+	}
+	if publicationDate != nil && *publicationDate != "" {
+		_, err := parsePartialDate(*publicationDate)
+		if err != nil {
+			errs = append(errs, FieldError("publicationDate", err.Error()))
+		}
+	}
+	if language != nil && *language != "" {
+		*language = strings.ToLower(*language)
+		if !languagePattern.MatchString(*language) {
+			errs = append(errs, FieldError("language", "Invalid language %v, expected an ISO 639-1 language code", *language))
+		}
+	}
+	if pageCount != nil && *pageCount <= 0 {
+		errs = append(errs, FieldError("pageCount", "Page count must be a positive number"))
+	}
+	return errs
+}
+
+func validPrice(price Money) bool {
+	return price.Amount >= 0 && ValidCurrency(price.Currency)
+}
+
+// validCatalogPrice checks a price of a book or a variant, the catalog is priced in the store currency.
+func validCatalogPrice(price Money) bool {
+	return price.Amount >= 0 && price.Currency == StoreCurrency()
+}
+
+func firstCommon(a []string, b []string) string {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return x
+			}
+		}
+	}
+	return ""
+}
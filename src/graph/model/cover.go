@@ -0,0 +1,24 @@
+package model
+
+// CoverImage is how the cover of a book is stored, under the coverImage field of the book.
+type CoverImage struct {
+	Renditions []*CoverRendition `bson:"renditions"`
+	Updated    int64             `bson:"updated"`
+}
+
+type CoverRendition struct {
+	Size   CoverSize `bson:"size"`
+	Key    string    `bson:"key"`
+	Width  int64     `bson:"width"`
+	Height int64     `bson:"height"`
+}
+
+// Rendition returns the rendition of the given size, or nil.
+func (image *CoverImage) Rendition(size CoverSize) *CoverRendition {
+	for _, rendition := range image.Renditions {
+		if rendition.Size == size {
+			return rendition
+		}
+	}
+	return nil
+}
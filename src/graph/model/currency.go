@@ -0,0 +1,85 @@
+package model
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Validate normalizes a new exchange rate and returns an error for every invalid field.
+func (input *NewExchangeRate) Validate() []*gqlerror.Error {
+	var errs []*gqlerror.Error
+	input.Currency = strings.ToUpper(strings.TrimSpace(input.Currency))
+	if !ValidCurrency(input.Currency) {
+		errs = append(errs, FieldError("currency", "Invalid currency %v, expected an ISO 4217 currency code", input.Currency))
+	} else if input.Currency == StoreCurrency() {
+		errs = append(errs, FieldError("currency", "%v is the base currency", input.Currency))
+	}
+	input.Rate = strings.TrimSpace(input.Rate)
+	if !IsDecimal(input.Rate) || input.Ratio().Sign() <= 0 {
+		errs = append(errs, FieldError("rate", "Rate must be a positive decimal number"))
+	}
+	if !input.Rounding.IsValid() {
+		errs = append(errs, FieldError("rounding", "Invalid rounding %v", input.Rounding))
+	}
+	if input.RoundingIncrement <= 0 {
+		errs = append(errs, FieldError("roundingIncrement", "Rounding increment must be a positive number"))
+	}
+	return errs
+}
+
+// Ratio is the rate as an exact fraction.
+func (rate *ExchangeRate) Ratio() *big.Rat {
+	return decimalRatio(rate.Rate)
+}
+
+func (input *NewExchangeRate) Ratio() *big.Rat {
+	return decimalRatio(input.Rate)
+}
+
+func decimalRatio(decimal string) *big.Rat {
+	ratio, ok := new(big.Rat).SetString(decimal)
+	if !ok {
+		return new(big.Rat)
+	}
+	return ratio
+}
+
+// Rat is the amount in major units as an exact fraction.
+func (m Money) Rat() *big.Rat {
+	return new(big.Rat).SetFrac(big.NewInt(m.Amount), pow10(CurrencyExponent(m.Currency)))
+}
+
+// RoundMoney rounds an amount in major units to a multiple of increment minor units of currency.
+func RoundMoney(value *big.Rat, currency string, mode RoundingMode, increment int64) Money {
+	if increment <= 0 {
+		increment = 1
+	}
+	units := new(big.Rat).Mul(value, new(big.Rat).SetInt(pow10(CurrencyExponent(currency))))
+	units.Quo(units, new(big.Rat).SetInt64(increment))
+	negative := units.Sign() < 0
+	units.Abs(units)
+	quotient, remainder := new(big.Int).QuoRem(units.Num(), units.Denom(), new(big.Int))
+	if remainder.Sign() != 0 {
+		// compares the remainder to half of the denominator
+		half := new(big.Int).Mul(remainder, big.NewInt(2)).Cmp(units.Denom())
+		roundUp := false
+		switch mode {
+		case RoundingModeUp:
+			roundUp = true
+		case RoundingModeHalfUp:
+			roundUp = half >= 0
+		case RoundingModeHalfEven:
+			roundUp = half > 0 || (half == 0 && quotient.Bit(0) == 1)
+		}
+		if roundUp {
+			quotient.Add(quotient, big.NewInt(1))
+		}
+	}
+	amount := quotient.Int64() * increment
+	if negative {
+		amount = -amount
+	}
+	return Money{Amount: amount, Currency: currency}
+}
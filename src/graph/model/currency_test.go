@@ -0,0 +1,44 @@
+package model
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestRoundMoney(t *testing.T) {
+	cases := []struct {
+		value     string
+		currency  string
+		mode      RoundingMode
+		increment int64
+		expected  int64
+	}{
+		{"12.345", "EUR", RoundingModeHalfUp, 1, 1235},
+		{"12.345", "EUR", RoundingModeHalfEven, 1, 1234},
+		{"12.355", "EUR", RoundingModeHalfEven, 1, 1236},
+		{"12.341", "EUR", RoundingModeUp, 1, 1235},
+		{"12.349", "EUR", RoundingModeDown, 1, 1234},
+		{"12.37", "CHF", RoundingModeHalfUp, 5, 1235},
+		{"12.38", "CHF", RoundingModeHalfUp, 5, 1240},
+		{"1499.5", "JPY", RoundingModeHalfUp, 1, 1500},
+		{"-12.345", "EUR", RoundingModeHalfUp, 1, -1235},
+	}
+	for _, c := range cases {
+		value, _ := new(big.Rat).SetString(c.value)
+		money := RoundMoney(value, c.currency, c.mode, c.increment)
+		if money.Amount != c.expected || money.Currency != c.currency {
+			t.Fatalf("RoundMoney(%v, %v, %v, %v) = %v, expected %v", c.value, c.currency, c.mode, c.increment, money, c.expected)
+		}
+	}
+}
+
+func TestNewExchangeRateValidate(t *testing.T) {
+	input := NewExchangeRate{Currency: " eur ", Rate: "0.9215", Rounding: RoundingModeHalfUp, RoundingIncrement: 1}
+	if errs := input.Validate(); len(errs) > 0 || input.Currency != "EUR" {
+		t.Fatalf("unexpected errors %v for %+v", errs, input)
+	}
+	input = NewExchangeRate{Currency: StoreCurrency(), Rate: "-1", Rounding: "NEAREST", RoundingIncrement: 0}
+	if errs := input.Validate(); len(errs) != 4 {
+		t.Fatalf("expected 4 errors, got %v", errs)
+	}
+}
@@ -0,0 +1,56 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NormalizeISBN checks the checksum of an ISBN-10 or ISBN-13, hyphens and spaces are ignored,
+// and returns it as an ISBN-13 so both forms of the same book compare equal.
+func NormalizeISBN(value string) (string, error) {
+	isbn := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(value))
+	switch len(isbn) {
+	case 10:
+		sum := 0
+		for i, c := range isbn {
+			digit := int(c - '0')
+			if c == 'X' && i == 9 {
+				digit = 10
+			} else if c < '0' || c > '9' {
+				return "", fmt.Errorf("Invalid ISBN %v", value)
+			}
+			sum += (10 - i) * digit
+		}
+		if sum%11 != 0 {
+			return "", fmt.Errorf("Invalid ISBN %v, wrong check digit", value)
+		}
+		isbn13 := "978" + isbn[:9]
+		return isbn13 + isbn13CheckDigit(isbn13), nil
+	case 13:
+		for _, c := range isbn {
+			if c < '0' || c > '9' {
+				return "", fmt.Errorf("Invalid ISBN %v", value)
+			}
+		}
+		if !strings.HasPrefix(isbn, "978") && !strings.HasPrefix(isbn, "979") {
+			return "", fmt.Errorf("Invalid ISBN %v, an ISBN-13 starts with 978 or 979", value)
+		}
+		if isbn13CheckDigit(isbn[:12]) != isbn[12:] {
+			return "", fmt.Errorf("Invalid ISBN %v, wrong check digit", value)
+		}
+		return isbn, nil
+	}
+	return "", fmt.Errorf("Invalid ISBN %v, expected 10 or 13 digits", value)
+}
+
+func isbn13CheckDigit(digits string) string {
+	sum := 0
+	for i, c := range digits {
+		weight := 1
+		if i%2 == 1 {
+			weight = 3
+		}
+		sum += weight * int(c-'0')
+	}
+	return fmt.Sprint((10 - sum%10) % 10)
+}
@@ -0,0 +1,42 @@
+package model
+
+import "testing"
+
+func TestNormalizeISBN(t *testing.T) {
+	cases := []struct {
+		value    string
+		expected string
+	}{
+		{"9780306406157", "9780306406157"},
+		{"978-0-306-40615-7", "9780306406157"},
+		{"978 0 306 40615 7", "9780306406157"},
+		{"0306406152", "9780306406157"},
+		{"0-306-40615-2", "9780306406157"},
+		{"080442957X", "9780804429573"},
+		{"0-8044-2957-x", "9780804429573"},
+		{"9791036901997", "9791036901997"},
+	}
+	for _, c := range cases {
+		isbn, err := NormalizeISBN(c.value)
+		if err != nil || isbn != c.expected {
+			t.Fatalf("NormalizeISBN(%v) = %v, %v, expected %v", c.value, isbn, err, c.expected)
+		}
+	}
+	invalid := []string{
+		"",
+		"0306406153",     // wrong check digit
+		"9780306406158",  // wrong check digit
+		"X306406152",     // X only as the check digit of an ISBN-10
+		"03064061X2",     // X only as the check digit of an ISBN-10
+		"978030640615X",  // no X in an ISBN-13
+		"9770306406157",  // neither 978 nor 979
+		"978-0-306-4061", // too short
+		"97803064061577", // too long
+		"03064O6152",     // a letter O
+	}
+	for _, value := range invalid {
+		if _, err := NormalizeISBN(value); err == nil {
+			t.Fatalf("NormalizeISBN(%v) should fail", value)
+		}
+	}
+}
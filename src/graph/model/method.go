@@ -9,15 +9,24 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
-func (user User) CreateJWT() (string, error) {
+// AccessTokenType is the typ claim of the tokens which authenticate requests.
+const AccessTokenType = "access"
+
+// CreateJWT embeds the permissions granted at login for the clients, requests are authorized
+// with the role and permissions read again from the account.
+func (user User) CreateJWT(twoFactor bool, permissions []Permission) (string, error) {
 	jwtLifeTime, err := strconv.Atoi(os.Getenv("JWT_LIFE_TIME"))
 	if err != nil {
 		return "", err
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"typ":   AccessTokenType,
 		"uid":   user.ID,
 		"email": user.Email,
 		"role":  user.Role,
+		"mfa":   twoFactor,
+		"perms": permissions,
+		"iat":   time.Now().Unix(),
 		"exp":   time.Now().Add(time.Hour * 24 * time.Duration(jwtLifeTime)).Unix(),
 	})
 	return token.SignedString([]byte(os.Getenv("JWT_SECRET")))
@@ -8,41 +8,147 @@ import (
 	"strconv"
 )
 
+type Address struct {
+	ID              string  `json:"id" bson:"_id"`
+	UserID          string  `json:"userId"`
+	Name            string  `json:"name"`
+	Line1           string  `json:"line1"`
+	Line2           *string `json:"line2"`
+	City            string  `json:"city"`
+	Region          *string `json:"region"`
+	PostalCode      string  `json:"postalCode"`
+	Country         string  `json:"country"`
+	Phone           *string `json:"phone"`
+	DefaultShipping bool    `json:"defaultShipping"`
+	DefaultBilling  bool    `json:"defaultBilling"`
+	Created         int64   `json:"created"`
+	Updated         int64   `json:"updated"`
+}
+
+type AddressUpdate struct {
+	Name            *string `json:"name"`
+	Line1           *string `json:"line1"`
+	Line2           *string `json:"line2"`
+	City            *string `json:"city"`
+	Region          *string `json:"region"`
+	PostalCode      *string `json:"postalCode"`
+	Country         *string `json:"country"`
+	Phone           *string `json:"phone"`
+	DefaultShipping *bool   `json:"defaultShipping"`
+	DefaultBilling  *bool   `json:"defaultBilling"`
+}
+
+type APIKey struct {
+	ID          string       `json:"id" bson:"_id"`
+	Name        string       `json:"name"`
+	Prefix      string       `json:"prefix"`
+	Permissions []Permission `json:"permissions"`
+	Expires     *int64       `json:"expires"`
+	LastUsed    *int64       `json:"lastUsed"`
+	Revoked     bool         `json:"revoked"`
+	CreatedBy   string       `json:"createdBy"`
+	Created     int64        `json:"created"`
+	Updated     int64        `json:"updated"`
+}
+
 type Author struct {
-	ID      string  `json:"id" bson:"_id"`
-	Name    string  `json:"name"`
-	Created int64   `json:"created"`
-	Updated int64   `json:"updated"`
-	Books   []*Book `json:"books"`
+	ID          string        `json:"id" bson:"_id"`
+	Name        string        `json:"name"`
+	Bio         *string       `json:"bio"`
+	BirthDate   *string       `json:"birthDate"`
+	DeathDate   *string       `json:"deathDate"`
+	Nationality *string       `json:"nationality"`
+	PhotoURL    *string       `json:"photoUrl"`
+	ExternalIds []*ExternalID `json:"externalIds"`
+	Created     int64         `json:"created"`
+	Updated     int64         `json:"updated"`
+	Books       []*Book       `json:"books"`
+}
+
+type AuthorUpdate struct {
+	Name        *string            `json:"name"`
+	Bio         *string            `json:"bio"`
+	BirthDate   *string            `json:"birthDate"`
+	DeathDate   *string            `json:"deathDate"`
+	Nationality *string            `json:"nationality"`
+	PhotoURL    *string            `json:"photoUrl"`
+	ExternalIds []*ExternalIDInput `json:"externalIds"`
 }
 
 type Book struct {
-	ID        string    `json:"id" bson:"_id"`
-	Name      string    `json:"name"`
-	Price     float64   `json:"price"`
-	Content   string    `json:"content"`
-	Created   int64     `json:"created"`
-	Updated   int64     `json:"updated"`
-	TopicsID  []string  `json:"topicsId"`
-	AuthorsID []string  `json:"authorsId"`
-	Topics    []*Topic  `json:"topics"`
-	Authors   []*Author `json:"authors"`
-	Reviews   []*Review `json:"reviews"`
+	ID               string           `json:"id" bson:"_id"`
+	Name             string           `json:"name"`
+	Price            Money            `json:"price"`
+	Content          string           `json:"content"`
+	Isbn             *string          `json:"isbn"`
+	PublisherID      *string          `json:"publisherId"`
+	PublicationDate  *string          `json:"publicationDate"`
+	Language         *string          `json:"language"`
+	PageCount        *int64           `json:"pageCount"`
+	Format           *BookFormat      `json:"format"`
+	Edition          *string          `json:"edition"`
+	SeriesID         *string          `json:"seriesId"`
+	SeriesPosition   *float64         `json:"seriesPosition"`
+	Created          int64            `json:"created"`
+	Updated          int64            `json:"updated"`
+	TopicsID         []string         `json:"topicsId"`
+	AuthorsID        []string         `json:"authorsId"`
+	Variants         []*BookVariant   `json:"variants"`
+	Cover            *Cover           `json:"cover"`
+	Publisher        *Publisher       `json:"publisher"`
+	Series           *Series          `json:"series"`
+	NextInSeries     *Book            `json:"nextInSeries"`
+	PreviousInSeries *Book            `json:"previousInSeries"`
+	Topics           []*Topic         `json:"topics"`
+	Authors          []*Author        `json:"authors"`
+	Reviews          []*Review        `json:"reviews"`
+	PriceHistory     []*PriceChange   `json:"priceHistory"`
+	PriceSchedules   []*PriceSchedule `json:"priceSchedules"`
+	LocalPrice       Money            `json:"localPrice"`
 }
 
 type BookUpdate struct {
-	Name              *string  `json:"name"`
-	Content           *string  `json:"content"`
-	AddingTopicsID    []string `json:"addingTopicsId"`
-	RemovingTopicsID  []string `json:"removingTopicsId"`
-	AddingAuthorsID   []string `json:"addingAuthorsId"`
-	RemovingAuthorsID []string `json:"removingAuthorsId"`
+	Name              *string     `json:"name"`
+	Price             *Money      `json:"price"`
+	Content           *string     `json:"content"`
+	Isbn              *string     `json:"isbn"`
+	PublisherID       *string     `json:"publisherId"`
+	PublicationDate   *string     `json:"publicationDate"`
+	Language          *string     `json:"language"`
+	PageCount         *int64      `json:"pageCount"`
+	Format            *BookFormat `json:"format"`
+	Edition           *string     `json:"edition"`
+	AddingTopicsID    []string    `json:"addingTopicsId"`
+	RemovingTopicsID  []string    `json:"removingTopicsId"`
+	AddingAuthorsID   []string    `json:"addingAuthorsId"`
+	RemovingAuthorsID []string    `json:"removingAuthorsId"`
+}
+
+type BookVariant struct {
+	ID         string     `json:"id" bson:"_id"`
+	Sku        string     `json:"sku"`
+	Format     BookFormat `json:"format"`
+	Price      Money      `json:"price"`
+	Stock      int64      `json:"stock"`
+	Weight     *int64     `json:"weight"`
+	LocalPrice Money      `json:"localPrice"`
+}
+
+type BookVariantUpdate struct {
+	Sku    *string     `json:"sku"`
+	Format *BookFormat `json:"format"`
+	Price  *Money      `json:"price"`
+	Stock  *int64      `json:"stock"`
+	Weight *int64      `json:"weight"`
 }
 
 type Cart struct {
-	ID     string      `json:"id" bson:"_id"`
-	UserID string      `json:"userId"`
-	Items  []*CartItem `json:"items"`
+	ID              string           `json:"id" bson:"_id"`
+	UserID          string           `json:"userId"`
+	Items           []*CartItem      `json:"items"`
+	Total           Money            `json:"total"`
+	Tax             *TaxBreakdown    `json:"tax"`
+	ShippingOptions []*ShippingQuote `json:"shippingOptions"`
 }
 
 type CartData struct {
@@ -50,14 +156,122 @@ type CartData struct {
 }
 
 type CartDataItem struct {
-	BookID   string `json:"bookId"`
-	Quantity int64  `json:"quantity"`
+	BookID    string  `json:"bookId"`
+	VariantID *string `json:"variantId"`
+	Quantity  int64   `json:"quantity"`
 }
 
 type CartItem struct {
-	BookID   string `json:"bookId"`
-	Quantity int64  `json:"quantity"`
-	Book     *Book  `json:"book"`
+	BookID    string       `json:"bookId"`
+	VariantID *string      `json:"variantId"`
+	Quantity  int64        `json:"quantity"`
+	Book      *Book        `json:"book"`
+	Variant   *BookVariant `json:"variant"`
+	Price     *Money       `json:"price"`
+}
+
+type Cover struct {
+	URL    string `json:"url"`
+	Width  int64  `json:"width"`
+	Height int64  `json:"height"`
+}
+
+type DataExport struct {
+	UserID  string `json:"userId"`
+	Created int64  `json:"created"`
+	Data    string `json:"data"`
+}
+
+type ExchangeRate struct {
+	ID                string       `json:"id" bson:"_id"`
+	Currency          string       `json:"currency"`
+	Rate              string       `json:"rate"`
+	EffectiveFrom     int64        `json:"effectiveFrom"`
+	Rounding          RoundingMode `json:"rounding"`
+	RoundingIncrement int64        `json:"roundingIncrement"`
+	UserID            string       `json:"userId"`
+	Created           int64        `json:"created"`
+}
+
+type ExportJob struct {
+	ID          string          `json:"id" bson:"_id"`
+	UserID      string          `json:"userId"`
+	Format      ExportFormat    `json:"format"`
+	Status      ExportJobStatus `json:"status"`
+	Error       *string         `json:"error"`
+	Total       int64           `json:"total"`
+	Created     int64           `json:"created"`
+	Updated     int64           `json:"updated"`
+	DownloadURL *string         `json:"downloadUrl"`
+}
+
+type ExternalID struct {
+	Source string `json:"source"`
+	Value  string `json:"value"`
+}
+
+type ExternalIDInput struct {
+	Source string `json:"source"`
+	Value  string `json:"value"`
+}
+
+type ImportJob struct {
+	ID           string            `json:"id" bson:"_id"`
+	UserID       string            `json:"userId"`
+	FileName     string            `json:"fileName"`
+	Format       ImportFormat      `json:"format"`
+	DryRun       bool              `json:"dryRun"`
+	Status       ImportJobStatus   `json:"status"`
+	Error        *string           `json:"error"`
+	Total        int64             `json:"total"`
+	Processed    int64             `json:"processed"`
+	BooksCreated int64             `json:"booksCreated"`
+	BooksUpdated int64             `json:"booksUpdated"`
+	Failed       int64             `json:"failed"`
+	RowErrors    []*ImportRowError `json:"rowErrors"`
+	Created      int64             `json:"created"`
+	Updated      int64             `json:"updated"`
+}
+
+type ImportRowError struct {
+	Row     int64   `json:"row"`
+	Isbn    *string `json:"isbn"`
+	Message string  `json:"message"`
+}
+
+type IntegrityIssue struct {
+	Collection string             `json:"collection"`
+	DocumentID string             `json:"documentId"`
+	Field      string             `json:"field"`
+	Reference  string             `json:"reference"`
+	Kind       IntegrityIssueKind `json:"kind"`
+}
+
+type IntegrityReport struct {
+	Checked int64             `json:"checked"`
+	Issues  []*IntegrityIssue `json:"issues"`
+}
+
+type Invoice struct {
+	ID          string        `json:"id" bson:"_id"`
+	Kind        InvoiceKind   `json:"kind"`
+	Number      string        `json:"number"`
+	OrderID     string        `json:"orderId"`
+	UserID      string        `json:"userId"`
+	InvoiceID   *string       `json:"invoiceId"`
+	Items       []*OrderItem  `json:"items"`
+	Tax         *TaxBreakdown `json:"tax"`
+	Shipping    *Money        `json:"shipping"`
+	Total       Money         `json:"total"`
+	Reason      *string       `json:"reason"`
+	RefundID    *string       `json:"refundId"`
+	Created     int64         `json:"created"`
+	DownloadURL *string       `json:"downloadUrl"`
+}
+
+type IssuedAPIKey struct {
+	Key    string  `json:"key"`
+	APIKey *APIKey `json:"apiKey"`
 }
 
 type Login struct {
@@ -65,16 +279,90 @@ type Login struct {
 	Password string `json:"password"`
 }
 
+type LoginResult struct {
+	Token             string `json:"token"`
+	TwoFactorRequired bool   `json:"twoFactorRequired"`
+}
+
+type NewAddress struct {
+	Name            string  `json:"name"`
+	Line1           string  `json:"line1"`
+	Line2           *string `json:"line2"`
+	City            string  `json:"city"`
+	Region          *string `json:"region"`
+	PostalCode      string  `json:"postalCode"`
+	Country         string  `json:"country"`
+	Phone           *string `json:"phone"`
+	DefaultShipping *bool   `json:"defaultShipping"`
+	DefaultBilling  *bool   `json:"defaultBilling"`
+}
+
+type NewAPIKey struct {
+	Name        string       `json:"name"`
+	Permissions []Permission `json:"permissions"`
+	Expires     *int64       `json:"expires"`
+}
+
 type NewAuthor struct {
-	Name string `json:"name"`
+	Name        string             `json:"name"`
+	Bio         *string            `json:"bio"`
+	BirthDate   *string            `json:"birthDate"`
+	DeathDate   *string            `json:"deathDate"`
+	Nationality *string            `json:"nationality"`
+	PhotoURL    *string            `json:"photoUrl"`
+	ExternalIds []*ExternalIDInput `json:"externalIds"`
 }
 
 type NewBook struct {
-	Name      string   `json:"name"`
-	Price     float64  `json:"price"`
-	Content   string   `json:"content"`
-	TopicsID  []string `json:"topicsId"`
-	AuthorsID []string `json:"authorsId"`
+	Name            string      `json:"name"`
+	Price           Money       `json:"price"`
+	Content         string      `json:"content"`
+	Isbn            *string     `json:"isbn"`
+	PublisherID     *string     `json:"publisherId"`
+	PublicationDate *string     `json:"publicationDate"`
+	Language        *string     `json:"language"`
+	PageCount       *int64      `json:"pageCount"`
+	Format          *BookFormat `json:"format"`
+	Edition         *string     `json:"edition"`
+	TopicsID        []string    `json:"topicsId"`
+	AuthorsID       []string    `json:"authorsId"`
+}
+
+type NewBookVariant struct {
+	Sku    string     `json:"sku"`
+	Format BookFormat `json:"format"`
+	Price  Money      `json:"price"`
+	Stock  int64      `json:"stock"`
+	Weight *int64     `json:"weight"`
+}
+
+type NewExchangeRate struct {
+	Currency          string       `json:"currency"`
+	Rate              string       `json:"rate"`
+	EffectiveFrom     *int64       `json:"effectiveFrom"`
+	Rounding          RoundingMode `json:"rounding"`
+	RoundingIncrement int64        `json:"roundingIncrement"`
+}
+
+type NewOrder struct {
+	ShippingAddressID *string `json:"shippingAddressId"`
+	BillingAddressID  *string `json:"billingAddressId"`
+	Currency          *string `json:"currency"`
+	ShippingMethodID  *string `json:"shippingMethodId"`
+}
+
+type NewPriceSchedule struct {
+	BookID    string  `json:"bookId"`
+	VariantID *string `json:"variantId"`
+	Price     Money   `json:"price"`
+	StartsAt  int64   `json:"startsAt"`
+	EndsAt    *int64  `json:"endsAt"`
+}
+
+type NewPublisher struct {
+	Name    string  `json:"name"`
+	Website *string `json:"website"`
+	Country *string `json:"country"`
 }
 
 type NewReview struct {
@@ -82,15 +370,160 @@ type NewReview struct {
 	BookID  string `json:"bookId"`
 }
 
+type NewSeries struct {
+	Name        string  `json:"name"`
+	Description *string `json:"description"`
+}
+
+type NewStaffRole struct {
+	Name        string       `json:"name"`
+	Permissions []Permission `json:"permissions"`
+}
+
 type NewTopic struct {
-	Name string `json:"name"`
+	Name     string  `json:"name"`
+	ParentID *string `json:"parentId"`
 }
 
 type NewUser struct {
 	Name     string `json:"name"`
 	Email    string `json:"email"`
 	Password string `json:"password"`
-	Role     Role   `json:"role"`
+	Role     *Role  `json:"role"`
+}
+
+type Order struct {
+	ID              string         `json:"id" bson:"_id"`
+	UserID          string         `json:"userId"`
+	Items           []*OrderItem   `json:"items"`
+	Total           Money          `json:"total"`
+	Tax             *TaxBreakdown  `json:"tax"`
+	Shipping        *OrderShipping `json:"shipping"`
+	Currency        string         `json:"currency"`
+	BaseTotal       Money          `json:"baseTotal"`
+	ExchangeRate    *string        `json:"exchangeRate"`
+	Status          OrderStatus    `json:"status"`
+	ShippingAddress *OrderAddress  `json:"shippingAddress"`
+	BillingAddress  *OrderAddress  `json:"billingAddress"`
+	Created         int64          `json:"created"`
+	Updated         int64          `json:"updated"`
+	InvoiceURL      *string        `json:"invoiceUrl"`
+	CreditNotes     []*Invoice     `json:"creditNotes"`
+	Returns         []*Return      `json:"returns"`
+}
+
+type OrderAddress struct {
+	Name       string  `json:"name"`
+	Line1      string  `json:"line1"`
+	Line2      *string `json:"line2"`
+	City       string  `json:"city"`
+	Region     *string `json:"region"`
+	PostalCode string  `json:"postalCode"`
+	Country    string  `json:"country"`
+	Phone      *string `json:"phone"`
+}
+
+type OrderItem struct {
+	BookID    string      `json:"bookId"`
+	VariantID *string     `json:"variantId"`
+	Sku       *string     `json:"sku"`
+	Format    *BookFormat `json:"format"`
+	Name      string      `json:"name"`
+	Price     Money       `json:"price"`
+	Quantity  int64       `json:"quantity"`
+	Weight    *int64      `json:"weight"`
+}
+
+type OrderShipping struct {
+	MethodID string `json:"methodId"`
+	Name     string `json:"name"`
+	Zone     string `json:"zone"`
+	Cost     Money  `json:"cost"`
+	MinDays  *int64 `json:"minDays"`
+	MaxDays  *int64 `json:"maxDays"`
+}
+
+type Pagination struct {
+	Limit  *int64 `json:"limit"`
+	Offset *int64 `json:"offset"`
+}
+
+type PriceChange struct {
+	ID         string            `json:"id" bson:"_id"`
+	BookID     string            `json:"bookId"`
+	VariantID  *string           `json:"variantId"`
+	OldPrice   *Money            `json:"oldPrice"`
+	NewPrice   Money             `json:"newPrice"`
+	Reason     PriceChangeReason `json:"reason"`
+	UserID     *string           `json:"userId"`
+	APIKeyID   *string           `json:"apiKeyId"`
+	ScheduleID *string           `json:"scheduleId"`
+	Created    int64             `json:"created"`
+}
+
+type PriceSchedule struct {
+	ID            string              `json:"id" bson:"_id"`
+	BookID        string              `json:"bookId"`
+	VariantID     *string             `json:"variantId"`
+	Price         Money               `json:"price"`
+	StartsAt      int64               `json:"startsAt"`
+	EndsAt        *int64              `json:"endsAt"`
+	Status        PriceScheduleStatus `json:"status"`
+	PreviousPrice *Money              `json:"previousPrice"`
+	UserID        *string             `json:"userId"`
+	APIKeyID      *string             `json:"apiKeyId"`
+	Created       int64               `json:"created"`
+	Updated       int64               `json:"updated"`
+}
+
+type ProfileUpdate struct {
+	Name  *string `json:"name"`
+	Email *string `json:"email"`
+}
+
+type Publisher struct {
+	ID      string  `json:"id" bson:"_id"`
+	Name    string  `json:"name"`
+	Website *string `json:"website"`
+	Country *string `json:"country"`
+	Created int64   `json:"created"`
+	Updated int64   `json:"updated"`
+	Books   []*Book `json:"books"`
+}
+
+type PublisherUpdate struct {
+	Name    *string `json:"name"`
+	Website *string `json:"website"`
+	Country *string `json:"country"`
+}
+
+type Return struct {
+	ID           string        `json:"id" bson:"_id"`
+	OrderID      string        `json:"orderId"`
+	UserID       string        `json:"userId"`
+	Lines        []*ReturnLine `json:"lines"`
+	Reason       string        `json:"reason"`
+	Status       ReturnStatus  `json:"status"`
+	Note         *string       `json:"note"`
+	Restocked    bool          `json:"restocked"`
+	CreditNoteID *string       `json:"creditNoteId"`
+	Refund       *Money        `json:"refund"`
+	Error        *string       `json:"error"`
+	Created      int64         `json:"created"`
+	Updated      int64         `json:"updated"`
+}
+
+type ReturnLine struct {
+	BookID    string  `json:"bookId"`
+	VariantID *string `json:"variantId"`
+	Name      string  `json:"name"`
+	Quantity  int64   `json:"quantity"`
+}
+
+type ReturnLineInput struct {
+	BookID    string  `json:"bookId"`
+	VariantID *string `json:"variantId"`
+	Quantity  int64   `json:"quantity"`
 }
 
 type Review struct {
@@ -102,22 +535,126 @@ type Review struct {
 	UserID  string `json:"userId"`
 }
 
+type Series struct {
+	ID          string  `json:"id" bson:"_id"`
+	Name        string  `json:"name"`
+	Description *string `json:"description"`
+	Created     int64   `json:"created"`
+	Updated     int64   `json:"updated"`
+	Books       []*Book `json:"books"`
+}
+
+type SeriesUpdate struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+}
+
+type ShippingMethod struct {
+	ID         string           `json:"id" bson:"_id"`
+	Name       string           `json:"name"`
+	Type       ShippingRateType `json:"type"`
+	WeightStep *int64           `json:"weightStep"`
+	Zones      []*ShippingZone  `json:"zones"`
+	MinDays    *int64           `json:"minDays"`
+	MaxDays    *int64           `json:"maxDays"`
+	Active     bool             `json:"active"`
+	Created    int64            `json:"created"`
+	Updated    int64            `json:"updated"`
+}
+
+type ShippingMethodInput struct {
+	Name       string               `json:"name"`
+	Type       ShippingRateType     `json:"type"`
+	WeightStep *int64               `json:"weightStep"`
+	Zones      []*ShippingZoneInput `json:"zones"`
+	MinDays    *int64               `json:"minDays"`
+	MaxDays    *int64               `json:"maxDays"`
+	Active     bool                 `json:"active"`
+}
+
+type ShippingQuote struct {
+	Method  *ShippingMethod `json:"method"`
+	Zone    string          `json:"zone"`
+	Cost    Money           `json:"cost"`
+	MinDays *int64          `json:"minDays"`
+	MaxDays *int64          `json:"maxDays"`
+}
+
+type ShippingZone struct {
+	Name        string   `json:"name"`
+	Countries   []string `json:"countries"`
+	Rate        Money    `json:"rate"`
+	RatePerStep *Money   `json:"ratePerStep"`
+	FreeOver    *Money   `json:"freeOver"`
+}
+
+type ShippingZoneInput struct {
+	Name        string   `json:"name"`
+	Countries   []string `json:"countries"`
+	Rate        Money    `json:"rate"`
+	RatePerStep *Money   `json:"ratePerStep"`
+	FreeOver    *Money   `json:"freeOver"`
+}
+
+type StaffRole struct {
+	ID          string       `json:"id" bson:"_id"`
+	Name        string       `json:"name"`
+	Permissions []Permission `json:"permissions"`
+	Created     int64        `json:"created"`
+	Updated     int64        `json:"updated"`
+}
+
+type StaffRoleUpdate struct {
+	Name        *string      `json:"name"`
+	Permissions []Permission `json:"permissions"`
+}
+
+type TaxBreakdown struct {
+	Lines    []*TaxLine `json:"lines"`
+	Shipping *TaxLine   `json:"shipping"`
+	Net      Money      `json:"net"`
+	Tax      Money      `json:"tax"`
+	Gross    Money      `json:"gross"`
+}
+
+type TaxLine struct {
+	BookID      *string        `json:"bookId"`
+	VariantID   *string        `json:"variantId"`
+	ProductType TaxProductType `json:"productType"`
+	Name        string         `json:"name"`
+	Rate        string         `json:"rate"`
+	Inclusive   bool           `json:"inclusive"`
+	Net         Money          `json:"net"`
+	Tax         Money          `json:"tax"`
+	Gross       Money          `json:"gross"`
+}
+
 type Topic struct {
-	ID      string  `json:"id" bson:"_id"`
-	Name    string  `json:"name"`
-	Created int64   `json:"created"`
-	Updated int64   `json:"updated"`
-	Books   []*Book `json:"books"`
+	ID        string   `json:"id" bson:"_id"`
+	Name      string   `json:"name"`
+	ParentID  *string  `json:"parentId"`
+	Created   int64    `json:"created"`
+	Updated   int64    `json:"updated"`
+	Parent    *Topic   `json:"parent"`
+	Children  []*Topic `json:"children"`
+	Ancestors []*Topic `json:"ancestors"`
+	Books     []*Book  `json:"books"`
 }
 
-type User struct {
-	ID       string `json:"id" bson:"_id"`
-	Name     string `json:"name"`
-	Email    string `json:"email"`
-	Password string `json:"password"`
-	Role     Role   `json:"role"`
-	Created  int64  `json:"created"`
-	Updated  int64  `json:"updated"`
+type TwoFactorLogin struct {
+	Challenge string `json:"challenge"`
+	Code      string `json:"code"`
+}
+
+type TwoFactorSetup struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioningUri"`
+}
+
+type UserFilter struct {
+	Search   *string `json:"search"`
+	Role     *Role   `json:"role"`
+	Disabled *bool   `json:"disabled"`
 }
 
 type WishList struct {
@@ -132,43 +669,786 @@ type WishListUpdate struct {
 	Remove []string `json:"remove"`
 }
 
-type Role string
+type BookFormat string
 
 const (
-	RoleAdmin  Role = "ADMIN"
-	RoleClient Role = "CLIENT"
+	BookFormatHardcover BookFormat = "HARDCOVER"
+	BookFormatPaperback BookFormat = "PAPERBACK"
+	BookFormatEbook     BookFormat = "EBOOK"
+	BookFormatAudiobook BookFormat = "AUDIOBOOK"
 )
 
-var AllRole = []Role{
-	RoleAdmin,
-	RoleClient,
+var AllBookFormat = []BookFormat{
+	BookFormatHardcover,
+	BookFormatPaperback,
+	BookFormatEbook,
+	BookFormatAudiobook,
 }
 
-func (e Role) IsValid() bool {
+func (e BookFormat) IsValid() bool {
 	switch e {
-	case RoleAdmin, RoleClient:
+	case BookFormatHardcover, BookFormatPaperback, BookFormatEbook, BookFormatAudiobook:
 		return true
 	}
 	return false
 }
 
-func (e Role) String() string {
+func (e BookFormat) String() string {
 	return string(e)
 }
 
-func (e *Role) UnmarshalGQL(v interface{}) error {
+func (e *BookFormat) UnmarshalGQL(v interface{}) error {
 	str, ok := v.(string)
 	if !ok {
 		return fmt.Errorf("enums must be strings")
 	}
 
-	*e = Role(str)
+	*e = BookFormat(str)
 	if !e.IsValid() {
-		return fmt.Errorf("%s is not a valid Role", str)
+		return fmt.Errorf("%s is not a valid BookFormat", str)
 	}
 	return nil
 }
 
-func (e Role) MarshalGQL(w io.Writer) {
+func (e BookFormat) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type CoverSize string
+
+const (
+	CoverSizeThumbnail CoverSize = "THUMBNAIL"
+	CoverSizeMedium    CoverSize = "MEDIUM"
+	CoverSizeLarge     CoverSize = "LARGE"
+)
+
+var AllCoverSize = []CoverSize{
+	CoverSizeThumbnail,
+	CoverSizeMedium,
+	CoverSizeLarge,
+}
+
+func (e CoverSize) IsValid() bool {
+	switch e {
+	case CoverSizeThumbnail, CoverSizeMedium, CoverSizeLarge:
+		return true
+	}
+	return false
+}
+
+func (e CoverSize) String() string {
+	return string(e)
+}
+
+func (e *CoverSize) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = CoverSize(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid CoverSize", str)
+	}
+	return nil
+}
+
+func (e CoverSize) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type ExportFormat string
+
+const (
+	ExportFormatCSV         ExportFormat = "CSV"
+	ExportFormatJSONL       ExportFormat = "JSONL"
+	ExportFormatMerchantXML ExportFormat = "MERCHANT_XML"
+)
+
+var AllExportFormat = []ExportFormat{
+	ExportFormatCSV,
+	ExportFormatJSONL,
+	ExportFormatMerchantXML,
+}
+
+func (e ExportFormat) IsValid() bool {
+	switch e {
+	case ExportFormatCSV, ExportFormatJSONL, ExportFormatMerchantXML:
+		return true
+	}
+	return false
+}
+
+func (e ExportFormat) String() string {
+	return string(e)
+}
+
+func (e *ExportFormat) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ExportFormat(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ExportFormat", str)
+	}
+	return nil
+}
+
+func (e ExportFormat) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type ExportJobStatus string
+
+const (
+	ExportJobStatusRunning   ExportJobStatus = "RUNNING"
+	ExportJobStatusCompleted ExportJobStatus = "COMPLETED"
+	ExportJobStatusFailed    ExportJobStatus = "FAILED"
+)
+
+var AllExportJobStatus = []ExportJobStatus{
+	ExportJobStatusRunning,
+	ExportJobStatusCompleted,
+	ExportJobStatusFailed,
+}
+
+func (e ExportJobStatus) IsValid() bool {
+	switch e {
+	case ExportJobStatusRunning, ExportJobStatusCompleted, ExportJobStatusFailed:
+		return true
+	}
+	return false
+}
+
+func (e ExportJobStatus) String() string {
+	return string(e)
+}
+
+func (e *ExportJobStatus) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ExportJobStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ExportJobStatus", str)
+	}
+	return nil
+}
+
+func (e ExportJobStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type ImportFormat string
+
+const (
+	ImportFormatCSV  ImportFormat = "CSV"
+	ImportFormatOnix ImportFormat = "ONIX"
+)
+
+var AllImportFormat = []ImportFormat{
+	ImportFormatCSV,
+	ImportFormatOnix,
+}
+
+func (e ImportFormat) IsValid() bool {
+	switch e {
+	case ImportFormatCSV, ImportFormatOnix:
+		return true
+	}
+	return false
+}
+
+func (e ImportFormat) String() string {
+	return string(e)
+}
+
+func (e *ImportFormat) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ImportFormat(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ImportFormat", str)
+	}
+	return nil
+}
+
+func (e ImportFormat) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type ImportJobStatus string
+
+const (
+	ImportJobStatusRunning   ImportJobStatus = "RUNNING"
+	ImportJobStatusCompleted ImportJobStatus = "COMPLETED"
+	ImportJobStatusFailed    ImportJobStatus = "FAILED"
+)
+
+var AllImportJobStatus = []ImportJobStatus{
+	ImportJobStatusRunning,
+	ImportJobStatusCompleted,
+	ImportJobStatusFailed,
+}
+
+func (e ImportJobStatus) IsValid() bool {
+	switch e {
+	case ImportJobStatusRunning, ImportJobStatusCompleted, ImportJobStatusFailed:
+		return true
+	}
+	return false
+}
+
+func (e ImportJobStatus) String() string {
+	return string(e)
+}
+
+func (e *ImportJobStatus) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ImportJobStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ImportJobStatus", str)
+	}
+	return nil
+}
+
+func (e ImportJobStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type IntegrityIssueKind string
+
+const (
+	IntegrityIssueKindMalformed IntegrityIssueKind = "MALFORMED"
+	IntegrityIssueKindMissing   IntegrityIssueKind = "MISSING"
+)
+
+var AllIntegrityIssueKind = []IntegrityIssueKind{
+	IntegrityIssueKindMalformed,
+	IntegrityIssueKindMissing,
+}
+
+func (e IntegrityIssueKind) IsValid() bool {
+	switch e {
+	case IntegrityIssueKindMalformed, IntegrityIssueKindMissing:
+		return true
+	}
+	return false
+}
+
+func (e IntegrityIssueKind) String() string {
+	return string(e)
+}
+
+func (e *IntegrityIssueKind) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = IntegrityIssueKind(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid IntegrityIssueKind", str)
+	}
+	return nil
+}
+
+func (e IntegrityIssueKind) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type InvoiceKind string
+
+const (
+	InvoiceKindInvoice    InvoiceKind = "INVOICE"
+	InvoiceKindCreditNote InvoiceKind = "CREDIT_NOTE"
+)
+
+var AllInvoiceKind = []InvoiceKind{
+	InvoiceKindInvoice,
+	InvoiceKindCreditNote,
+}
+
+func (e InvoiceKind) IsValid() bool {
+	switch e {
+	case InvoiceKindInvoice, InvoiceKindCreditNote:
+		return true
+	}
+	return false
+}
+
+func (e InvoiceKind) String() string {
+	return string(e)
+}
+
+func (e *InvoiceKind) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = InvoiceKind(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid InvoiceKind", str)
+	}
+	return nil
+}
+
+func (e InvoiceKind) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type OrderStatus string
+
+const (
+	OrderStatusPending   OrderStatus = "PENDING"
+	OrderStatusPaid      OrderStatus = "PAID"
+	OrderStatusShipped   OrderStatus = "SHIPPED"
+	OrderStatusDelivered OrderStatus = "DELIVERED"
+	OrderStatusCancelled OrderStatus = "CANCELLED"
+)
+
+var AllOrderStatus = []OrderStatus{
+	OrderStatusPending,
+	OrderStatusPaid,
+	OrderStatusShipped,
+	OrderStatusDelivered,
+	OrderStatusCancelled,
+}
+
+func (e OrderStatus) IsValid() bool {
+	switch e {
+	case OrderStatusPending, OrderStatusPaid, OrderStatusShipped, OrderStatusDelivered, OrderStatusCancelled:
+		return true
+	}
+	return false
+}
+
+func (e OrderStatus) String() string {
+	return string(e)
+}
+
+func (e *OrderStatus) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = OrderStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid OrderStatus", str)
+	}
+	return nil
+}
+
+func (e OrderStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type Permission string
+
+const (
+	PermissionCatalogWrite    Permission = "CATALOG_WRITE"
+	PermissionReviewsModerate Permission = "REVIEWS_MODERATE"
+	PermissionOrdersManage    Permission = "ORDERS_MANAGE"
+	PermissionUsersManage     Permission = "USERS_MANAGE"
+)
+
+var AllPermission = []Permission{
+	PermissionCatalogWrite,
+	PermissionReviewsModerate,
+	PermissionOrdersManage,
+	PermissionUsersManage,
+}
+
+func (e Permission) IsValid() bool {
+	switch e {
+	case PermissionCatalogWrite, PermissionReviewsModerate, PermissionOrdersManage, PermissionUsersManage:
+		return true
+	}
+	return false
+}
+
+func (e Permission) String() string {
+	return string(e)
+}
+
+func (e *Permission) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = Permission(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid Permission", str)
+	}
+	return nil
+}
+
+func (e Permission) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type PriceChangeReason string
+
+const (
+	PriceChangeReasonCreated       PriceChangeReason = "CREATED"
+	PriceChangeReasonManual        PriceChangeReason = "MANUAL"
+	PriceChangeReasonImport        PriceChangeReason = "IMPORT"
+	PriceChangeReasonScheduleStart PriceChangeReason = "SCHEDULE_START"
+	PriceChangeReasonScheduleEnd   PriceChangeReason = "SCHEDULE_END"
+)
+
+var AllPriceChangeReason = []PriceChangeReason{
+	PriceChangeReasonCreated,
+	PriceChangeReasonManual,
+	PriceChangeReasonImport,
+	PriceChangeReasonScheduleStart,
+	PriceChangeReasonScheduleEnd,
+}
+
+func (e PriceChangeReason) IsValid() bool {
+	switch e {
+	case PriceChangeReasonCreated, PriceChangeReasonManual, PriceChangeReasonImport, PriceChangeReasonScheduleStart, PriceChangeReasonScheduleEnd:
+		return true
+	}
+	return false
+}
+
+func (e PriceChangeReason) String() string {
+	return string(e)
+}
+
+func (e *PriceChangeReason) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = PriceChangeReason(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid PriceChangeReason", str)
+	}
+	return nil
+}
+
+func (e PriceChangeReason) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type PriceScheduleStatus string
+
+const (
+	PriceScheduleStatusScheduled PriceScheduleStatus = "SCHEDULED"
+	PriceScheduleStatusActive    PriceScheduleStatus = "ACTIVE"
+	PriceScheduleStatusCompleted PriceScheduleStatus = "COMPLETED"
+	PriceScheduleStatusCancelled PriceScheduleStatus = "CANCELLED"
+)
+
+var AllPriceScheduleStatus = []PriceScheduleStatus{
+	PriceScheduleStatusScheduled,
+	PriceScheduleStatusActive,
+	PriceScheduleStatusCompleted,
+	PriceScheduleStatusCancelled,
+}
+
+func (e PriceScheduleStatus) IsValid() bool {
+	switch e {
+	case PriceScheduleStatusScheduled, PriceScheduleStatusActive, PriceScheduleStatusCompleted, PriceScheduleStatusCancelled:
+		return true
+	}
+	return false
+}
+
+func (e PriceScheduleStatus) String() string {
+	return string(e)
+}
+
+func (e *PriceScheduleStatus) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = PriceScheduleStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid PriceScheduleStatus", str)
+	}
+	return nil
+}
+
+func (e PriceScheduleStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type RemovalPolicy string
+
+const (
+	RemovalPolicyRestrict RemovalPolicy = "RESTRICT"
+	RemovalPolicyPull     RemovalPolicy = "PULL"
+	RemovalPolicyReassign RemovalPolicy = "REASSIGN"
+)
+
+var AllRemovalPolicy = []RemovalPolicy{
+	RemovalPolicyRestrict,
+	RemovalPolicyPull,
+	RemovalPolicyReassign,
+}
+
+func (e RemovalPolicy) IsValid() bool {
+	switch e {
+	case RemovalPolicyRestrict, RemovalPolicyPull, RemovalPolicyReassign:
+		return true
+	}
+	return false
+}
+
+func (e RemovalPolicy) String() string {
+	return string(e)
+}
+
+func (e *RemovalPolicy) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = RemovalPolicy(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid RemovalPolicy", str)
+	}
+	return nil
+}
+
+func (e RemovalPolicy) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type ReturnStatus string
+
+const (
+	ReturnStatusRequested ReturnStatus = "REQUESTED"
+	ReturnStatusRejected  ReturnStatus = "REJECTED"
+	ReturnStatusApproved  ReturnStatus = "APPROVED"
+	ReturnStatusRefunded  ReturnStatus = "REFUNDED"
+)
+
+var AllReturnStatus = []ReturnStatus{
+	ReturnStatusRequested,
+	ReturnStatusRejected,
+	ReturnStatusApproved,
+	ReturnStatusRefunded,
+}
+
+func (e ReturnStatus) IsValid() bool {
+	switch e {
+	case ReturnStatusRequested, ReturnStatusRejected, ReturnStatusApproved, ReturnStatusRefunded:
+		return true
+	}
+	return false
+}
+
+func (e ReturnStatus) String() string {
+	return string(e)
+}
+
+func (e *ReturnStatus) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ReturnStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ReturnStatus", str)
+	}
+	return nil
+}
+
+func (e ReturnStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type Role string
+
+const (
+	RoleAdmin  Role = "ADMIN"
+	RoleClient Role = "CLIENT"
+)
+
+var AllRole = []Role{
+	RoleAdmin,
+	RoleClient,
+}
+
+func (e Role) IsValid() bool {
+	switch e {
+	case RoleAdmin, RoleClient:
+		return true
+	}
+	return false
+}
+
+func (e Role) String() string {
+	return string(e)
+}
+
+func (e *Role) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = Role(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid Role", str)
+	}
+	return nil
+}
+
+func (e Role) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type RoundingMode string
+
+const (
+	RoundingModeHalfUp   RoundingMode = "HALF_UP"
+	RoundingModeHalfEven RoundingMode = "HALF_EVEN"
+	RoundingModeUp       RoundingMode = "UP"
+	RoundingModeDown     RoundingMode = "DOWN"
+)
+
+var AllRoundingMode = []RoundingMode{
+	RoundingModeHalfUp,
+	RoundingModeHalfEven,
+	RoundingModeUp,
+	RoundingModeDown,
+}
+
+func (e RoundingMode) IsValid() bool {
+	switch e {
+	case RoundingModeHalfUp, RoundingModeHalfEven, RoundingModeUp, RoundingModeDown:
+		return true
+	}
+	return false
+}
+
+func (e RoundingMode) String() string {
+	return string(e)
+}
+
+func (e *RoundingMode) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = RoundingMode(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid RoundingMode", str)
+	}
+	return nil
+}
+
+func (e RoundingMode) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type ShippingRateType string
+
+const (
+	ShippingRateTypeFlat   ShippingRateType = "FLAT"
+	ShippingRateTypeWeight ShippingRateType = "WEIGHT"
+)
+
+var AllShippingRateType = []ShippingRateType{
+	ShippingRateTypeFlat,
+	ShippingRateTypeWeight,
+}
+
+func (e ShippingRateType) IsValid() bool {
+	switch e {
+	case ShippingRateTypeFlat, ShippingRateTypeWeight:
+		return true
+	}
+	return false
+}
+
+func (e ShippingRateType) String() string {
+	return string(e)
+}
+
+func (e *ShippingRateType) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ShippingRateType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ShippingRateType", str)
+	}
+	return nil
+}
+
+func (e ShippingRateType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type TaxProductType string
+
+const (
+	TaxProductTypeBook      TaxProductType = "BOOK"
+	TaxProductTypeEbook     TaxProductType = "EBOOK"
+	TaxProductTypeAudiobook TaxProductType = "AUDIOBOOK"
+	TaxProductTypeShipping  TaxProductType = "SHIPPING"
+)
+
+var AllTaxProductType = []TaxProductType{
+	TaxProductTypeBook,
+	TaxProductTypeEbook,
+	TaxProductTypeAudiobook,
+	TaxProductTypeShipping,
+}
+
+func (e TaxProductType) IsValid() bool {
+	switch e {
+	case TaxProductTypeBook, TaxProductTypeEbook, TaxProductTypeAudiobook, TaxProductTypeShipping:
+		return true
+	}
+	return false
+}
+
+func (e TaxProductType) String() string {
+	return string(e)
+}
+
+func (e *TaxProductType) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = TaxProductType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid TaxProductType", str)
+	}
+	return nil
+}
+
+func (e TaxProductType) MarshalGQL(w io.Writer) {
 	fmt.Fprint(w, strconv.Quote(e.String()))
 }
@@ -0,0 +1,186 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Money is an exact amount in the minor units of an ISO 4217 currency, e.g. 1999 USD is $19.99.
+// It is a {amount, currency} object in GraphQL and is stored in Mongo with the amount as a Decimal128
+// in major units, so the database can still compare and sum prices.
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+var currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// currencyExponents are the number of minor unit digits of the currencies which don't have 2.
+var currencyExponents = map[string]int{
+	"BHD": 3, "BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "IQD": 3, "ISK": 0, "JOD": 3,
+	"JPY": 0, "KMF": 0, "KRW": 0, "KWD": 3, "LYD": 3, "OMR": 3, "PYG": 0, "RWF": 0,
+	"TND": 3, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+}
+
+// StoreCurrency is the currency of the catalog prices, set with STORE_CURRENCY.
+func StoreCurrency() string {
+	if currency := os.Getenv("STORE_CURRENCY"); currency != "" {
+		return strings.ToUpper(currency)
+	}
+	return "USD"
+}
+
+// CurrencyExponent returns the number of digits of the minor unit of a currency.
+func CurrencyExponent(currency string) int {
+	if exponent, ok := currencyExponents[currency]; ok {
+		return exponent
+	}
+	return 2
+}
+
+// ValidCurrency tells whether currency has the form of an ISO 4217 code.
+func ValidCurrency(currency string) bool {
+	return currencyPattern.MatchString(currency)
+}
+
+var decimalPattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+
+// IsDecimal tells whether s is a positive decimal number without exponent, e.g. "19.99".
+func IsDecimal(s string) bool {
+	return decimalPattern.MatchString(s)
+}
+
+// ParseMoney reads a decimal amount in major units, e.g. "19.99", an amount with more
+// decimals than the currency has is refused rather than rounded.
+func ParseMoney(amount string, currency string) (Money, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if !ValidCurrency(currency) {
+		return Money{}, fmt.Errorf("Invalid currency %v", currency)
+	}
+	value, ok := new(big.Rat).SetString(strings.TrimSpace(amount))
+	if !ok {
+		return Money{}, fmt.Errorf("Invalid amount %v", amount)
+	}
+	value.Mul(value, new(big.Rat).SetInt(pow10(CurrencyExponent(currency))))
+	if !value.IsInt() {
+		return Money{}, fmt.Errorf("Amount %v has more decimals than %v allows", amount, currency)
+	}
+	if !value.Num().IsInt64() {
+		return Money{}, fmt.Errorf("Amount %v is too large", amount)
+	}
+	return Money{Amount: value.Num().Int64(), Currency: currency}, nil
+}
+
+// Decimal formats the amount in major units, e.g. "19.99".
+func (m Money) Decimal() string {
+	exponent := CurrencyExponent(m.Currency)
+	if exponent == 0 {
+		return strconv.FormatInt(m.Amount, 10)
+	}
+	return new(big.Rat).SetFrac(big.NewInt(m.Amount), pow10(exponent)).FloatString(exponent)
+}
+
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+// Add sums two amounts of the same currency.
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, fmt.Errorf("Can't add %v to %v", other.Currency, m.Currency)
+	}
+	return Money{Amount: m.Amount + other.Amount, Currency: m.Currency}, nil
+}
+
+// Times multiplies the amount, e.g. by the quantity of an order item.
+func (m Money) Times(n int64) Money {
+	return Money{Amount: m.Amount * n, Currency: m.Currency}
+}
+
+// UnmarshalGQL reads a {amount, currency} object, amount being in minor units.
+func (m *Money) UnmarshalGQL(v interface{}) error {
+	object, ok := v.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("Money must be an object with an amount and a currency")
+	}
+	currency, _ := object["currency"].(string)
+	currency = strings.ToUpper(currency)
+	if !ValidCurrency(currency) {
+		return fmt.Errorf("Invalid currency %v, expected an ISO 4217 currency code", object["currency"])
+	}
+	var amount int64
+	var err error
+	switch value := object["amount"].(type) {
+	case int64:
+		amount = value
+	case int:
+		amount = int64(value)
+	case json.Number:
+		amount, err = value.Int64()
+	default:
+		err = fmt.Errorf("not an integer")
+	}
+	if err != nil {
+		return fmt.Errorf("Invalid amount %v, expected an integer number of minor units", object["amount"])
+	}
+	m.Amount = amount
+	m.Currency = currency
+	return nil
+}
+
+func (m Money) MarshalGQL(w io.Writer) {
+	fmt.Fprintf(w, `{"amount":%d,"currency":%q}`, m.Amount, m.Currency)
+}
+
+type moneyDocument struct {
+	Amount   primitive.Decimal128 `bson:"amount"`
+	Currency string               `bson:"currency"`
+}
+
+func (m Money) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	amount, ok := primitive.ParseDecimal128FromBigInt(big.NewInt(m.Amount), -CurrencyExponent(m.Currency))
+	if !ok {
+		return 0, nil, fmt.Errorf("Amount %v can't be stored as a decimal", m.Amount)
+	}
+	return bson.MarshalValue(moneyDocument{Amount: amount, Currency: m.Currency})
+}
+
+func (m *Money) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	var document moneyDocument
+	err := bson.RawValue{Type: t, Value: data}.Unmarshal(&document)
+	if err != nil {
+		return err
+	}
+	value, exp, err := document.Amount.BigInt()
+	if err != nil {
+		return err
+	}
+	amount := new(big.Rat).SetInt(value)
+	// the exponent of the stored decimal may differ from the one of the currency, e.g. 19.990 USD
+	exp += CurrencyExponent(document.Currency)
+	if exp >= 0 {
+		amount.Mul(amount, new(big.Rat).SetInt(pow10(exp)))
+	} else {
+		amount.Quo(amount, new(big.Rat).SetInt(pow10(-exp)))
+	}
+	if !amount.IsInt() || !amount.Num().IsInt64() {
+		return fmt.Errorf("Stored amount %v isn't a whole number of %v minor units", document.Amount, document.Currency)
+	}
+	m.Amount = amount.Num().Int64()
+	m.Currency = document.Currency
+	return nil
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
@@ -0,0 +1,102 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestParseMoney(t *testing.T) {
+	cases := []struct {
+		amount   string
+		currency string
+		expected Money
+	}{
+		{"19.99", "usd", Money{Amount: 1999, Currency: "USD"}},
+		{"12.5", "EUR", Money{Amount: 1250, Currency: "EUR"}},
+		{"1500", "JPY", Money{Amount: 1500, Currency: "JPY"}},
+		{"1.234", "BHD", Money{Amount: 1234, Currency: "BHD"}},
+	}
+	for _, c := range cases {
+		money, err := ParseMoney(c.amount, c.currency)
+		if err != nil || money != c.expected {
+			t.Fatalf("ParseMoney(%v, %v) = %v, %v, expected %v", c.amount, c.currency, money, err, c.expected)
+		}
+	}
+	for _, amount := range []string{"19.999", "abc", ""} {
+		if _, err := ParseMoney(amount, "USD"); err == nil {
+			t.Fatalf("ParseMoney(%v) should fail", amount)
+		}
+	}
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	// 0.1 + 0.2 is the classic float rounding error
+	total, err := Money{Amount: 10, Currency: "USD"}.Add(Money{Amount: 20, Currency: "USD"})
+	if err != nil || total.Decimal() != "0.30" {
+		t.Fatalf("unexpected total %v, %v", total, err)
+	}
+	if total.Times(3).String() != "0.90 USD" {
+		t.Fatalf("unexpected product %v", total.Times(3))
+	}
+	if _, err := total.Add(Money{Amount: 1, Currency: "EUR"}); err == nil {
+		t.Fatal("adding different currencies should fail")
+	}
+}
+
+func TestMoneyBSON(t *testing.T) {
+	price := Money{Amount: 1999, Currency: "USD"}
+	data, err := bson.Marshal(bson.M{"price": price})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw struct {
+		Price struct {
+			Amount   primitive.Decimal128
+			Currency string
+		}
+	}
+	if err := bson.Unmarshal(data, &raw); err != nil || raw.Price.Amount.String() != "19.99" {
+		t.Fatalf("unexpected stored price %+v, %v", raw, err)
+	}
+	var decoded struct{ Price Money }
+	if err := bson.Unmarshal(data, &decoded); err != nil || decoded.Price != price {
+		t.Fatalf("unexpected decoded price %v, %v", decoded.Price, err)
+	}
+	// a migrated price may keep extra zeros
+	amount, _ := primitive.ParseDecimal128("19.9900")
+	data, _ = bson.Marshal(bson.M{"price": bson.M{"amount": amount, "currency": "USD"}})
+	if err := bson.Unmarshal(data, &decoded); err != nil || decoded.Price != price {
+		t.Fatalf("unexpected decoded price %v, %v", decoded.Price, err)
+	}
+}
+
+func TestMoneyGQL(t *testing.T) {
+	var money Money
+	if err := money.UnmarshalGQL(map[string]interface{}{"amount": json.Number("1999"), "currency": "usd"}); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	money.MarshalGQL(&buf)
+	if buf.String() != `{"amount":1999,"currency":"USD"}` {
+		t.Fatalf("unexpected output %v", buf.String())
+	}
+	if err := money.UnmarshalGQL(map[string]interface{}{"amount": 19.99, "currency": "USD"}); err == nil {
+		t.Fatal("a fractional amount should fail")
+	}
+}
+
+func TestValidCatalogPrice(t *testing.T) {
+	if !validCatalogPrice(Money{Amount: 1999, Currency: StoreCurrency()}) {
+		t.Fatal("expected a price in the store currency to be valid")
+	}
+	if validCatalogPrice(Money{Amount: 1999, Currency: "XTS"}) {
+		t.Fatal("expected a price in another currency to be invalid")
+	}
+	if validCatalogPrice(Money{Amount: -1, Currency: StoreCurrency()}) {
+		t.Fatal("expected a negative price to be invalid")
+	}
+}
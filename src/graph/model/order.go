@@ -0,0 +1,19 @@
+package model
+
+// orderTransitions are the statuses an order can move to from each status.
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusPending: {OrderStatusPaid, OrderStatusCancelled},
+	OrderStatusPaid:    {OrderStatusShipped, OrderStatusCancelled},
+	OrderStatusShipped: {OrderStatusDelivered},
+}
+
+// CanBecome tells whether an order can move from a status to another, delivered and
+// cancelled orders are final.
+func (status OrderStatus) CanBecome(next OrderStatus) bool {
+	for _, s := range orderTransitions[status] {
+		if s == next {
+			return true
+		}
+	}
+	return false
+}
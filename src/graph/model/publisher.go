@@ -0,0 +1,41 @@
+package model
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Validate normalizes and checks the fields of a publisher.
+func (publisher *Publisher) Validate() error {
+	publisher.Name = strings.TrimSpace(publisher.Name)
+	if publisher.Name == "" {
+		return fmt.Errorf("Name is required")
+	}
+	if publisher.Website != nil {
+		website, err := url.Parse(*publisher.Website)
+		if err != nil || (website.Scheme != "http" && website.Scheme != "https") || website.Host == "" {
+			return fmt.Errorf("Invalid website %v", *publisher.Website)
+		}
+	}
+	if publisher.Country != nil {
+		country := strings.ToUpper(*publisher.Country)
+		if !IsCountryCode(country) {
+			return fmt.Errorf("Invalid country %v, expected an ISO 3166-1 alpha-2 country code", *publisher.Country)
+		}
+		publisher.Country = &country
+	}
+	return nil
+}
+
+func (publisher *Publisher) ApplyUpdate(update PublisherUpdate) {
+	if update.Name != nil {
+		publisher.Name = *update.Name
+	}
+	if update.Website != nil {
+		publisher.Website = Optional(update.Website)
+	}
+	if update.Country != nil {
+		publisher.Country = Optional(update.Country)
+	}
+}
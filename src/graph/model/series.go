@@ -0,0 +1,24 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Validate normalizes and checks the fields of a series.
+func (series *Series) Validate() error {
+	series.Name = strings.TrimSpace(series.Name)
+	if series.Name == "" {
+		return fmt.Errorf("Name is required")
+	}
+	return nil
+}
+
+func (series *Series) ApplyUpdate(update SeriesUpdate) {
+	if update.Name != nil {
+		series.Name = *update.Name
+	}
+	if update.Description != nil {
+		series.Description = Optional(update.Description)
+	}
+}
@@ -0,0 +1,116 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Validate normalizes a shipping method and returns an error for every invalid field.
+func (input *ShippingMethodInput) Validate() []*gqlerror.Error {
+	var errs []*gqlerror.Error
+	input.Name = strings.TrimSpace(input.Name)
+	if input.Name == "" {
+		errs = append(errs, FieldError("name", "Name must not be empty"))
+	}
+	if !input.Type.IsValid() {
+		errs = append(errs, FieldError("type", "Invalid type %v", input.Type))
+	}
+	if input.Type == ShippingRateTypeWeight && (input.WeightStep == nil || *input.WeightStep <= 0) {
+		errs = append(errs, FieldError("weightStep", "Weight step must be a positive number of grams"))
+	}
+	if input.MinDays != nil && *input.MinDays < 0 {
+		errs = append(errs, FieldError("minDays", "Delivery days must not be negative"))
+	}
+	if input.MinDays != nil && input.MaxDays != nil && *input.MaxDays < *input.MinDays {
+		errs = append(errs, FieldError("maxDays", "Maximum delivery days must not be less than the minimum"))
+	}
+	if len(input.Zones) == 0 {
+		errs = append(errs, FieldError("zones", "A shipping method needs at least one zone"))
+	}
+	countries := map[string]bool{}
+	restOfWorld := false
+	for i, zone := range input.Zones {
+		field := fmt.Sprintf("zones.%v", i)
+		zone.Name = strings.TrimSpace(zone.Name)
+		if zone.Name == "" {
+			errs = append(errs, FieldError(field+".name", "Name must not be empty"))
+		}
+		if len(zone.Countries) == 0 {
+			if restOfWorld {
+				errs = append(errs, FieldError(field+".countries", "Only one zone can be without countries"))
+			}
+			restOfWorld = true
+		}
+		for j, country := range zone.Countries {
+			country = strings.ToUpper(strings.TrimSpace(country))
+			zone.Countries[j] = country
+			if !IsCountryCode(country) {
+				errs = append(errs, FieldError(field+".countries", "Invalid country code %v", country))
+			} else if countries[country] {
+				errs = append(errs, FieldError(field+".countries", "Country %v is in several zones", country))
+			}
+			countries[country] = true
+		}
+		prices := []struct {
+			name  string
+			price *Money
+		}{{"rate", &zone.Rate}, {"ratePerStep", zone.RatePerStep}, {"freeOver", zone.FreeOver}}
+		for _, p := range prices {
+			if p.price == nil {
+				continue
+			}
+			if !validPrice(*p.price) {
+				errs = append(errs, FieldError(field+"."+p.name, "Price must be a positive number"))
+			} else if p.price.Currency != zone.Rate.Currency {
+				errs = append(errs, FieldError(field+"."+p.name, "Price must be in %v like the rate", zone.Rate.Currency))
+			}
+		}
+		if input.Type == ShippingRateTypeWeight && zone.RatePerStep == nil {
+			errs = append(errs, FieldError(field+".ratePerStep", "A weight based zone needs a rate per step"))
+		}
+	}
+	return errs
+}
+
+// ShippingZones returns the zones of a shipping method input, once validated.
+func (input *ShippingMethodInput) ShippingZones() []*ShippingZone {
+	zones := []*ShippingZone{}
+	for _, zone := range input.Zones {
+		zones = append(zones, &ShippingZone{
+			Name:        zone.Name,
+			Countries:   zone.Countries,
+			Rate:        zone.Rate,
+			RatePerStep: zone.RatePerStep,
+			FreeOver:    zone.FreeOver,
+		})
+	}
+	return zones
+}
+
+// Zone returns the zone listing a country, or else the zone without countries.
+func (method *ShippingMethod) Zone(country string) *ShippingZone {
+	var restOfWorld *ShippingZone
+	for _, zone := range method.Zones {
+		if len(zone.Countries) == 0 {
+			restOfWorld = zone
+		}
+		for _, c := range zone.Countries {
+			if c == country {
+				return zone
+			}
+		}
+	}
+	return restOfWorld
+}
+
+// Cost is the cost of shipping weight grams in a zone, before the free shipping threshold.
+func (method *ShippingMethod) Cost(zone *ShippingZone, weight int64) Money {
+	cost := zone.Rate
+	if method.Type == ShippingRateTypeWeight && zone.RatePerStep != nil && method.WeightStep != nil && *method.WeightStep > 0 {
+		steps := (weight + *method.WeightStep - 1) / *method.WeightStep
+		cost.Amount += zone.RatePerStep.Amount * steps
+	}
+	return cost
+}
@@ -0,0 +1,46 @@
+package model
+
+import "testing"
+
+func TestShippingMethodCost(t *testing.T) {
+	step := int64(500)
+	input := ShippingMethodInput{
+		Name:       "Standard",
+		Type:       ShippingRateTypeWeight,
+		WeightStep: &step,
+		Zones: []*ShippingZoneInput{
+			{Name: "Domestic", Countries: []string{"us"}, Rate: Money{Amount: 399, Currency: "USD"}, RatePerStep: &Money{Amount: 100, Currency: "USD"}},
+			{Name: "International", Rate: Money{Amount: 1299, Currency: "USD"}, RatePerStep: &Money{Amount: 250, Currency: "USD"}},
+		},
+	}
+	if errs := input.Validate(); len(errs) > 0 {
+		t.Fatalf("unexpected errors %v", errs)
+	}
+	method := &ShippingMethod{Type: input.Type, WeightStep: input.WeightStep, Zones: input.ShippingZones()}
+	zone := method.Zone("US")
+	if zone == nil || zone.Name != "Domestic" {
+		t.Fatalf("unexpected zone %+v", zone)
+	}
+	// 1.2 kg are 3 started steps of 500 g
+	if cost := method.Cost(zone, 1200); cost.Amount != 699 {
+		t.Fatalf("unexpected cost %v", cost)
+	}
+	if zone := method.Zone("FR"); zone == nil || zone.Name != "International" || method.Cost(zone, 0).Amount != 1299 {
+		t.Fatalf("unexpected zone %+v", zone)
+	}
+}
+
+func TestShippingMethodValidate(t *testing.T) {
+	input := ShippingMethodInput{
+		Name: "Flat",
+		Type: ShippingRateTypeFlat,
+		Zones: []*ShippingZoneInput{
+			{Name: "Europe", Countries: []string{"FR", "DE"}, Rate: Money{Amount: 500, Currency: "EUR"}, FreeOver: &Money{Amount: 5000, Currency: "USD"}},
+			{Name: "France", Countries: []string{"FR", "XX"}, Rate: Money{Amount: 300, Currency: "EUR"}},
+		},
+	}
+	// a threshold in another currency, a country in two zones and an invalid country
+	if errs := input.Validate(); len(errs) != 3 {
+		t.Fatalf("expected 3 errors, got %v", errs)
+	}
+}
@@ -0,0 +1,36 @@
+package model
+
+import (
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+const TwoFactorIssuer = "Book Store"
+
+// TwoFactor is the "twoFactor" sub document of a user, it is never exposed through the schema.
+type TwoFactor struct {
+	Secret        string   `bson:"secret"`
+	PendingSecret string   `bson:"pendingSecret"`
+	RecoveryCodes []string `bson:"recoveryCodes"`
+	LastStep      int64    `bson:"lastStep"`
+	// Attempts counts the codes tried since the last valid one, LockedUntil is set once there were too many.
+	Attempts    int64 `bson:"attempts"`
+	LockedUntil int64 `bson:"lockedUntil"`
+}
+
+func TwoFactorRequired(role Role) bool {
+	return role == RoleAdmin && os.Getenv("ADMIN_TWO_FACTOR_REQUIRED") == "true"
+}
+
+// CreateTwoFactorChallenge creates a short-lived token which can only be exchanged for a real token
+// together with a valid two-factor code.
+func (user User) CreateTwoFactorChallenge() (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"uid":       user.ID,
+		"challenge": "2fa",
+		"exp":       time.Now().Add(time.Minute * 5).Unix(),
+	})
+	return token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+}
@@ -0,0 +1,17 @@
+package model
+
+// User is not generated so that the password hash is never part of the schema.
+type User struct {
+	ID               string   `json:"id" bson:"_id"`
+	Name             string   `json:"name"`
+	Email            string   `json:"email"`
+	Password         string   `json:"-"`
+	Role             Role     `json:"role"`
+	TwoFactorEnabled bool     `json:"twoFactorEnabled"`
+	Disabled         bool     `json:"disabled"`
+	StaffRolesID     []string `json:"staffRolesId"`
+	// PasswordChanged invalidates the tokens issued before the last password change.
+	PasswordChanged int64 `json:"-"`
+	Created         int64 `json:"created"`
+	Updated         int64 `json:"updated"`
+}
@@ -0,0 +1,57 @@
+package model
+
+import (
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Validate normalizes a book variant and returns an error for every invalid field.
+func (variant *BookVariant) Validate() []*gqlerror.Error {
+	var errs []*gqlerror.Error
+	variant.Sku = strings.ToUpper(strings.TrimSpace(variant.Sku))
+	if variant.Sku == "" {
+		errs = append(errs, FieldError("sku", "SKU must not be empty"))
+	}
+	if !variant.Format.IsValid() {
+		errs = append(errs, FieldError("format", "Invalid format %v", variant.Format))
+	}
+	if !validCatalogPrice(variant.Price) {
+		errs = append(errs, FieldError("price", "Price must be a positive amount in %v", StoreCurrency()))
+	}
+	if variant.Stock < 0 {
+		errs = append(errs, FieldError("stock", "Stock must not be negative"))
+	}
+	if variant.Weight != nil && *variant.Weight <= 0 {
+		errs = append(errs, FieldError("weight", "Weight must be a positive number"))
+	}
+	return errs
+}
+
+func (variant *BookVariant) ApplyUpdate(update BookVariantUpdate) {
+	if update.Sku != nil {
+		variant.Sku = *update.Sku
+	}
+	if update.Format != nil {
+		variant.Format = *update.Format
+	}
+	if update.Price != nil {
+		variant.Price = *update.Price
+	}
+	if update.Stock != nil {
+		variant.Stock = *update.Stock
+	}
+	if update.Weight != nil {
+		variant.Weight = update.Weight
+	}
+}
+
+// Variant returns the variant of a book with the given id, or nil.
+func (book *Book) Variant(id string) *BookVariant {
+	for _, variant := range book.Variants {
+		if variant.ID == id {
+			return variant
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package resolver
+
+import (
+	"book-store/graph/model"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const apiKeyLastUsedInterval = 60
+
+type storedAPIKey struct {
+	model.APIKey `bson:",inline"`
+	Hash         string `bson:"hash"`
+}
+
+// generateAPIKey returns the plain key, which is shown only once, its public prefix
+// and the hash which is stored in place of the key.
+func generateAPIKey() (string, string, string, error) {
+	prefixBytes := make([]byte, 4)
+	if _, err := rand.Read(prefixBytes); err != nil {
+		return "", "", "", err
+	}
+	secretBytes := make([]byte, 32)
+	if _, err := rand.Read(secretBytes); err != nil {
+		return "", "", "", err
+	}
+	prefix := "bsk_" + hex.EncodeToString(prefixBytes)
+	key := prefix + "_" + base64.RawURLEncoding.EncodeToString(secretBytes)
+	return key, prefix, hashAPIKey(key), nil
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// authFromAPIKey authenticates a machine client. A key acts on behalf of its creator, so it only
+// keeps the permissions its creator still holds and stops working when the creator is disabled or deleted.
+func (r *Resolver) authFromAPIKey(key string) (*Auth, error) {
+	var apiKey storedAPIKey
+	err := r.DB.Collection("api-keys").FindOne(context.Background(), bson.M{"hash": hashAPIKey(key)}).Decode(&apiKey)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid API key")
+	}
+	now := time.Now().Unix()
+	if apiKey.Revoked {
+		return nil, fmt.Errorf("API key has been revoked")
+	}
+	if apiKey.Expires != nil && *apiKey.Expires < now {
+		return nil, fmt.Errorf("API key is expired")
+	}
+	permissions, err := r.creatorPermissions(&apiKey.APIKey)
+	if err != nil {
+		return nil, err
+	}
+	// lastUsed is only written once per interval to avoid a write on every request
+	if apiKey.LastUsed == nil || *apiKey.LastUsed < now-apiKeyLastUsedInterval {
+		filter := bson.M{"hash": apiKey.Hash}
+		update := bson.M{"$set": bson.M{"lastUsed": now}}
+		_, err = r.DB.Collection("api-keys").UpdateOne(context.Background(), filter, update)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &Auth{
+		APIKeyID:    apiKey.ID,
+		Permissions: permissions,
+	}, nil
+}
+
+// creatorPermissions returns the permissions of an API key which its creator still holds.
+func (r *Resolver) creatorPermissions(apiKey *model.APIKey) ([]model.Permission, error) {
+	creatorOID, err := primitive.ObjectIDFromHex(apiKey.CreatedBy)
+	if err != nil {
+		return nil, fmt.Errorf("API key has been revoked")
+	}
+	var creator model.User
+	opts := options.FindOne().SetProjection(bson.M{"disabled": 1, "role": 1, "staffRolesId": 1})
+	err = r.DB.Collection("users").FindOne(context.Background(), bson.M{"_id": creatorOID}, opts).Decode(&creator)
+	if err != nil || creator.Disabled {
+		return nil, fmt.Errorf("API key has been revoked")
+	}
+	held, err := r.permissionsOf(&creator)
+	if err != nil {
+		return nil, err
+	}
+	holds := map[model.Permission]bool{}
+	for _, permission := range held {
+		holds[permission] = true
+	}
+	permissions := []model.Permission{}
+	for _, permission := range apiKey.Permissions {
+		if holds[permission] {
+			permissions = append(permissions, permission)
+		}
+	}
+	return permissions, nil
+}
@@ -0,0 +1,73 @@
+package resolver
+
+import (
+	"book-store/graph/model"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestAuthFromAPIKey(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+	creatorID, roleID := primitive.NewObjectID(), primitive.NewObjectID()
+	key := "bsk_00000000_secret"
+	apiKey := mtest.CreateCursorResponse(0, "db.api-keys", mtest.FirstBatch, bson.D{
+		{Key: "_id", Value: primitive.NewObjectID()},
+		{Key: "hash", Value: hashAPIKey(key)},
+		{Key: "permissions", Value: bson.A{model.PermissionCatalogWrite, model.PermissionOrdersManage}},
+		{Key: "createdBy", Value: creatorID.Hex()},
+		{Key: "lastUsed", Value: time.Now().Unix()},
+	})
+	creator := func(fields ...bson.E) bson.D {
+		return mtest.CreateCursorResponse(0, "db.users", mtest.FirstBatch, append(bson.D{{Key: "_id", Value: creatorID}}, fields...))
+	}
+
+	mt.Run("admin creator", func(mt *mtest.T) {
+		r := &Resolver{DB: mt.DB}
+		mt.AddMockResponses(apiKey, creator(bson.E{Key: "role", Value: model.RoleAdmin}))
+		auth, err := r.authFromAPIKey(key)
+		if err != nil {
+			mt.Fatal(err)
+		}
+		if len(auth.Permissions) != 2 {
+			mt.Fatalf("expected the key to keep its permissions, got %v", auth.Permissions)
+		}
+	})
+
+	mt.Run("demoted creator", func(mt *mtest.T) {
+		r := &Resolver{DB: mt.DB}
+		role := bson.D{{Key: "_id", Value: roleID}, {Key: "name", Value: "Catalog"}, {Key: "permissions", Value: bson.A{model.PermissionCatalogWrite}}}
+		mt.AddMockResponses(
+			apiKey,
+			creator(bson.E{Key: "role", Value: model.RoleClient}, bson.E{Key: "staffRolesId", Value: bson.A{roleID.Hex()}}),
+			mtest.CreateCursorResponse(0, "db.staff-roles", mtest.FirstBatch, role),
+		)
+		auth, err := r.authFromAPIKey(key)
+		if err != nil {
+			mt.Fatal(err)
+		}
+		if len(auth.Permissions) != 1 || auth.Permissions[0] != model.PermissionCatalogWrite {
+			mt.Fatalf("expected the key to keep only the permissions its creator holds, got %v", auth.Permissions)
+		}
+	})
+
+	mt.Run("deleted creator", func(mt *mtest.T) {
+		r := &Resolver{DB: mt.DB}
+		mt.AddMockResponses(apiKey, mtest.CreateCursorResponse(0, "db.users", mtest.FirstBatch))
+		if _, err := r.authFromAPIKey(key); err == nil {
+			mt.Fatal("expected the key of a deleted user to be refused")
+		}
+	})
+
+	mt.Run("disabled creator", func(mt *mtest.T) {
+		r := &Resolver{DB: mt.DB}
+		mt.AddMockResponses(apiKey, creator(bson.E{Key: "role", Value: model.RoleAdmin}, bson.E{Key: "disabled", Value: true}))
+		if _, err := r.authFromAPIKey(key); err == nil {
+			mt.Fatal("expected the key of a disabled user to be refused")
+		}
+	})
+}
@@ -0,0 +1,84 @@
+package resolver
+
+import (
+	"book-store/graph/model"
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// validationError reports every field error of an input, the last one is returned as the resolver error.
+func validationError(ctx context.Context, errs []*gqlerror.Error) error {
+	for _, err := range errs[:len(errs)-1] {
+		graphql.AddError(ctx, err)
+	}
+	return errs[len(errs)-1]
+}
+
+// mergeIDs builds the aggregation expression of an id list field with the adding ids
+// appended, when not already in the list, and the removing ids left out.
+func mergeIDs(field string, adding []string, removing []string) bson.M {
+	current := bson.M{"$ifNull": bson.A{"$" + field, bson.A{}}}
+	added := bson.M{"$filter": bson.M{
+		"input": bson.M{"$literal": uniqueIDs(adding)},
+		"cond":  bson.M{"$not": bson.A{bson.M{"$in": bson.A{"$$this", current}}}},
+	}}
+	return bson.M{"$filter": bson.M{
+		"input": bson.M{"$concatArrays": bson.A{current, added}},
+		"cond":  bson.M{"$not": bson.A{bson.M{"$in": bson.A{"$$this", bson.M{"$literal": uniqueIDs(removing)}}}}},
+	}}
+}
+
+func uniqueIDs(ids []string) []string {
+	unique := []string{}
+	seen := map[string]bool{}
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+// checkBookReferences returns a field error for every missing topic, author or publisher,
+// prefix is the prefix of the input fields holding the topic and author ids, e.g. "adding".
+func (r *Resolver) checkBookReferences(prefix string, topicsID []string, authorsID []string, publisherID *string) []*gqlerror.Error {
+	var errs []*gqlerror.Error
+	topicsField, authorsField := "topicsId", "authorsId"
+	if prefix != "" {
+		topicsField, authorsField = prefix+"TopicsId", prefix+"AuthorsId"
+	}
+	if err := r.checkReferences("topics", "Topic", topicsID); err != nil {
+		errs = append(errs, model.FieldError(topicsField, err.Error()))
+	}
+	if err := r.checkReferences("authors", "Author", authorsID); err != nil {
+		errs = append(errs, model.FieldError(authorsField, err.Error()))
+	}
+	if publisherID != nil {
+		if err := r.checkReferences("publishers", "Publisher", []string{*publisherID}); err != nil {
+			errs = append(errs, model.FieldError("publisherId", err.Error()))
+		}
+	}
+	return errs
+}
+
+// checkISBNUnique returns a field error when another book than bookID already has the ISBN,
+// the unique index still catches concurrent writes.
+func (r *Resolver) checkISBNUnique(isbn string, bookID string) []*gqlerror.Error {
+	filter := bson.M{"isbn": isbn}
+	if bookOID, err := primitive.ObjectIDFromHex(bookID); err == nil {
+		filter["_id"] = bson.M{"$ne": bookOID}
+	}
+	count, err := r.DB.Collection("books").CountDocuments(context.Background(), filter)
+	if err != nil {
+		return []*gqlerror.Error{gqlerror.Errorf("%v", err.Error())}
+	}
+	if count > 0 {
+		return []*gqlerror.Error{model.FieldError("isbn", "A book with ISBN %v already exists", isbn)}
+	}
+	return nil
+}
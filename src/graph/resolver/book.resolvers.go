@@ -8,10 +8,78 @@ import (
 	"book-store/graph/model"
 	"context"
 
+	"github.com/99designs/gqlgen/graphql"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
+func (r *bookResolver) Cover(ctx context.Context, obj *model.Book, size model.CoverSize) (*model.Cover, error) {
+	bookOID, err := primitive.ObjectIDFromHex(obj.ID)
+	if err != nil {
+		return nil, err
+	}
+	image, err := r.loadCoverImage(ctx, bookOID)
+	if err != nil || image == nil {
+		return nil, err
+	}
+	rendition := image.Rendition(size)
+	if rendition == nil {
+		return nil, nil
+	}
+	return &model.Cover{
+		URL:    r.Blobs.URL(rendition.Key),
+		Width:  rendition.Width,
+		Height: rendition.Height,
+	}, nil
+}
+
+func (r *bookResolver) Publisher(ctx context.Context, obj *model.Book) (*model.Publisher, error) {
+	if obj.PublisherID == nil {
+		return nil, nil
+	}
+	publisherOID, err := primitive.ObjectIDFromHex(*obj.PublisherID)
+	if err != nil {
+		graphql.AddErrorf(ctx, "Book %v references an invalid publisher id %v", obj.ID, *obj.PublisherID)
+		return nil, nil
+	}
+	var publisher *model.Publisher
+	err = r.DB.Collection("publishers").FindOne(context.Background(), bson.M{"_id": publisherOID}).Decode(&publisher)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return publisher, nil
+}
+
+func (r *bookResolver) Series(ctx context.Context, obj *model.Book) (*model.Series, error) {
+	if obj.SeriesID == nil {
+		return nil, nil
+	}
+	series, err := r.findSeries(*obj.SeriesID)
+	if err != nil {
+		graphql.AddError(ctx, err)
+		return nil, nil
+	}
+	return series, nil
+}
+
+func (r *bookResolver) NextInSeries(ctx context.Context, obj *model.Book) (*model.Book, error) {
+	if obj.SeriesID == nil || obj.SeriesPosition == nil {
+		return nil, nil
+	}
+	return r.bookInSeries(*obj.SeriesID, bson.M{"$gt": *obj.SeriesPosition}, 1)
+}
+
+func (r *bookResolver) PreviousInSeries(ctx context.Context, obj *model.Book) (*model.Book, error) {
+	if obj.SeriesID == nil || obj.SeriesPosition == nil {
+		return nil, nil
+	}
+	return r.bookInSeries(*obj.SeriesID, bson.M{"$lt": *obj.SeriesPosition}, -1)
+}
+
 func (r *bookResolver) Topics(ctx context.Context, obj *model.Book) ([]*model.Topic, error) {
 	if len(obj.TopicsID) == 0 {
 		return nil, nil
@@ -20,6 +88,7 @@ func (r *bookResolver) Topics(ctx context.Context, obj *model.Book) ([]*model.To
 	for _, id := range obj.TopicsID {
 		objId, err := primitive.ObjectIDFromHex(id)
 		if err != nil {
+			graphql.AddErrorf(ctx, "Book %v references an invalid topic id %v", obj.ID, id)
 			continue
 		}
 		topicsId = append(topicsId, objId)
@@ -46,6 +115,7 @@ func (r *bookResolver) Authors(ctx context.Context, obj *model.Book) ([]*model.A
 	for _, id := range obj.AuthorsID {
 		objId, err := primitive.ObjectIDFromHex(id)
 		if err != nil {
+			graphql.AddErrorf(ctx, "Book %v references an invalid author id %v", obj.ID, id)
 			continue
 		}
 		authorsId = append(authorsId, objId)
@@ -79,7 +149,43 @@ func (r *bookResolver) Reviews(ctx context.Context, obj *model.Book) ([]*model.R
 	return reviews, nil
 }
 
+func (r *bookResolver) PriceHistory(ctx context.Context, obj *model.Book, pagination *model.Pagination) ([]*model.PriceChange, error) {
+	opts := paginationOptions(pagination).SetSort(bson.M{"created": -1})
+	cs, err := r.DB.Collection("price-changes").Find(context.Background(), bson.M{"bookId": obj.ID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	var changes []*model.PriceChange
+	defer cs.Close(context.Background())
+	err = cs.All(context.Background(), &changes)
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+func (r *bookResolver) PriceSchedules(ctx context.Context, obj *model.Book) ([]*model.PriceSchedule, error) {
+	var schedules []*model.PriceSchedule
+	err := findAll(r.DB, "price-schedules", bson.M{"bookId": obj.ID}, &schedules)
+	if err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+func (r *bookResolver) LocalPrice(ctx context.Context, obj *model.Book, currency *string) (*model.Money, error) {
+	return r.localPrice(ctx, obj.Price, currency)
+}
+
+func (r *bookVariantResolver) LocalPrice(ctx context.Context, obj *model.BookVariant, currency *string) (*model.Money, error) {
+	return r.localPrice(ctx, obj.Price, currency)
+}
+
 // Book returns generated.BookResolver implementation.
 func (r *Resolver) Book() generated.BookResolver { return &bookResolver{r} }
 
+// BookVariant returns generated.BookVariantResolver implementation.
+func (r *Resolver) BookVariant() generated.BookVariantResolver { return &bookVariantResolver{r} }
+
 type bookResolver struct{ *Resolver }
+type bookVariantResolver struct{ *Resolver }
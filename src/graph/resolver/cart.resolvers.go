@@ -7,29 +7,120 @@ import (
 	"book-store/graph/generated"
 	"book-store/graph/model"
 	"context"
+	"time"
 
-	"go.mongodb.org/mongo-driver/bson"
+	"github.com/99designs/gqlgen/graphql"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
+func (r *cartResolver) Total(ctx context.Context, obj *model.Cart, currency *string) (*model.Money, error) {
+	target := requestCurrency(ctx, currency)
+	if target == "" {
+		target = model.StoreCurrency()
+	}
+	books, err := r.cartBooks(obj)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().Unix()
+	total := model.Money{Currency: target}
+	for _, item := range obj.Items {
+		price, err := cartItemPrice(item, books[item.BookID])
+		if err != nil {
+			return nil, err
+		}
+		if price == nil {
+			continue
+		}
+		converted, err := r.convertPrice(*price, target, now)
+		if err != nil {
+			return nil, err
+		}
+		total, _ = total.Add(converted.Times(item.Quantity))
+	}
+	return &total, nil
+}
+
+func (r *cartResolver) Tax(ctx context.Context, obj *model.Cart, addressID *string, currency *string) (*model.TaxBreakdown, error) {
+	var address *model.Address
+	var err error
+	if addressID != nil {
+		address, err = r.findAddress(obj.UserID, *addressID)
+	} else {
+		address, err = r.findDefaultAddress(obj.UserID, "defaultShipping")
+	}
+	if err != nil || address == nil {
+		return nil, err
+	}
+	target := requestCurrency(ctx, currency)
+	if target == "" {
+		target = model.StoreCurrency()
+	}
+	items, err := r.cartOrderItems(obj, target, time.Now().Unix())
+	if err != nil {
+		return nil, err
+	}
+	return r.computeTax(items, nil, taxLocation(address.Country, address.Region), target)
+}
+
+func (r *cartResolver) ShippingOptions(ctx context.Context, obj *model.Cart, addressID string, currency *string) ([]*model.ShippingQuote, error) {
+	address, err := r.findAddress(obj.UserID, addressID)
+	if err != nil {
+		return nil, err
+	}
+	target := requestCurrency(ctx, currency)
+	if target == "" {
+		target = model.StoreCurrency()
+	}
+	now := time.Now().Unix()
+	items, err := r.cartOrderItems(obj, target, now)
+	if err != nil {
+		return nil, err
+	}
+	return r.shippingQuotes(items, address.Country, target, now)
+}
+
 func (r *cartItemResolver) Book(ctx context.Context, obj *model.CartItem) (*model.Book, error) {
 	bookId, err := primitive.ObjectIDFromHex(obj.BookID)
 	if err != nil {
 		return nil, err
 	}
-	var book *model.Book
-	err = r.DB.Collection("books").FindOne(context.Background(), bson.M{"_id": bookId}).Decode(&book)
-	if err == mongo.ErrNoDocuments {
+	return r.loadBook(ctx, bookId)
+}
+
+func (r *cartItemResolver) Variant(ctx context.Context, obj *model.CartItem) (*model.BookVariant, error) {
+	if obj.VariantID == nil {
 		return nil, nil
 	}
+	_, variant, err := r.findBookByVariant(*obj.VariantID)
+	if err != nil {
+		graphql.AddError(ctx, err)
+		return nil, nil
+	}
+	return variant, nil
+}
+
+func (r *cartItemResolver) Price(ctx context.Context, obj *model.CartItem, currency *string) (*model.Money, error) {
+	bookId, err := primitive.ObjectIDFromHex(obj.BookID)
 	if err != nil {
 		return nil, err
 	}
-	return book, nil
+	book, err := r.loadBook(ctx, bookId)
+	if err != nil {
+		return nil, err
+	}
+	price, err := cartItemPrice(obj, book)
+	if err != nil || price == nil {
+		return nil, err
+	}
+	return r.localPrice(ctx, *price, currency)
 }
 
+// Cart returns generated.CartResolver implementation.
+func (r *Resolver) Cart() generated.CartResolver { return &cartResolver{r} }
+
 // CartItem returns generated.CartItemResolver implementation.
 func (r *Resolver) CartItem() generated.CartItemResolver { return &cartItemResolver{r} }
 
+type cartResolver struct{ *Resolver }
 type cartItemResolver struct{ *Resolver }
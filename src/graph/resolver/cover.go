@@ -0,0 +1,61 @@
+package resolver
+
+import (
+	"book-store/cover"
+	"book-store/graph/model"
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// findCoverImage returns the stored cover of a book, or nil when the book has no cover.
+func (r *Resolver) findCoverImage(bookOID primitive.ObjectID) (*model.CoverImage, error) {
+	var book struct {
+		CoverImage *model.CoverImage `bson:"coverImage"`
+	}
+	opts := options.FindOne().SetProjection(bson.M{"coverImage": 1})
+	err := r.DB.Collection("books").FindOne(context.Background(), bson.M{"_id": bookOID}, opts).Decode(&book)
+	if err != nil {
+		return nil, err
+	}
+	return book.CoverImage, nil
+}
+
+// storeCoverRenditions puts the renditions of a cover in the blob store, keys are unique
+// for every upload so that cached copies of a previous cover are never served.
+func (r *Resolver) storeCoverRenditions(ctx context.Context, bookID string, renditions []*cover.Rendition) (*model.CoverImage, error) {
+	image := &model.CoverImage{Updated: time.Now().Unix()}
+	version := primitive.NewObjectID().Hex()
+	for _, rendition := range renditions {
+		stored := &model.CoverRendition{
+			Size:   model.CoverSize(rendition.Size),
+			Key:    fmt.Sprintf("covers/%v/%v-%v.jpg", bookID, version, rendition.Size),
+			Width:  int64(rendition.Width),
+			Height: int64(rendition.Height),
+		}
+		err := r.Blobs.Put(ctx, stored.Key, "image/jpeg", rendition.Data)
+		if err != nil {
+			r.deleteCoverBlobs(ctx, image)
+			return nil, err
+		}
+		image.Renditions = append(image.Renditions, stored)
+	}
+	return image, nil
+}
+
+func (r *Resolver) deleteCoverBlobs(ctx context.Context, image *model.CoverImage) error {
+	if image == nil {
+		return nil
+	}
+	for _, rendition := range image.Renditions {
+		err := r.Blobs.Delete(ctx, rendition.Key)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
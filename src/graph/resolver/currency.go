@@ -0,0 +1,157 @@
+package resolver
+
+import (
+	"book-store/graph/model"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// requestCurrency is the currency argument of a field, or else the currency of the X-Currency header,
+// it is empty without either.
+func requestCurrency(ctx context.Context, currency *string) string {
+	if currency != nil && *currency != "" {
+		return strings.ToUpper(*currency)
+	}
+	ginContext, err := GinContextFromContext(ctx)
+	if err != nil {
+		return ""
+	}
+	return strings.ToUpper(ginContext.GetHeader("X-Currency"))
+}
+
+// exchangeRate returns the rate of a currency in effect at a time, the base currency has no rate.
+func (r *Resolver) exchangeRate(currency string, at int64) (*model.ExchangeRate, error) {
+	if currency == model.StoreCurrency() {
+		return nil, nil
+	}
+	var rate *model.ExchangeRate
+	filter := bson.M{"currency": currency, "effectiveFrom": bson.M{"$lte": at}}
+	opts := options.FindOne().SetSort(bson.M{"effectiveFrom": -1})
+	err := r.DB.Collection("exchange-rates").FindOne(context.Background(), filter, opts).Decode(&rate)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("Currency %v isn't supported", currency)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return rate, nil
+}
+
+// convertPrice converts a price to a currency through the base currency with the rates in effect at a time,
+// the converted price is rounded with the rounding rules of the rate of the currency.
+func (r *Resolver) convertPrice(price model.Money, currency string, at int64) (model.Money, error) {
+	if price.Currency == currency {
+		return price, nil
+	}
+	value := price.Rat()
+	from, err := r.exchangeRate(price.Currency, at)
+	if err != nil {
+		return model.Money{}, err
+	}
+	if from != nil {
+		value.Quo(value, from.Ratio())
+	}
+	to, err := r.exchangeRate(currency, at)
+	if err != nil {
+		return model.Money{}, err
+	}
+	if to == nil {
+		return model.RoundMoney(value, currency, model.RoundingModeHalfUp, 1), nil
+	}
+	value.Mul(value, to.Ratio())
+	return model.RoundMoney(value, currency, to.Rounding, to.RoundingIncrement), nil
+}
+
+// localPrice converts a price to the currency of the request, the price is returned as is when
+// the request has no currency.
+func (r *Resolver) localPrice(ctx context.Context, price model.Money, currency *string) (*model.Money, error) {
+	target := requestCurrency(ctx, currency)
+	if target == "" {
+		return &price, nil
+	}
+	converted, err := r.convertPrice(price, target, time.Now().Unix())
+	if err != nil {
+		return nil, err
+	}
+	return &converted, nil
+}
+
+// cartItemPrice returns the unit price of a cart item with its book, there is none until the variant of a book
+// with variants is chosen.
+func cartItemPrice(item *model.CartItem, book *model.Book) (*model.Money, error) {
+	if book == nil {
+		return nil, fmt.Errorf("Book %v doesn't exist", item.BookID)
+	}
+	if item.VariantID != nil {
+		variant := book.Variant(*item.VariantID)
+		if variant == nil {
+			return nil, fmt.Errorf("Variant %v doesn't exist", *item.VariantID)
+		}
+		return &variant.Price, nil
+	}
+	if len(book.Variants) > 0 {
+		return nil, nil
+	}
+	return &book.Price, nil
+}
+
+// convertOrderItems converts the item prices of an order to the charged currency, it returns a copy of
+// the items priced in the base currency and the exchange rate of the charged currency.
+func (r *Resolver) convertOrderItems(items []*model.OrderItem, currency string, at int64) ([]*model.OrderItem, *string, error) {
+	baseItems := make([]*model.OrderItem, len(items))
+	for i, item := range items {
+		baseItem := *item
+		var err error
+		baseItem.Price, err = r.convertPrice(item.Price, model.StoreCurrency(), at)
+		if err != nil {
+			return nil, nil, err
+		}
+		baseItems[i] = &baseItem
+		item.Price, err = r.convertPrice(item.Price, currency, at)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	rate, err := r.exchangeRate(currency, at)
+	if err != nil || rate == nil {
+		return baseItems, nil, err
+	}
+	return baseItems, &rate.Rate, nil
+}
+
+// cartOrderItems returns the items of a cart priced in a currency, like the cart total
+// the items without a price yet are left out.
+func (r *Resolver) cartOrderItems(cart *model.Cart, currency string, at int64) ([]*model.OrderItem, error) {
+	books, err := r.cartBooks(cart)
+	if err != nil {
+		return nil, err
+	}
+	priced := &model.Cart{UserID: cart.UserID}
+	for _, item := range cart.Items {
+		price, err := cartItemPrice(item, books[item.BookID])
+		if err != nil {
+			return nil, err
+		}
+		if price != nil && item.Quantity > 0 {
+			priced.Items = append(priced.Items, item)
+		}
+	}
+	if len(priced.Items) == 0 {
+		return []*model.OrderItem{}, nil
+	}
+	items, err := cartOrderItemsOf(priced, books)
+	if err != nil {
+		return nil, err
+	}
+	_, _, err = r.convertOrderItems(items, currency, at)
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,68 @@
+package resolver
+
+import (
+	"book-store/graph/model"
+	"context"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestCartItemPrice(t *testing.T) {
+	variantID := "v1"
+	book := &model.Book{ID: "b", Price: model.Money{Amount: 1000, Currency: "USD"}}
+	withVariants := &model.Book{ID: "b", Variants: []*model.BookVariant{{ID: variantID, Price: model.Money{Amount: 1500, Currency: "USD"}}}}
+	missing := "v2"
+	tests := []struct {
+		name    string
+		item    *model.CartItem
+		book    *model.Book
+		price   *int64
+		invalid bool
+	}{
+		{"book", &model.CartItem{BookID: "b"}, book, &book.Price.Amount, false},
+		{"variant", &model.CartItem{BookID: "b", VariantID: &variantID}, withVariants, &withVariants.Variants[0].Price.Amount, false},
+		{"variant not chosen", &model.CartItem{BookID: "b"}, withVariants, nil, false},
+		{"missing variant", &model.CartItem{BookID: "b", VariantID: &missing}, withVariants, nil, true},
+		{"missing book", &model.CartItem{BookID: "b"}, nil, nil, true},
+	}
+	for _, test := range tests {
+		price, err := cartItemPrice(test.item, test.book)
+		if (err != nil) != test.invalid {
+			t.Fatalf("%v: unexpected error %v", test.name, err)
+		}
+		if (price == nil) != (test.price == nil) || (price != nil && price.Amount != *test.price) {
+			t.Fatalf("%v: unexpected price %v", test.name, price)
+		}
+	}
+}
+
+func TestCartTotal(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("one query for the books", func(mt *mtest.T) {
+		r := &Resolver{DB: mt.DB}
+		first, second := primitive.NewObjectID(), primitive.NewObjectID()
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.books", mtest.FirstBatch,
+			bson.D{{Key: "_id", Value: first}, {Key: "price", Value: model.Money{Amount: 1000, Currency: model.StoreCurrency()}}},
+			bson.D{{Key: "_id", Value: second}, {Key: "price", Value: model.Money{Amount: 250, Currency: model.StoreCurrency()}}},
+		))
+		cart := &model.Cart{Items: []*model.CartItem{
+			{BookID: first.Hex(), Quantity: 2},
+			{BookID: second.Hex(), Quantity: 1},
+		}}
+		total, err := (&cartResolver{r}).Total(context.Background(), cart, nil)
+		if err != nil {
+			mt.Fatal(err)
+		}
+		if total.Amount != 2250 {
+			mt.Fatalf("expected a total of 2250, got %v", total.Amount)
+		}
+		if finds := commands(mt)["find"]; len(finds) != 1 {
+			mt.Fatalf("expected the books to be read with a single query, got %v", len(finds))
+		}
+	})
+}
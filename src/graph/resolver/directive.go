@@ -0,0 +1,74 @@
+package resolver
+
+import (
+	"book-store/graph/generated"
+	"book-store/graph/model"
+	"context"
+	"fmt"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+func (r *Resolver) Directives() generated.DirectiveRoot {
+	return generated.DirectiveRoot{
+		Auth:          r.AuthDirective,
+		HasRole:       r.HasRoleDirective,
+		HasPermission: r.HasPermissionDirective,
+		Public:        r.PublicDirective,
+	}
+}
+
+// AuthDirective requires a user token, the authenticated user is stored in the context
+// so that resolvers can read it back with GetAuthFromContext.
+func (r *Resolver) AuthDirective(ctx context.Context, obj interface{}, next graphql.Resolver, enforceTwoFactor *bool) (interface{}, error) {
+	auth, err := r.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if auth.APIKeyID != "" {
+		return nil, fmt.Errorf("API keys are not allowed for this operation")
+	}
+	if enforceTwoFactor == nil || *enforceTwoFactor {
+		err = checkTwoFactorPolicy(auth)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return next(context.WithValue(ctx, authContextKey{}, auth))
+}
+
+func (r *Resolver) HasRoleDirective(ctx context.Context, obj interface{}, next graphql.Resolver, role model.Role) (interface{}, error) {
+	auth, err := r.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = checkTwoFactorPolicy(auth)
+	if err != nil {
+		return nil, err
+	}
+	if auth.Role != role.String() {
+		return nil, fmt.Errorf("Access denied")
+	}
+	return next(context.WithValue(ctx, authContextKey{}, auth))
+}
+
+// HasPermissionDirective is satisfied by a user granted the permission or by an API key with it in its scopes.
+func (r *Resolver) HasPermissionDirective(ctx context.Context, obj interface{}, next graphql.Resolver, permission model.Permission) (interface{}, error) {
+	auth, err := r.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = checkTwoFactorPolicy(auth)
+	if err != nil {
+		return nil, err
+	}
+	if !auth.HasPermission(permission) {
+		return nil, fmt.Errorf("Access denied")
+	}
+	return next(context.WithValue(ctx, authContextKey{}, auth))
+}
+
+// PublicDirective marks a field which is intentionally available without a token.
+func (r *Resolver) PublicDirective(ctx context.Context, obj interface{}, next graphql.Resolver) (interface{}, error) {
+	return next(ctx)
+}
@@ -0,0 +1,24 @@
+package resolver
+
+import (
+	"book-store/graph/generated"
+	"strings"
+	"testing"
+)
+
+func TestEveryOperationHasDirective(t *testing.T) {
+	schema := generated.NewExecutableSchema(generated.Config{}).Schema()
+	for _, operation := range []string{"Query", "Mutation"} {
+		for _, field := range schema.Types[operation].Fields {
+			if strings.HasPrefix(field.Name, "__") {
+				continue
+			}
+			if field.Directives.ForName("auth") == nil &&
+				field.Directives.ForName("hasRole") == nil &&
+				field.Directives.ForName("hasPermission") == nil &&
+				field.Directives.ForName("public") == nil {
+				t.Errorf("%v.%v has no @auth, @hasRole, @hasPermission or @public directive", operation, field.Name)
+			}
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package resolver
+
+import (
+	"book-store/catalog"
+	"book-store/graph/model"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ExportURLLifetime is how long the signed download URL of an export stays valid.
+const ExportURLLifetime = time.Hour
+
+// ExportBlobKey is the key of the file of an export job in the blob store.
+func ExportBlobKey(jobID string, format string) string {
+	return "exports/" + catalog.ExportFileName(jobID, format)
+}
+
+// runExport writes the catalog to the blob store, it is meant to be run in the background
+// once the job is saved. Books are read with a cursor and written to a temporary file which
+// is then streamed to the store, so they never are all in memory.
+func (r *Resolver) runExport(job *model.ExportJob) {
+	err := r.safeWriteExport(job)
+	if err != nil {
+		message := err.Error()
+		job.Error = &message
+		job.Status = model.ExportJobStatusFailed
+	} else {
+		job.Status = model.ExportJobStatusCompleted
+	}
+	job.Updated = time.Now().Unix()
+	jobOID, _ := primitive.ObjectIDFromHex(job.ID)
+	update := bson.M{"$set": bson.M{
+		"status":  job.Status,
+		"error":   job.Error,
+		"total":   job.Total,
+		"updated": job.Updated,
+	}}
+	r.DB.Collection("export-jobs").UpdateOne(context.Background(), bson.M{"_id": jobOID}, update)
+}
+
+// safeWriteExport writes an export, a panic is returned as an error so it fails the job rather than the server.
+func (r *Resolver) safeWriteExport(job *model.ExportJob) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			log.Printf("Export %v panicked: %v\n%s", job.ID, p, debug.Stack())
+			err = fmt.Errorf("Export stopped unexpectedly: %v", p)
+		}
+	}()
+	return r.writeExport(job)
+}
+
+func (r *Resolver) writeExport(job *model.ExportJob) error {
+	names, err := r.exportNames()
+	if err != nil {
+		return err
+	}
+	file, err := os.CreateTemp("", "export-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+	writer, err := catalog.NewWriter(string(job.Format), file)
+	if err != nil {
+		return err
+	}
+	opts := options.Find().SetSort(bson.M{"_id": 1})
+	cs, err := r.DB.Collection("books").Find(context.Background(), bson.M{}, opts)
+	if err != nil {
+		return err
+	}
+	defer cs.Close(context.Background())
+	storeURL := strings.TrimSuffix(os.Getenv("STORE_URL"), "/")
+	for cs.Next(context.Background()) {
+		var book storedBook
+		err = cs.Decode(&book)
+		if err != nil {
+			return err
+		}
+		exported := names.exportBook(&book.Book)
+		if book.CoverImage != nil {
+			if rendition := book.CoverImage.Rendition(model.CoverSizeLarge); rendition != nil {
+				exported.CoverURL = r.Blobs.URL(rendition.Key)
+			}
+		}
+		if storeURL != "" {
+			exported.Link = storeURL + "/books/" + book.ID
+		}
+		err = writer.Write(exported)
+		if err != nil {
+			return err
+		}
+		job.Total++
+	}
+	if err = cs.Err(); err != nil {
+		return err
+	}
+	if err = writer.Close(); err != nil {
+		return err
+	}
+	size, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	format := string(job.Format)
+	return r.Documents.PutStream(context.Background(), ExportBlobKey(job.ID, format), catalog.ExportContentType(format), file, size)
+}
+
+// exportNames resolves the ids referenced by books to the names written in an export.
+type exportNames struct {
+	authors    map[string]string
+	publishers map[string]string
+	topics     map[string]*model.Topic
+}
+
+func (r *Resolver) exportNames() (*exportNames, error) {
+	names := &exportNames{authors: map[string]string{}, publishers: map[string]string{}, topics: map[string]*model.Topic{}}
+	var authors []*model.Author
+	if err := findAll(r.DB, "authors", bson.M{}, &authors); err != nil {
+		return nil, err
+	}
+	for _, author := range authors {
+		names.authors[author.ID] = author.Name
+	}
+	var publishers []*model.Publisher
+	if err := findAll(r.DB, "publishers", bson.M{}, &publishers); err != nil {
+		return nil, err
+	}
+	for _, publisher := range publishers {
+		names.publishers[publisher.ID] = publisher.Name
+	}
+	var topics []*model.Topic
+	if err := findAll(r.DB, "topics", bson.M{}, &topics); err != nil {
+		return nil, err
+	}
+	for _, topic := range topics {
+		names.topics[topic.ID] = topic
+	}
+	return names, nil
+}
+
+// topicPath returns the names of a topic and its ancestors, from the root.
+func (names *exportNames) topicPath(id string) []string {
+	path := []string{}
+	for depth := 0; depth < maxTopicDepth; depth++ {
+		topic, ok := names.topics[id]
+		if !ok {
+			break
+		}
+		path = append([]string{topic.Name}, path...)
+		if topic.ParentID == nil {
+			break
+		}
+		id = *topic.ParentID
+	}
+	return path
+}
+
+func (names *exportNames) exportBook(book *model.Book) *catalog.ExportBook {
+	exported := &catalog.ExportBook{
+		ID:          book.ID,
+		Title:       book.Name,
+		Description: book.Content,
+		Price:       json.Number(book.Price.Decimal()),
+		Currency:    book.Price.Currency,
+		Authors:     []string{},
+		Topics:      [][]string{},
+		PageCount:   book.PageCount,
+		Variants:    []catalog.ExportVariant{},
+	}
+	exported.ISBN = valueOf(book.Isbn)
+	exported.PublicationDate = valueOf(book.PublicationDate)
+	exported.Language = valueOf(book.Language)
+	exported.Edition = valueOf(book.Edition)
+	if book.Format != nil {
+		exported.Format = string(*book.Format)
+	}
+	if book.PublisherID != nil {
+		exported.Publisher = names.publishers[*book.PublisherID]
+	}
+	for _, id := range book.AuthorsID {
+		if name, ok := names.authors[id]; ok {
+			exported.Authors = append(exported.Authors, name)
+		}
+	}
+	for _, id := range book.TopicsID {
+		if path := names.topicPath(id); len(path) > 0 {
+			exported.Topics = append(exported.Topics, path)
+		}
+	}
+	for _, variant := range book.Variants {
+		exported.Variants = append(exported.Variants, catalog.ExportVariant{
+			ID:       variant.ID,
+			SKU:      variant.Sku,
+			Format:   string(variant.Format),
+			Price:    json.Number(variant.Price.Decimal()),
+			Currency: variant.Price.Currency,
+			Stock:    variant.Stock,
+			Weight:   variant.Weight,
+		})
+	}
+	return exported
+}
+
+func valueOf(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
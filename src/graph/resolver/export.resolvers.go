@@ -0,0 +1,27 @@
+package resolver
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+
+import (
+	"book-store/graph/generated"
+	"book-store/graph/model"
+	"book-store/signedurl"
+	"context"
+	"os"
+	"time"
+)
+
+func (r *exportJobResolver) DownloadURL(ctx context.Context, obj *model.ExportJob) (*string, error) {
+	if obj.Status != model.ExportJobStatusCompleted {
+		return nil, nil
+	}
+	path := "/exports/" + obj.ID
+	url := os.Getenv("PUBLIC_URL") + signedurl.Sign(os.Getenv("SIGNED_URL_SECRET"), path, time.Now().Add(ExportURLLifetime))
+	return &url, nil
+}
+
+// ExportJob returns generated.ExportJobResolver implementation.
+func (r *Resolver) ExportJob() generated.ExportJobResolver { return &exportJobResolver{r} }
+
+type exportJobResolver struct{ *Resolver }
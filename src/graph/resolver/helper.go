@@ -1,6 +1,7 @@
 package resolver
 
 import (
+	"book-store/graph/model"
 	"context"
 	"fmt"
 	"os"
@@ -9,6 +10,9 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 func GinContextFromContext(ctx context.Context) (*gin.Context, error) {
@@ -27,12 +31,92 @@ func GinContextFromContext(ctx context.Context) (*gin.Context, error) {
 }
 
 type Auth struct {
-	UID   string
-	Email string
-	Role  string
+	UID         string
+	Email       string
+	Role        string
+	TwoFactor   bool
+	Permissions []model.Permission
+	// APIKeyID is set instead of UID when a machine client authenticates with an API key.
+	APIKeyID string
+	Issued   int64
 }
 
+type authContextKey struct{}
+
+// GetAuthFromContext returns the user authenticated by the @auth or @hasRole directive,
+// it falls back to reading the token for fields without a directive.
 func GetAuthFromContext(ctx context.Context) (*Auth, error) {
+	if auth, ok := ctx.Value(authContextKey{}).(*Auth); ok {
+		return auth, nil
+	}
+	auth, err := authFromToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = checkTwoFactorPolicy(auth)
+	if err != nil {
+		return nil, err
+	}
+	return auth, nil
+}
+
+// authenticate accepts either an API key in the X-API-Key header or a Bearer token.
+func (r *Resolver) authenticate(ctx context.Context) (*Auth, error) {
+	ginContext, err := GinContextFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if key := ginContext.GetHeader("X-API-Key"); key != "" {
+		return r.authFromAPIKey(key)
+	}
+	auth, err := authFromToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = r.checkAccount(auth)
+	if err != nil {
+		return nil, err
+	}
+	return auth, nil
+}
+
+// checkAccount rejects the tokens of disabled users and the tokens issued before the last password change.
+// The role and the permissions of the token are read again from the account, so that changing them
+// takes effect without waiting for the token to expire.
+func (r *Resolver) checkAccount(auth *Auth) error {
+	userOID, err := primitive.ObjectIDFromHex(auth.UID)
+	if err != nil {
+		return fmt.Errorf("Invalid token")
+	}
+	var user model.User
+	opts := options.FindOne().SetProjection(bson.M{"disabled": 1, "passwordChanged": 1, "role": 1, "staffRolesId": 1})
+	err = r.DB.Collection("users").FindOne(context.Background(), bson.M{"_id": userOID}, opts).Decode(&user)
+	if err != nil {
+		return fmt.Errorf("Invalid token")
+	}
+	if user.Disabled {
+		return fmt.Errorf("Account is disabled")
+	}
+	if auth.Issued < user.PasswordChanged {
+		return fmt.Errorf("Token has been revoked, please login again")
+	}
+	permissions, err := r.permissionsOf(&user)
+	if err != nil {
+		return err
+	}
+	auth.Role = user.Role.String()
+	auth.Permissions = permissions
+	return nil
+}
+
+func checkTwoFactorPolicy(auth *Auth) error {
+	if model.TwoFactorRequired(model.Role(auth.Role)) && !auth.TwoFactor {
+		return fmt.Errorf("Two-factor authentication is required for %v accounts", auth.Role)
+	}
+	return nil
+}
+
+func authFromToken(ctx context.Context) (*Auth, error) {
 	ginContext, err := GinContextFromContext(ctx)
 	if err != nil {
 		return nil, err
@@ -42,7 +126,39 @@ func GetAuthFromContext(ctx context.Context) (*Auth, error) {
 	if len(bearerTokens) != 2 || bearerTokens[0] != "Bearer" {
 		return nil, fmt.Errorf("Invalid token, please provide a Bearer token")
 	}
-	token, err := jwt.Parse(bearerTokens[1], func(t *jwt.Token) (interface{}, error) {
+	claims, err := parseToken(bearerTokens[1])
+	if err != nil {
+		return nil, err
+	}
+	// the other tokens signed with the same secret, like the two-factor challenge, aren't access tokens
+	if claims["typ"] != model.AccessTokenType {
+		return nil, fmt.Errorf("Invalid token")
+	}
+	uid, _ := claims["uid"].(string)
+	email, _ := claims["email"].(string)
+	role, _ := claims["role"].(string)
+	twoFactor, _ := claims["mfa"].(bool)
+	issued, _ := claims["iat"].(float64)
+	permissions := []model.Permission{}
+	if perms, ok := claims["perms"].([]interface{}); ok {
+		for _, perm := range perms {
+			if permission, ok := perm.(string); ok {
+				permissions = append(permissions, model.Permission(permission))
+			}
+		}
+	}
+	return &Auth{
+		UID:         uid,
+		Email:       email,
+		Role:        role,
+		TwoFactor:   twoFactor,
+		Permissions: permissions,
+		Issued:      int64(issued),
+	}, nil
+}
+
+func parseToken(tokenString string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
 		if t.Method.Alg() != jwt.SigningMethodHS256.Name {
 			return nil, fmt.Errorf("Invalid signing method: %v", t.Method.Alg())
 		}
@@ -51,20 +167,35 @@ func GetAuthFromContext(ctx context.Context) (*Auth, error) {
 	if err != nil {
 		return nil, err
 	}
-	if claims, ok := token.Claims.(jwt.MapClaims); token.Valid && ok {
-		exp := claims["exp"].(float64)
-		if int64(exp) < time.Now().Unix() {
-			return nil, fmt.Errorf("Token is expired")
-		}
-		uid := claims["uid"].(string)
-		email := claims["email"].(string)
-		role := claims["role"].(string)
-		return &Auth{
-			UID:   uid,
-			Email: email,
-			Role:  role,
-		}, nil
-	} else {
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !token.Valid || !ok {
 		return nil, fmt.Errorf("Invalid token")
 	}
+	exp, ok := claims["exp"].(float64)
+	if !ok || int64(exp) < time.Now().Unix() {
+		return nil, fmt.Errorf("Token is expired")
+	}
+	return claims, nil
+}
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+func paginationOptions(pagination *model.Pagination) *options.FindOptions {
+	limit := int64(defaultPageSize)
+	offset := int64(0)
+	if pagination != nil {
+		if pagination.Limit != nil && *pagination.Limit > 0 {
+			limit = *pagination.Limit
+		}
+		if pagination.Offset != nil && *pagination.Offset > 0 {
+			offset = *pagination.Offset
+		}
+	}
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
+	return options.Find().SetLimit(limit).SetSkip(offset)
 }
@@ -0,0 +1,299 @@
+package resolver
+
+import (
+	"book-store/catalog"
+	"book-store/graph/model"
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// the job is saved every importProgressInterval rows so its progress can be followed
+	importProgressInterval = 50
+	// rows failing past maxImportRowErrors are counted but their errors aren't kept
+	maxImportRowErrors = 1000
+)
+
+// catalogImporter creates or updates the books of an import file, the authors, publishers and
+// topics found by name are cached for the duration of the import.
+type catalogImporter struct {
+	*Resolver
+	job        *model.ImportJob
+	authors    map[string]string
+	publishers map[string]string
+	topics     map[string]string
+}
+
+func (r *Resolver) newCatalogImporter(job *model.ImportJob) *catalogImporter {
+	return &catalogImporter{
+		Resolver:   r,
+		job:        job,
+		authors:    map[string]string{},
+		publishers: map[string]string{},
+		topics:     map[string]string{},
+	}
+}
+
+// run imports a file, it is meant to be run in the background once the job is saved. A panic
+// fails the job rather than the server.
+func (im *catalogImporter) run(data []byte) {
+	defer func() {
+		if p := recover(); p != nil {
+			log.Printf("Import %v panicked: %v\n%s", im.job.ID, p, debug.Stack())
+			message := fmt.Sprintf("Import stopped unexpectedly: %v", p)
+			im.job.Error = &message
+			im.job.Status = model.ImportJobStatusFailed
+			im.save()
+		}
+	}()
+	records, rowErrors, err := catalog.Parse(string(im.job.Format), bytes.NewReader(data))
+	if err != nil {
+		message := err.Error()
+		im.job.Error = &message
+		im.job.Status = model.ImportJobStatusFailed
+		im.save()
+		return
+	}
+	im.job.Total = int64(len(records) + len(rowErrors))
+	for _, rowError := range rowErrors {
+		im.fail(rowError.Row, rowError.ISBN, rowError.Message)
+	}
+	for i, record := range records {
+		created, err := im.importRecord(record)
+		if err != nil {
+			im.fail(record.Row, record.ISBN, err.Error())
+		} else if created {
+			im.job.BooksCreated++
+		} else {
+			im.job.BooksUpdated++
+		}
+		im.job.Processed++
+		if (i+1)%importProgressInterval == 0 {
+			im.save()
+		}
+	}
+	im.job.Status = model.ImportJobStatusCompleted
+	im.save()
+}
+
+func (im *catalogImporter) fail(row int, isbn string, message string) {
+	im.job.Failed++
+	if row > 0 && len(im.job.RowErrors) < maxImportRowErrors {
+		rowError := &model.ImportRowError{Row: int64(row), Message: message}
+		if isbn != "" {
+			rowError.Isbn = &isbn
+		}
+		im.job.RowErrors = append(im.job.RowErrors, rowError)
+	}
+}
+
+func (im *catalogImporter) save() {
+	im.job.Updated = time.Now().Unix()
+	jobOID, _ := primitive.ObjectIDFromHex(im.job.ID)
+	update := bson.M{"$set": bson.M{
+		"status":       im.job.Status,
+		"error":        im.job.Error,
+		"total":        im.job.Total,
+		"processed":    im.job.Processed,
+		"booksCreated": im.job.BooksCreated,
+		"booksUpdated": im.job.BooksUpdated,
+		"failed":       im.job.Failed,
+		"rowErrors":    im.job.RowErrors,
+		"updated":      im.job.Updated,
+	}}
+	im.DB.Collection("import-jobs").UpdateOne(context.Background(), bson.M{"_id": jobOID}, update)
+}
+
+// importRecord creates the book of a record or updates the book with the same ISBN,
+// it returns whether the book was created.
+func (im *catalogImporter) importRecord(record *catalog.Record) (bool, error) {
+	var existing *model.Book
+	isbn, err := model.NormalizeISBN(record.ISBN)
+	if err != nil {
+		return false, err
+	}
+	err = im.DB.Collection("books").FindOne(context.Background(), bson.M{"isbn": isbn}).Decode(&existing)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return false, err
+	}
+	if existing == nil && record.Price == nil {
+		return false, fmt.Errorf("Price is required for a new book")
+	}
+	input := model.NewBook{
+		Name:            record.Title,
+		Content:         record.Description,
+		Isbn:            &isbn,
+		PublicationDate: &record.PublicationDate,
+		Language:        &record.Language,
+		PageCount:       record.PageCount,
+		Edition:         &record.Edition,
+	}
+	if record.Price != nil {
+		input.Price, err = model.ParseMoney(record.Price.String(), model.StoreCurrency())
+		if err != nil {
+			return false, err
+		}
+	}
+	if record.Format != "" {
+		format := model.BookFormat(record.Format)
+		if !format.IsValid() {
+			return false, fmt.Errorf("Invalid format %v", record.Format)
+		}
+		input.Format = &format
+	}
+	if errs := input.Validate(); len(errs) > 0 {
+		return false, errs[0]
+	}
+
+	bookData := bson.M{"name": input.Name, "isbn": isbn, "updated": time.Now().Unix()}
+	if record.Price != nil {
+		bookData["price"] = input.Price
+	}
+	if input.Content != "" || existing == nil {
+		bookData["content"] = input.Content
+	}
+	optionalFields := map[string]interface{}{
+		"publicationDate": input.PublicationDate,
+		"language":        input.Language,
+		"pageCount":       input.PageCount,
+		"format":          input.Format,
+		"edition":         input.Edition,
+	}
+	// a value missing from the file leaves the value of an existing book unchanged
+	for field, value := range optionalFields {
+		if !isNilPointer(value) || existing == nil {
+			bookData[field] = value
+		}
+	}
+	if record.Publisher != "" {
+		id, err := im.findOrCreate(im.publishers, "publishers", record.Publisher, nil)
+		if err != nil {
+			return false, err
+		}
+		bookData["publisherId"] = id
+	}
+	if len(record.Authors) > 0 || existing == nil {
+		authorsId := []string{}
+		for _, name := range record.Authors {
+			id, err := im.findOrCreate(im.authors, "authors", name, nil)
+			if err != nil {
+				return false, err
+			}
+			authorsId = append(authorsId, id)
+		}
+		bookData["authorsId"] = authorsId
+	}
+	if len(record.Topics) > 0 || existing == nil {
+		topicsId := []string{}
+		for _, path := range record.Topics {
+			id, err := im.findOrCreateTopic(path)
+			if err != nil {
+				return false, err
+			}
+			topicsId = append(topicsId, id)
+		}
+		bookData["topicsId"] = topicsId
+	}
+	if im.job.DryRun {
+		return existing == nil, nil
+	}
+	change := &model.PriceChange{NewPrice: input.Price, Reason: model.PriceChangeReasonImport, UserID: &im.job.UserID}
+	if existing != nil {
+		bookOID, _ := primitive.ObjectIDFromHex(existing.ID)
+		_, err = im.DB.Collection("books").UpdateOne(context.Background(), bson.M{"_id": bookOID}, bson.M{"$set": bookData})
+		if err != nil || record.Price == nil {
+			return false, err
+		}
+		change.BookID = existing.ID
+		change.OldPrice = &existing.Price
+		return false, im.recordPriceChange(change)
+	}
+	bookData["created"] = bookData["updated"]
+	result, err := im.DB.Collection("books").InsertOne(context.Background(), bookData)
+	if err != nil {
+		return true, err
+	}
+	change.BookID = result.InsertedID.(primitive.ObjectID).Hex()
+	return true, im.recordPriceChange(change)
+}
+
+// findOrCreate returns the id of the document of collection with the given name, compared
+// without case, and creates it when there is none. A dry run doesn't create anything.
+func (im *catalogImporter) findOrCreate(cache map[string]string, collection string, name string, parentID *string) (string, error) {
+	cacheKey := strings.ToLower(name)
+	if parentID != nil {
+		cacheKey = *parentID + "/" + cacheKey
+	}
+	if id, ok := cache[cacheKey]; ok {
+		return id, nil
+	}
+	filter := bson.M{"name": primitive.Regex{Pattern: "^" + regexp.QuoteMeta(name) + "$", Options: "i"}}
+	if collection == "topics" {
+		filter["parentId"] = parentID
+	}
+	var document struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	err := im.DB.Collection(collection).FindOne(context.Background(), filter).Decode(&document)
+	if err == nil {
+		cache[cacheKey] = document.ID.Hex()
+		return cache[cacheKey], nil
+	}
+	if err != mongo.ErrNoDocuments {
+		return "", err
+	}
+	if im.job.DryRun {
+		// a placeholder keeps the dry run going, nothing is written with it
+		cache[cacheKey] = "dry-run:" + cacheKey
+		return cache[cacheKey], nil
+	}
+	now := time.Now().Unix()
+	data := bson.M{"name": name, "created": now, "updated": now}
+	if collection == "topics" {
+		data["parentId"] = parentID
+	}
+	result, err := im.DB.Collection(collection).InsertOne(context.Background(), data)
+	if err != nil {
+		return "", err
+	}
+	cache[cacheKey] = result.InsertedID.(primitive.ObjectID).Hex()
+	return cache[cacheKey], nil
+}
+
+// findOrCreateTopic walks down a topic path such as Fiction > Fantasy from the root topics.
+func (im *catalogImporter) findOrCreateTopic(path []string) (string, error) {
+	var parentID *string
+	for _, name := range path {
+		id, err := im.findOrCreate(im.topics, "topics", name, parentID)
+		if err != nil {
+			return "", err
+		}
+		parentID = &id
+	}
+	if parentID == nil {
+		return "", fmt.Errorf("Empty topic")
+	}
+	return *parentID, nil
+}
+
+func isNilPointer(value interface{}) bool {
+	switch v := value.(type) {
+	case *string:
+		return v == nil
+	case *int64:
+		return v == nil
+	case *model.BookFormat:
+		return v == nil
+	}
+	return value == nil
+}
@@ -0,0 +1,196 @@
+package resolver
+
+import (
+	"book-store/graph/model"
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// checkReferences returns an error when one of ids is malformed or doesn't exist in collection,
+// label names the referenced type in the error message.
+func (r *Resolver) checkReferences(collection string, label string, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	var objIds []primitive.ObjectID
+	for _, id := range ids {
+		objId, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return fmt.Errorf("Invalid %v id %v", label, id)
+		}
+		objIds = append(objIds, objId)
+	}
+	existing, err := r.idSet(collection, bson.M{"_id": bson.M{"$in": objIds}})
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if !existing[id] {
+			return fmt.Errorf("%v %v doesn't exist", label, id)
+		}
+	}
+	return nil
+}
+
+// applyRemovalPolicy updates the books referencing id in field (topicsId or authorsId)
+// before the topic or author is removed.
+func (r *Resolver) applyRemovalPolicy(collection string, label string, field string, id string, policy *model.RemovalPolicy, reassignTo *string) error {
+	filter := bson.M{field: id}
+	now := time.Now().Unix()
+	removalPolicy := model.RemovalPolicyPull
+	if policy != nil {
+		removalPolicy = *policy
+	}
+	switch removalPolicy {
+	case model.RemovalPolicyRestrict:
+		count, err := r.DB.Collection("books").CountDocuments(context.Background(), filter)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return fmt.Errorf("%v %v is used by %v books", label, id, count)
+		}
+		return nil
+	case model.RemovalPolicyReassign:
+		if reassignTo == nil || *reassignTo == id {
+			return fmt.Errorf("Please provide another %v to reassign the books to", label)
+		}
+		err := r.checkReferences(collection, label, []string{*reassignTo})
+		if err != nil {
+			return err
+		}
+		update := bson.M{"$addToSet": bson.M{field: *reassignTo}}
+		_, err = r.DB.Collection("books").UpdateMany(context.Background(), filter, update)
+		if err != nil {
+			return err
+		}
+	}
+	update := bson.M{"$pull": bson.M{field: id}, "$set": bson.M{"updated": now}}
+	_, err := r.DB.Collection("books").UpdateMany(context.Background(), filter, update)
+	return err
+}
+
+func (r *Resolver) idSet(collection string, filter bson.M) (map[string]bool, error) {
+	opts := options.Find().SetProjection(bson.M{"_id": 1})
+	cs, err := r.DB.Collection(collection).Find(context.Background(), filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cs.Close(context.Background())
+	ids := map[string]bool{}
+	for cs.Next(context.Background()) {
+		var doc struct {
+			ID primitive.ObjectID `bson:"_id"`
+		}
+		if err := cs.Decode(&doc); err != nil {
+			return nil, err
+		}
+		ids[doc.ID.Hex()] = true
+	}
+	return ids, cs.Err()
+}
+
+type integrityChecker struct {
+	report *model.IntegrityReport
+}
+
+func (c *integrityChecker) check(collection string, documentID string, field string, reference string, existing map[string]bool) {
+	c.report.Checked++
+	if _, err := primitive.ObjectIDFromHex(reference); err != nil {
+		c.add(collection, documentID, field, reference, model.IntegrityIssueKindMalformed)
+	} else if !existing[reference] {
+		c.add(collection, documentID, field, reference, model.IntegrityIssueKindMissing)
+	}
+}
+
+func (c *integrityChecker) add(collection string, documentID string, field string, reference string, kind model.IntegrityIssueKind) {
+	c.report.Issues = append(c.report.Issues, &model.IntegrityIssue{
+		Collection: collection,
+		DocumentID: documentID,
+		Field:      field,
+		Reference:  reference,
+		Kind:       kind,
+	})
+}
+
+// integrityReport finds the references to topics, authors, publishers, series and books which are malformed or orphaned.
+func (r *Resolver) integrityReport() (*model.IntegrityReport, error) {
+	topics, err := r.idSet("topics", bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	authors, err := r.idSet("authors", bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	publishers, err := r.idSet("publishers", bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	seriesSet, err := r.idSet("series", bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	books, err := r.idSet("books", bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	checker := &integrityChecker{report: &model.IntegrityReport{Issues: []*model.IntegrityIssue{}}}
+
+	var topicDocs []*model.Topic
+	if err := findAll(r.DB, "topics", bson.M{"parentId": bson.M{"$ne": nil}}, &topicDocs); err != nil {
+		return nil, err
+	}
+	for _, topic := range topicDocs {
+		checker.check("topics", topic.ID, "parentId", *topic.ParentID, topics)
+	}
+	var bookDocs []*model.Book
+	if err := findAll(r.DB, "books", bson.M{}, &bookDocs); err != nil {
+		return nil, err
+	}
+	for _, book := range bookDocs {
+		for _, id := range book.TopicsID {
+			checker.check("books", book.ID, "topicsId", id, topics)
+		}
+		for _, id := range book.AuthorsID {
+			checker.check("books", book.ID, "authorsId", id, authors)
+		}
+		if book.PublisherID != nil {
+			checker.check("books", book.ID, "publisherId", *book.PublisherID, publishers)
+		}
+		if book.SeriesID != nil {
+			checker.check("books", book.ID, "seriesId", *book.SeriesID, seriesSet)
+		}
+	}
+	var carts []*model.Cart
+	if err := findAll(r.DB, "carts", bson.M{}, &carts); err != nil {
+		return nil, err
+	}
+	for _, cart := range carts {
+		for _, item := range cart.Items {
+			checker.check("carts", cart.ID, "items.bookId", item.BookID, books)
+		}
+	}
+	var wishLists []*model.WishList
+	if err := findAll(r.DB, "wish-lists", bson.M{}, &wishLists); err != nil {
+		return nil, err
+	}
+	for _, wishList := range wishLists {
+		for _, id := range wishList.BooksID {
+			checker.check("wish-lists", wishList.ID, "booksId", id, books)
+		}
+	}
+	var reviews []*model.Review
+	if err := findAll(r.DB, "reviews", bson.M{}, &reviews); err != nil {
+		return nil, err
+	}
+	for _, review := range reviews {
+		checker.check("reviews", review.ID, "bookId", review.BookID, books)
+	}
+	return checker.report, nil
+}
@@ -0,0 +1,503 @@
+package resolver
+
+import (
+	"book-store/graph/model"
+	"book-store/invoice"
+	"book-store/payment"
+	"book-store/signedurl"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// InvoiceURLLifetime is how long the signed download URL of an invoice stays valid.
+const InvoiceURLLifetime = time.Hour
+
+var invoicePrefixes = map[model.InvoiceKind]string{
+	model.InvoiceKindInvoice:    "INV",
+	model.InvoiceKindCreditNote: "CN",
+}
+
+// InvoiceBlobKey is the key of the PDF of an invoice or a credit note in the blob store.
+func InvoiceBlobKey(number string) string {
+	return "invoices/" + number + ".pdf"
+}
+
+func invoiceURL(id string) string {
+	path := "/invoices/" + id
+	return os.Getenv("PUBLIC_URL") + signedurl.Sign(os.Getenv("SIGNED_URL_SECRET"), path, time.Now().Add(InvoiceURLLifetime))
+}
+
+func (r *Resolver) findInvoiceByID(id string) (*model.Invoice, error) {
+	invoiceOID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	var inv *model.Invoice
+	err = r.DB.Collection("invoices").FindOne(context.Background(), bson.M{"_id": invoiceOID}).Decode(&inv)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("Invoice %v doesn't exist", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return inv, nil
+}
+
+// findInvoice returns the invoice of an order, or nil until the order is paid.
+func (r *Resolver) findInvoice(orderID string) (*model.Invoice, error) {
+	var inv *model.Invoice
+	filter := bson.M{"orderId": orderID, "kind": model.InvoiceKindInvoice}
+	err := r.DB.Collection("invoices").FindOne(context.Background(), filter).Decode(&inv)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return inv, nil
+}
+
+// insertInvoice numbers an invoice after the last one of its kind and saves it. A concurrent insert
+// of the same number breaks the unique index and the next number is taken again, and since nothing
+// is saved before a number is taken and invoices are never deleted, the numbers have no gaps.
+// The invoice of an order is issued once, the existing one is returned if there is one.
+func (r *Resolver) insertInvoice(inv *model.Invoice) (*model.Invoice, error) {
+	for {
+		if inv.Kind == model.InvoiceKindInvoice {
+			existing, err := r.findInvoice(inv.OrderID)
+			if err != nil || existing != nil {
+				return existing, err
+			}
+		}
+		var last struct{ Sequence int64 }
+		opts := options.FindOne().SetSort(bson.M{"sequence": -1})
+		err := r.DB.Collection("invoices").FindOne(context.Background(), bson.M{"kind": inv.Kind}, opts).Decode(&last)
+		if err != nil && err != mongo.ErrNoDocuments {
+			return nil, err
+		}
+		sequence := last.Sequence + 1
+		inv.Number = fmt.Sprintf("%v-%06d", invoicePrefixes[inv.Kind], sequence)
+		result, err := r.DB.Collection("invoices").InsertOne(context.Background(), bson.M{
+			"kind":      inv.Kind,
+			"sequence":  sequence,
+			"number":    inv.Number,
+			"orderId":   inv.OrderID,
+			"userId":    inv.UserID,
+			"invoiceId": inv.InvoiceID,
+			"items":     inv.Items,
+			"tax":       inv.Tax,
+			"shipping":  inv.Shipping,
+			"total":     inv.Total,
+			"reason":    inv.Reason,
+			"created":   inv.Created,
+			"rendered":  false,
+		})
+		if mongo.IsDuplicateKeyError(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		inv.ID = result.InsertedID.(primitive.ObjectID).Hex()
+		return inv, nil
+	}
+}
+
+// issueInvoice issues the invoice of a paid order and stores its PDF. A PDF which couldn't be
+// stored is rendered again when its URL is asked for.
+func (r *Resolver) issueInvoice(order *model.Order) (*model.Invoice, error) {
+	inv := &model.Invoice{
+		Kind:    model.InvoiceKindInvoice,
+		OrderID: order.ID,
+		UserID:  order.UserID,
+		Items:   order.Items,
+		Tax:     order.Tax,
+		Total:   order.Total,
+		Created: time.Now().Unix(),
+	}
+	if order.Shipping != nil {
+		inv.Shipping = &order.Shipping.Cost
+	}
+	inv, err := r.insertInvoice(inv)
+	if err != nil {
+		return nil, err
+	}
+	return inv, r.renderInvoice(inv, order)
+}
+
+// issueCreditNote credits quantities of the items of an invoiced order, quantities[i] of order.Items[i],
+// and optionally its shipping.
+func (r *Resolver) issueCreditNote(order *model.Order, quantities []int64, shipping bool, reason string) (*model.Invoice, error) {
+	invoiced, err := r.findInvoice(order.ID)
+	if err != nil {
+		return nil, err
+	}
+	if invoiced == nil {
+		return nil, fmt.Errorf("Order %v has no invoice to credit", order.ID)
+	}
+	items, taxes, err := creditLines(order, quantities)
+	if err != nil {
+		return nil, err
+	}
+	note := &model.Invoice{
+		Kind:      model.InvoiceKindCreditNote,
+		OrderID:   order.ID,
+		UserID:    order.UserID,
+		InvoiceID: &invoiced.ID,
+		Items:     items,
+		Tax:       taxes,
+		Total:     model.Money{Currency: order.Currency},
+		Reason:    &reason,
+		Created:   time.Now().Unix(),
+	}
+	note.Total.Amount = note.Tax.Gross.Amount
+	// the items and the shipping are claimed on the order first, so they're credited once
+	claimed, err := r.claimQuantities(order, "creditedQuantities", quantities, func() ([]int64, error) {
+		return r.creditedQuantities(order)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, fmt.Errorf("Items of order %v were credited already", order.ID)
+	}
+	if shipping && order.Shipping != nil {
+		shipping, err = r.claimShippingCredit(order)
+		if err != nil {
+			r.releaseCredit(order, quantities, false)
+			return nil, err
+		}
+		if shipping {
+			note.Shipping = &order.Shipping.Cost
+			creditShipping(note, order)
+		}
+	} else {
+		shipping = false
+	}
+	if note.Total.Amount == 0 {
+		r.releaseCredit(order, quantities, shipping)
+		return nil, fmt.Errorf("Nothing to credit")
+	}
+	note, err = r.insertInvoice(note)
+	if err != nil {
+		r.releaseCredit(order, quantities, shipping)
+		return nil, err
+	}
+	return note, r.renderInvoice(note, order)
+}
+
+// creditShipping adds the shipping of an order to a credit note with its tax line, the orders placed
+// before the shipping was taxed are credited the shipping cost.
+func creditShipping(note *model.Invoice, order *model.Order) {
+	if order.Tax == nil || order.Tax.Shipping == nil {
+		note.Total.Amount += order.Shipping.Cost.Amount
+		return
+	}
+	line := *order.Tax.Shipping
+	note.Tax.Shipping = &line
+	note.Tax.Net.Amount += line.Net.Amount
+	note.Tax.Tax.Amount += line.Tax.Amount
+	note.Tax.Gross.Amount += line.Gross.Amount
+	note.Total.Amount += line.Gross.Amount
+}
+
+// creditedQuantities returns the quantity of every item of an order in its credit notes.
+func (r *Resolver) creditedQuantities(order *model.Order) ([]int64, error) {
+	var notes []*model.Invoice
+	err := findAll(r.DB, "invoices", bson.M{"orderId": order.ID, "kind": model.InvoiceKindCreditNote}, &notes)
+	if err != nil {
+		return nil, err
+	}
+	quantities := make([]int64, len(order.Items))
+	for _, note := range notes {
+		for _, item := range note.Items {
+			if i := orderItemIndex(order, item.BookID, item.VariantID); i >= 0 {
+				quantities[i] += item.Quantity
+			}
+		}
+	}
+	return quantities, nil
+}
+
+// claimShippingCredit flags the shipping of an order as credited, false is returned if it was already.
+func (r *Resolver) claimShippingCredit(order *model.Order) (bool, error) {
+	// credit notes issued before the flag was kept
+	filter := bson.M{"orderId": order.ID, "kind": model.InvoiceKindCreditNote, "shipping": bson.M{"$ne": nil}}
+	credited, err := r.DB.Collection("invoices").CountDocuments(context.Background(), filter)
+	if err != nil || credited > 0 {
+		return false, err
+	}
+	orderOID, err := primitive.ObjectIDFromHex(order.ID)
+	if err != nil {
+		return false, err
+	}
+	filter = bson.M{"_id": orderOID, "shippingCredited": bson.M{"$ne": true}}
+	result, err := r.DB.Collection("orders").UpdateOne(context.Background(), filter, bson.M{"$set": bson.M{"shippingCredited": true}})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+// releaseCredit takes back what was claimed for a credit note which wasn't issued. It's best effort,
+// the error of the credit note is the one reported.
+func (r *Resolver) releaseCredit(order *model.Order, quantities []int64, shipping bool) {
+	r.releaseQuantities(order, "creditedQuantities", quantities)
+	if shipping {
+		orderOID, err := primitive.ObjectIDFromHex(order.ID)
+		if err == nil {
+			r.DB.Collection("orders").UpdateOne(context.Background(), bson.M{"_id": orderOID}, bson.M{"$set": bson.M{"shippingCredited": false}})
+		}
+	}
+}
+
+// creditLines returns the credited items of an order, quantities[i] of order.Items[i], and their tax.
+// The tax of a credited line is its share of the tax of the order line, the orders placed before taxes
+// were computed have no tax lines and are credited without tax.
+func creditLines(order *model.Order, quantities []int64) ([]*model.OrderItem, *model.TaxBreakdown, error) {
+	zero := model.Money{Currency: order.Currency}
+	items := []*model.OrderItem{}
+	taxes := &model.TaxBreakdown{Lines: []*model.TaxLine{}, Net: zero, Tax: zero, Gross: zero}
+	for i, item := range order.Items {
+		if i >= len(quantities) || quantities[i] <= 0 {
+			continue
+		}
+		if quantities[i] > item.Quantity {
+			return nil, nil, fmt.Errorf("Only %v of %v were ordered", item.Quantity, item.Name)
+		}
+		credited := *item
+		credited.Quantity = quantities[i]
+		items = append(items, &credited)
+		var line model.TaxLine
+		if order.Tax != nil && i < len(order.Tax.Lines) {
+			line = *order.Tax.Lines[i]
+			line.Gross = prorate(line.Gross, quantities[i], item.Quantity)
+			line.Tax = prorate(line.Tax, quantities[i], item.Quantity)
+		} else {
+			line = model.TaxLine{
+				BookID:      &item.BookID,
+				VariantID:   item.VariantID,
+				ProductType: taxProductType(item.Format),
+				Rate:        "0",
+				Gross:       item.Price.Times(quantities[i]),
+				Tax:         model.Money{Currency: item.Price.Currency},
+			}
+		}
+		line.Net = model.Money{Amount: line.Gross.Amount - line.Tax.Amount, Currency: line.Gross.Currency}
+		taxes.Lines = append(taxes.Lines, &line)
+		taxes.Net.Amount += line.Net.Amount
+		taxes.Tax.Amount += line.Tax.Amount
+		taxes.Gross.Amount += line.Gross.Amount
+	}
+	return items, taxes, nil
+}
+
+// refund issues a credit note of quantities of the items of an order, and optionally of its shipping,
+// and refunds it. A PDF which couldn't be stored doesn't hold the refund back.
+func (r *Resolver) refund(ctx context.Context, order *model.Order, quantities []int64, shipping bool, reason string) (*model.Invoice, error) {
+	note, err := r.issueCreditNote(order, quantities, shipping, reason)
+	if note == nil {
+		return nil, err
+	}
+	refundErr := r.refundCreditNote(ctx, note)
+	if refundErr != nil {
+		return note, refundErr
+	}
+	return note, err
+}
+
+// refundCreditNote pays the total of a credit note back through the payment gateway, the credit note
+// is the key of the refund so it's never refunded twice. The return of the credit note is refunded with it.
+func (r *Resolver) refundCreditNote(ctx context.Context, note *model.Invoice) error {
+	if note.RefundID != nil {
+		return nil
+	}
+	if r.Payments == nil {
+		return fmt.Errorf("Payment gateway isn't configured")
+	}
+	refund := payment.Refund{
+		OrderID:  note.OrderID,
+		Amount:   note.Total.Amount,
+		Currency: note.Total.Currency,
+		Key:      note.ID,
+	}
+	if note.Reason != nil {
+		refund.Reason = *note.Reason
+	}
+	refundID, err := r.Payments.Refund(ctx, refund)
+	if err != nil {
+		return err
+	}
+	noteOID, err := primitive.ObjectIDFromHex(note.ID)
+	if err != nil {
+		return err
+	}
+	_, err = r.DB.Collection("invoices").UpdateOne(context.Background(), bson.M{"_id": noteOID}, bson.M{"$set": bson.M{"refundId": refundID}})
+	if err != nil {
+		return err
+	}
+	note.RefundID = &refundID
+	filter := bson.M{"creditNoteId": note.ID, "status": model.ReturnStatusApproved}
+	update := bson.M{
+		"$set":   bson.M{"status": model.ReturnStatusRefunded, "refund": note.Total, "updated": time.Now().Unix()},
+		"$unset": bson.M{"error": ""},
+	}
+	_, err = r.DB.Collection("returns").UpdateMany(context.Background(), filter, update)
+	return err
+}
+
+// prorate is the share of an amount for quantity of total units, rounded half up.
+func prorate(amount model.Money, quantity int64, total int64) model.Money {
+	return model.Money{Amount: (2*amount.Amount*quantity + total) / (2 * total), Currency: amount.Currency}
+}
+
+// invoicePDF returns the URL of the PDF of an invoice, the PDF is rendered again if storing it failed.
+func (r *Resolver) invoicePDF(inv *model.Invoice) (string, error) {
+	invoiceOID, err := primitive.ObjectIDFromHex(inv.ID)
+	if err != nil {
+		return "", err
+	}
+	var state struct{ Rendered bool }
+	err = r.DB.Collection("invoices").FindOne(context.Background(), bson.M{"_id": invoiceOID}).Decode(&state)
+	if err != nil {
+		return "", err
+	}
+	if !state.Rendered {
+		orderOID, err := primitive.ObjectIDFromHex(inv.OrderID)
+		if err != nil {
+			return "", err
+		}
+		var order *model.Order
+		err = r.DB.Collection("orders").FindOne(context.Background(), bson.M{"_id": orderOID}).Decode(&order)
+		if err != nil {
+			return "", err
+		}
+		err = r.renderInvoice(inv, order)
+		if err != nil {
+			return "", err
+		}
+	}
+	return invoiceURL(inv.ID), nil
+}
+
+// renderInvoice stores the PDF of an invoice or a credit note of an order.
+func (r *Resolver) renderInvoice(inv *model.Invoice, order *model.Order) error {
+	doc := &invoice.Invoice{
+		Title:      "Invoice",
+		Number:     inv.Number,
+		Date:       time.Unix(inv.Created, 0),
+		Seller:     sellerLines(),
+		BillTo:     addressLines(order.BillingAddress),
+		References: []invoice.Reference{{Label: "Order", Value: order.ID}},
+	}
+	if inv.Kind == model.InvoiceKindCreditNote {
+		doc.Title = "Credit note"
+		invoiced, err := r.findInvoice(order.ID)
+		if err != nil {
+			return err
+		}
+		if invoiced != nil {
+			doc.References = append(doc.References, invoice.Reference{Label: "Invoice", Value: invoiced.Number})
+		}
+		if inv.Reason != nil && *inv.Reason != "" {
+			doc.Notes = append(doc.Notes, "Reason: "+*inv.Reason)
+		}
+	}
+	if order.Shipping != nil {
+		doc.ShipTo = addressLines(order.ShippingAddress)
+	}
+	for i, item := range inv.Items {
+		description := item.Name
+		if item.Sku != nil {
+			description += " (" + *item.Sku + ")"
+		}
+		line := invoice.Line{
+			Description: description,
+			Quantity:    item.Quantity,
+			UnitPrice:   item.Price.Decimal(),
+			Amount:      item.Price.Times(item.Quantity).Decimal(),
+		}
+		if i < len(inv.Tax.Lines) {
+			line.TaxRate = inv.Tax.Lines[i].Rate + "%"
+		}
+		doc.Lines = append(doc.Lines, line)
+	}
+	if inv.Shipping != nil && inv.Tax.Shipping != nil {
+		doc.Lines = append(doc.Lines, invoice.Line{
+			Description: "Shipping",
+			Quantity:    1,
+			UnitPrice:   inv.Shipping.Decimal(),
+			TaxRate:     inv.Tax.Shipping.Rate + "%",
+			Amount:      inv.Shipping.Decimal(),
+		})
+	}
+	doc.Totals = []invoice.Total{
+		{Label: "Net", Amount: inv.Tax.Net.String()},
+		{Label: "Tax", Amount: inv.Tax.Tax.String()},
+	}
+	// the shipping of the orders placed before it was taxed is out of the tax totals
+	if inv.Shipping != nil && inv.Tax.Shipping == nil {
+		doc.Totals = append(doc.Totals, invoice.Total{Label: "Shipping", Amount: inv.Shipping.String()})
+	}
+	total := "Total"
+	if inv.Kind == model.InvoiceKindCreditNote {
+		total = "Total credited"
+	}
+	doc.Totals = append(doc.Totals, invoice.Total{Label: total, Amount: inv.Total.String()})
+	if order.ExchangeRate != nil {
+		doc.Notes = append(doc.Notes, fmt.Sprintf("Exchange rate: 1 %v = %v %v", model.StoreCurrency(), *order.ExchangeRate, order.Currency))
+	}
+	data := invoice.Render(doc)
+	err := r.Documents.Put(context.Background(), InvoiceBlobKey(inv.Number), "application/pdf", data)
+	if err != nil {
+		return err
+	}
+	invoiceOID, err := primitive.ObjectIDFromHex(inv.ID)
+	if err != nil {
+		return err
+	}
+	_, err = r.DB.Collection("invoices").UpdateOne(context.Background(), bson.M{"_id": invoiceOID}, bson.M{"$set": bson.M{"rendered": true}})
+	return err
+}
+
+// sellerLines are the name, the address and the tax ID of the store, from STORE_NAME, STORE_ADDRESS
+// whose lines are separated by semicolons and STORE_TAX_ID.
+func sellerLines() []string {
+	name := os.Getenv("STORE_NAME")
+	if name == "" {
+		name = "Book Store"
+	}
+	lines := []string{name}
+	for _, line := range strings.Split(os.Getenv("STORE_ADDRESS"), ";") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if taxID := os.Getenv("STORE_TAX_ID"); taxID != "" {
+		lines = append(lines, "Tax ID: "+taxID)
+	}
+	return lines
+}
+
+func addressLines(address *model.OrderAddress) []string {
+	if address == nil {
+		return nil
+	}
+	lines := []string{address.Name, address.Line1}
+	if address.Line2 != nil && *address.Line2 != "" {
+		lines = append(lines, *address.Line2)
+	}
+	city := address.PostalCode + " " + address.City
+	if address.Region != nil && *address.Region != "" {
+		city += ", " + *address.Region
+	}
+	return append(lines, city, address.Country)
+}
@@ -0,0 +1,23 @@
+package resolver
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+
+import (
+	"book-store/graph/generated"
+	"book-store/graph/model"
+	"context"
+)
+
+func (r *invoiceResolver) DownloadURL(ctx context.Context, obj *model.Invoice) (*string, error) {
+	url, err := r.invoicePDF(obj)
+	if err != nil {
+		return nil, err
+	}
+	return &url, nil
+}
+
+// Invoice returns generated.InvoiceResolver implementation.
+func (r *Resolver) Invoice() generated.InvoiceResolver { return &invoiceResolver{r} }
+
+type invoiceResolver struct{ *Resolver }
@@ -0,0 +1,191 @@
+package resolver
+
+import (
+	"book-store/graph/model"
+	"book-store/payment"
+	"book-store/storage"
+	"context"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestProrate(t *testing.T) {
+	cases := []struct {
+		amount, quantity, total, expected int64
+	}{
+		{1000, 1, 3, 333},
+		{1000, 2, 3, 667},
+		{1000, 3, 3, 1000},
+		{5, 1, 2, 3},
+	}
+	for _, c := range cases {
+		share := prorate(model.Money{Amount: c.amount, Currency: "EUR"}, c.quantity, c.total)
+		if share.Amount != c.expected || share.Currency != "EUR" {
+			t.Fatalf("prorate(%v, %v, %v) = %v, expected %v", c.amount, c.quantity, c.total, share.Amount, c.expected)
+		}
+	}
+}
+
+func TestCreditLines(t *testing.T) {
+	eur := func(amount int64) model.Money { return model.Money{Amount: amount, Currency: "EUR"} }
+	order := &model.Order{
+		Currency: "EUR",
+		Items: []*model.OrderItem{
+			{BookID: "a", Name: "A", Price: eur(1070), Quantity: 3},
+			{BookID: "b", Name: "B", Price: eur(500), Quantity: 1},
+		},
+		Tax: &model.TaxBreakdown{Lines: []*model.TaxLine{
+			{Rate: "7", Inclusive: true, Net: eur(3000), Tax: eur(210), Gross: eur(3210)},
+			{Rate: "19", Inclusive: true, Net: eur(420), Tax: eur(80), Gross: eur(500)},
+		}},
+	}
+	items, taxes, err := creditLines(order, []int64{1, 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].Quantity != 1 || len(taxes.Lines) != 1 {
+		t.Fatalf("expected one credited line, got %+v", items)
+	}
+	if taxes.Gross.Amount != 1070 || taxes.Tax.Amount != 70 || taxes.Net.Amount != 1000 {
+		t.Fatalf("unexpected credited tax %+v", taxes)
+	}
+	if _, _, err := creditLines(order, []int64{4, 0}); err == nil {
+		t.Fatal("expected an error when crediting more than was ordered")
+	}
+}
+
+func TestCreditLinesOfLegacyOrder(t *testing.T) {
+	// the orders placed before taxes were computed have a breakdown without lines
+	order := &model.Order{
+		Currency: "USD",
+		Items:    []*model.OrderItem{{BookID: "a", Name: "A", Price: model.Money{Amount: 1250, Currency: "USD"}, Quantity: 2}},
+		Tax:      &model.TaxBreakdown{Lines: []*model.TaxLine{}},
+	}
+	items, taxes, err := creditLines(order, []int64{2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || len(taxes.Lines) != 1 || taxes.Lines[0].Rate != "0" {
+		t.Fatalf("expected a line without tax, got %+v", taxes.Lines)
+	}
+	if taxes.Gross.Amount != 2500 || taxes.Net.Amount != 2500 || taxes.Tax.Amount != 0 || taxes.Gross.Currency != "USD" {
+		t.Fatalf("unexpected credited tax %+v", taxes)
+	}
+}
+
+type fakeGateway struct {
+	refunds []payment.Refund
+}
+
+func (g *fakeGateway) Refund(ctx context.Context, refund payment.Refund) (string, error) {
+	g.refunds = append(g.refunds, refund)
+	return "re_" + refund.Key, nil
+}
+
+func TestRefundCreditNote(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+	mt.Run("refund", func(mt *mtest.T) {
+		payments := &fakeGateway{}
+		r := &Resolver{DB: mt.DB, Payments: payments}
+		note := &model.Invoice{ID: primitive.NewObjectID().Hex(), OrderID: "o", Total: model.Money{Amount: 1200, Currency: "EUR"}}
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}),
+		)
+		err := r.refundCreditNote(context.Background(), note)
+		if err != nil {
+			mt.Fatal(err)
+		}
+		// a refunded credit note isn't refunded again
+		err = r.refundCreditNote(context.Background(), note)
+		if err != nil {
+			mt.Fatal(err)
+		}
+		if len(payments.refunds) != 1 || payments.refunds[0].Key != note.ID || payments.refunds[0].Amount != 1200 {
+			mt.Fatalf("expected one refund keyed by the credit note, got %+v", payments.refunds)
+		}
+		if note.RefundID == nil || *note.RefundID != "re_"+note.ID {
+			mt.Fatalf("expected the refund to be recorded, got %v", note.RefundID)
+		}
+	})
+}
+
+func TestIssueCreditNote(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+	order := &model.Order{
+		ID:       primitive.NewObjectID().Hex(),
+		Currency: "EUR",
+		Items:    []*model.OrderItem{{BookID: "a", Name: "A", Price: model.Money{Amount: 1000, Currency: "EUR"}, Quantity: 2}},
+		Shipping: &model.OrderShipping{Cost: model.Money{Amount: 500, Currency: "EUR"}},
+	}
+	invoiced := bson.D{{Key: "_id", Value: primitive.NewObjectID()}, {Key: "kind", Value: model.InvoiceKindInvoice}, {Key: "number", Value: "INV-000001"}}
+	cursor := func(docs ...bson.D) bson.D {
+		return mtest.CreateCursorResponse(0, "db.invoices", mtest.FirstBatch, docs...)
+	}
+
+	mt.Run("shipping credited once", func(mt *mtest.T) {
+		documents, _ := storage.NewLocalStore(mt.TempDir(), "")
+		r := &Resolver{DB: mt.DB, Documents: documents}
+		mt.AddMockResponses(
+			cursor(invoiced),
+			cursor(), // the order counts its credited quantities
+			updated(1),
+			cursor(),   // no credit note of the shipping before the flag
+			updated(0), // the shipping was claimed by another credit note
+			cursor(),
+			mtest.CreateSuccessResponse(),
+			cursor(invoiced),
+			updated(1),
+		)
+		note, err := r.issueCreditNote(order, []int64{1, 0}, true, "Damaged")
+		if err != nil {
+			mt.Fatal(err)
+		}
+		if note.Shipping != nil || note.Total.Amount != 1000 || note.Number != "CN-000001" {
+			mt.Fatalf("expected a credit note of the item only, got %+v", note)
+		}
+	})
+
+	mt.Run("credited already", func(mt *mtest.T) {
+		r := &Resolver{DB: mt.DB}
+		mt.AddMockResponses(
+			cursor(invoiced),
+			cursor(),
+			updated(0), // more than was ordered
+		)
+		_, err := r.issueCreditNote(order, []int64{2}, false, "Cancelled")
+		if err == nil {
+			mt.Fatal("expected crediting items twice to fail")
+		}
+		for event := mt.GetStartedEvent(); event != nil; event = mt.GetStartedEvent() {
+			if event.CommandName == "insert" {
+				mt.Fatal("expected no credit note to be issued")
+			}
+		}
+	})
+}
+
+func TestCreditShipping(t *testing.T) {
+	eur := func(amount int64) model.Money { return model.Money{Amount: amount, Currency: "EUR"} }
+	order := &model.Order{
+		Shipping: &model.OrderShipping{Cost: eur(500)},
+		Tax:      &model.TaxBreakdown{Shipping: &model.TaxLine{Rate: "19", Net: eur(500), Tax: eur(95), Gross: eur(595)}},
+	}
+	note := &model.Invoice{Tax: &model.TaxBreakdown{Net: eur(1000), Tax: eur(70), Gross: eur(1070)}, Total: eur(1070)}
+	creditShipping(note, order)
+	if note.Tax.Shipping == nil || note.Tax.Gross.Amount != 1665 || note.Tax.Tax.Amount != 165 || note.Total.Amount != 1665 {
+		t.Fatalf("expected the shipping to be credited with its tax, got %+v", note.Tax)
+	}
+	// the shipping of a legacy order wasn't taxed
+	order.Tax = &model.TaxBreakdown{}
+	note = &model.Invoice{Tax: &model.TaxBreakdown{Gross: eur(1070)}, Total: eur(1070)}
+	creditShipping(note, order)
+	if note.Tax.Shipping != nil || note.Tax.Gross.Amount != 1070 || note.Total.Amount != 1570 {
+		t.Fatalf("expected the shipping cost to be credited, got %+v", note)
+	}
+}
@@ -0,0 +1,27 @@
+package resolver
+
+import (
+	"book-store/graph/model"
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// FailInterruptedJobs fails the import and export jobs left running by a previous run of the
+// server. The jobs run in the server process, so they stopped with it and would otherwise stay
+// running forever. It is meant to be called once on startup, before any job is queued.
+func (r *Resolver) FailInterruptedJobs() error {
+	message := "Interrupted by a restart of the server, please try again"
+	update := func(status string) bson.M {
+		return bson.M{"$set": bson.M{"status": status, "error": message, "updated": time.Now().Unix()}}
+	}
+	_, err := r.DB.Collection("import-jobs").UpdateMany(context.Background(),
+		bson.M{"status": model.ImportJobStatusRunning}, update(string(model.ImportJobStatusFailed)))
+	if err != nil {
+		return err
+	}
+	_, err = r.DB.Collection("export-jobs").UpdateMany(context.Background(),
+		bson.M{"status": model.ExportJobStatusRunning}, update(string(model.ExportJobStatusFailed)))
+	return err
+}
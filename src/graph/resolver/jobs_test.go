@@ -0,0 +1,40 @@
+package resolver
+
+import (
+	"book-store/graph/model"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestFailInterruptedJobs(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("fail running jobs", func(mt *mtest.T) {
+		r := &Resolver{DB: mt.DB}
+		mt.AddMockResponses(updated(2), updated(1))
+		if err := r.FailInterruptedJobs(); err != nil {
+			mt.Fatal(err)
+		}
+		updates := commands(mt)["update"]
+		if len(updates) != 2 {
+			mt.Fatalf("expected the import and the export jobs to be updated, got %v updates", len(updates))
+		}
+		for i, collection := range []string{"import-jobs", "export-jobs"} {
+			if name := updates[i].Lookup("update").StringValue(); name != collection {
+				mt.Fatalf("expected %v to be updated, got %v", collection, name)
+			}
+			update := updates[i].Lookup("updates").Array().Index(0).Value().Document()
+			if status := update.Lookup("q", "status").StringValue(); status != string(model.ImportJobStatusRunning) {
+				mt.Fatalf("expected the running jobs to be matched, got %v", status)
+			}
+			if status := update.Lookup("u", "$set", "status").StringValue(); status != string(model.ImportJobStatusFailed) {
+				mt.Fatalf("expected the jobs to fail, got %v", status)
+			}
+			if !update.Lookup("multi").Boolean() {
+				mt.Fatal("expected every running job to be updated")
+			}
+		}
+	})
+}
@@ -0,0 +1,116 @@
+package resolver
+
+import (
+	"book-store/graph/model"
+	"context"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// bookLoadWait is how long a book lookup waits for the lookups of the other items of a list,
+// gqlgen resolves the fields of the items of a list concurrently.
+const bookLoadWait = 2 * time.Millisecond
+
+// storedBook is a book with the fields which are stored but not exposed as is.
+type storedBook struct {
+	model.Book `bson:",inline"`
+	CoverImage *model.CoverImage `bson:"coverImage"`
+}
+
+// bookLoader batches the book lookups of an operation, the books looked up together are read
+// with a single query instead of one per book, such as the covers of a list of books or the
+// books of the items of a cart.
+type bookLoader struct {
+	r     *Resolver
+	mu    sync.Mutex
+	batch *bookBatch
+}
+
+type bookBatch struct {
+	ids   []primitive.ObjectID
+	books map[string]*storedBook
+	err   error
+	done  chan struct{}
+}
+
+type bookLoaderKey struct{}
+
+// WithLoaders returns a context with the loaders of an operation, they are meant to live only as
+// long as the operation.
+func (r *Resolver) WithLoaders(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bookLoaderKey{}, &bookLoader{r: r})
+}
+
+// loadStoredBook returns a book or nil when it doesn't exist. The books are looked up in batches
+// when the context has loaders.
+func (r *Resolver) loadStoredBook(ctx context.Context, bookOID primitive.ObjectID) (*storedBook, error) {
+	loader, ok := ctx.Value(bookLoaderKey{}).(*bookLoader)
+	if !ok {
+		var book *storedBook
+		err := r.DB.Collection("books").FindOne(context.Background(), bson.M{"_id": bookOID}).Decode(&book)
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return book, err
+	}
+	return loader.load(bookOID)
+}
+
+// loadBook returns a book or nil when it doesn't exist, see loadStoredBook.
+func (r *Resolver) loadBook(ctx context.Context, bookOID primitive.ObjectID) (*model.Book, error) {
+	book, err := r.loadStoredBook(ctx, bookOID)
+	if err != nil || book == nil {
+		return nil, err
+	}
+	return &book.Book, nil
+}
+
+// loadCoverImage returns the stored cover of a book, or nil when the book has no cover.
+func (r *Resolver) loadCoverImage(ctx context.Context, bookOID primitive.ObjectID) (*model.CoverImage, error) {
+	book, err := r.loadStoredBook(ctx, bookOID)
+	if err != nil || book == nil {
+		return nil, err
+	}
+	return book.CoverImage, nil
+}
+
+func (l *bookLoader) load(bookOID primitive.ObjectID) (*storedBook, error) {
+	l.mu.Lock()
+	batch := l.batch
+	if batch == nil {
+		batch = &bookBatch{done: make(chan struct{})}
+		l.batch = batch
+		time.AfterFunc(bookLoadWait, func() { l.run(batch) })
+	}
+	batch.ids = append(batch.ids, bookOID)
+	l.mu.Unlock()
+	<-batch.done
+	return batch.books[bookOID.Hex()], batch.err
+}
+
+func (l *bookLoader) run(batch *bookBatch) {
+	l.mu.Lock()
+	l.batch = nil
+	ids := batch.ids
+	l.mu.Unlock()
+	defer close(batch.done)
+	var books []*storedBook
+	cs, err := l.r.DB.Collection("books").Find(context.Background(), bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		batch.err = err
+		return
+	}
+	defer cs.Close(context.Background())
+	if err = cs.All(context.Background(), &books); err != nil {
+		batch.err = err
+		return
+	}
+	batch.books = map[string]*storedBook{}
+	for _, book := range books {
+		batch.books[book.ID] = book
+	}
+}
@@ -0,0 +1,52 @@
+package resolver
+
+import (
+	"book-store/graph/model"
+	"context"
+	"sync"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestLoadCoverImage(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("one query for a list", func(mt *mtest.T) {
+		r := &Resolver{DB: mt.DB}
+		ctx := r.WithLoaders(context.Background())
+		withCover, withoutCover := primitive.NewObjectID(), primitive.NewObjectID()
+		image := bson.D{{Key: "renditions", Value: bson.A{bson.D{{Key: "size", Value: model.CoverSizeMedium}, {Key: "key", Value: "covers/a.jpg"}}}}}
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.books", mtest.FirstBatch,
+			bson.D{{Key: "_id", Value: withCover}, {Key: "coverImage", Value: image}},
+			bson.D{{Key: "_id", Value: withoutCover}},
+		))
+		images := make([]*model.CoverImage, 2)
+		errs := make([]error, 2)
+		var wg sync.WaitGroup
+		for i, id := range []primitive.ObjectID{withCover, withoutCover} {
+			wg.Add(1)
+			go func(i int, id primitive.ObjectID) {
+				defer wg.Done()
+				images[i], errs[i] = r.loadCoverImage(ctx, id)
+			}(i, id)
+		}
+		wg.Wait()
+		if errs[0] != nil || errs[1] != nil {
+			mt.Fatalf("unexpected errors %v", errs)
+		}
+		if images[0] == nil || images[0].Rendition(model.CoverSizeMedium).Key != "covers/a.jpg" || images[1] != nil {
+			mt.Fatalf("unexpected covers %v", images)
+		}
+		finds := commands(mt)["find"]
+		if len(finds) != 1 {
+			mt.Fatalf("expected the covers to be read with a single query, got %v", len(finds))
+		}
+		if ids, _ := finds[0].Lookup("filter", "_id", "$in").Array().Values(); len(ids) != 2 {
+			mt.Fatalf("expected both books in the query, got %v", ids)
+		}
+	})
+}
@@ -4,47 +4,128 @@ package resolver
 // will be copied through when generating and any unknown code will be moved to the end.
 
 import (
+	"book-store/cover"
 	"book-store/graph/generated"
 	"book-store/graph/model"
+	"book-store/totp"
 	"context"
 	"fmt"
+	"io"
+	"strings"
 	"time"
 
+	"github.com/99designs/gqlgen/graphql"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"golang.org/x/crypto/bcrypt"
 )
 
 func (r *mutationResolver) CreateAuthor(ctx context.Context, input model.NewAuthor) (*model.Author, error) {
-	auth, err := GetAuthFromContext(ctx)
+	now := time.Now().Unix()
+	author := &model.Author{
+		Name:        input.Name,
+		Bio:         input.Bio,
+		BirthDate:   input.BirthDate,
+		DeathDate:   input.DeathDate,
+		Nationality: input.Nationality,
+		PhotoURL:    input.PhotoURL,
+		ExternalIds: model.ExternalIDs(input.ExternalIds),
+		Created:     now,
+		Updated:     now,
+	}
+	err := author.Validate()
 	if err != nil {
 		return nil, err
 	}
-	if auth.Role != model.RoleAdmin.String() {
-		return nil, fmt.Errorf("Access denied!")
-	}
-	now := time.Now().Unix()
 	authorData := bson.M{
-		"name":    input.Name,
-		"created": now,
-		"updated": now,
+		"name":        author.Name,
+		"bio":         author.Bio,
+		"birthDate":   author.BirthDate,
+		"deathDate":   author.DeathDate,
+		"nationality": author.Nationality,
+		"photoUrl":    author.PhotoURL,
+		"externalIds": author.ExternalIds,
+		"created":     now,
+		"updated":     now,
 	}
 	result, err := r.DB.Collection("authors").InsertOne(context.Background(), authorData)
 	if err != nil {
 		return nil, err
 	}
-	return &model.Author{
-		ID:      result.InsertedID.(primitive.ObjectID).Hex(),
-		Name:    input.Name,
-		Created: now,
-		Updated: now,
-	}, nil
+	author.ID = result.InsertedID.(primitive.ObjectID).Hex()
+	return author, nil
+}
+
+func (r *mutationResolver) RemoveAuthor(ctx context.Context, id string, policy *model.RemovalPolicy, reassignTo *string) (*model.Author, error) {
+	authorOID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	var author *model.Author
+	filter := bson.M{"_id": authorOID}
+	err = r.DB.Collection("authors").FindOne(context.Background(), filter).Decode(&author)
+	if err != nil {
+		return nil, err
+	}
+	err = r.applyRemovalPolicy("authors", "Author", "authorsId", id, policy, reassignTo)
+	if err != nil {
+		return nil, err
+	}
+	_, err = r.DB.Collection("authors").DeleteOne(context.Background(), filter)
+	if err != nil {
+		return nil, err
+	}
+	return author, nil
+}
+
+func (r *mutationResolver) UpdateAuthor(ctx context.Context, id string, update model.AuthorUpdate) (*model.Author, error) {
+	authorOID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	var author *model.Author
+	filter := bson.M{"_id": authorOID}
+	err = r.DB.Collection("authors").FindOne(context.Background(), filter).Decode(&author)
+	if err != nil {
+		return nil, err
+	}
+	author.ApplyUpdate(update)
+	err = author.Validate()
+	if err != nil {
+		return nil, err
+	}
+	author.Updated = time.Now().Unix()
+	updateData := bson.M{"$set": bson.M{
+		"name":        author.Name,
+		"bio":         author.Bio,
+		"birthDate":   author.BirthDate,
+		"deathDate":   author.DeathDate,
+		"nationality": author.Nationality,
+		"photoUrl":    author.PhotoURL,
+		"externalIds": author.ExternalIds,
+		"updated":     author.Updated,
+	}}
+	_, err = r.DB.Collection("authors").UpdateOne(context.Background(), filter, updateData)
+	if err != nil {
+		return nil, err
+	}
+	return author, nil
 }
 
 func (r *mutationResolver) CreateUser(ctx context.Context, input model.NewUser) (*model.User, error) {
-	if !input.Role.IsValid() {
-		return nil, fmt.Errorf("Invalid Role")
+	role := model.RoleClient
+	if input.Role != nil && *input.Role != model.RoleClient {
+		if !input.Role.IsValid() {
+			return nil, fmt.Errorf("Invalid Role")
+		}
+		// signing up is public, creating staff accounts isn't
+		auth, err := r.authenticate(ctx)
+		if err != nil || auth.Role != model.RoleAdmin.String() || checkTwoFactorPolicy(auth) != nil {
+			return nil, fmt.Errorf("Only an admin can create %v users", *input.Role)
+		}
+		role = *input.Role
 	}
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
 	if err != nil {
@@ -55,7 +136,7 @@ func (r *mutationResolver) CreateUser(ctx context.Context, input model.NewUser)
 		"name":     input.Name,
 		"email":    input.Email,
 		"password": string(hashedPassword),
-		"role":     input.Role,
+		"role":     role,
 		"created":  now,
 		"updated":  now,
 	}
@@ -68,318 +149,2101 @@ func (r *mutationResolver) CreateUser(ctx context.Context, input model.NewUser)
 		Name:     input.Name,
 		Email:    input.Email,
 		Password: string(hashedPassword),
-		Role:     input.Role,
+		Role:     role,
 		Created:  now,
 		Updated:  now,
 	}, nil
 }
 
-func (r *mutationResolver) CreateTopic(ctx context.Context, input model.NewTopic) (*model.Topic, error) {
+func (r *mutationResolver) UpdateProfile(ctx context.Context, input model.ProfileUpdate) (*model.User, error) {
 	auth, err := GetAuthFromContext(ctx)
 	if err != nil {
 		return nil, err
 	}
-	if auth.Role != model.RoleAdmin.String() {
-		return nil, fmt.Errorf("Access denied!")
+	userOID, err := primitive.ObjectIDFromHex(auth.UID)
+	if err != nil {
+		return nil, err
 	}
-	now := time.Now().Unix()
-	topicData := bson.M{
-		"name":    input.Name,
-		"created": now,
-		"updated": now,
+	setData := bson.M{"updated": time.Now().Unix()}
+	if input.Name != nil {
+		if *input.Name == "" {
+			return nil, fmt.Errorf("Name must not be empty")
+		}
+		setData["name"] = *input.Name
 	}
-	result, err := r.DB.Collection("topics").InsertOne(context.Background(), topicData)
+	if input.Email != nil {
+		filter := bson.M{"email": *input.Email, "_id": bson.M{"$ne": userOID}}
+		count, err := r.DB.Collection("users").CountDocuments(context.Background(), filter)
+		if err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			return nil, fmt.Errorf("Email %v is already used", *input.Email)
+		}
+		setData["email"] = *input.Email
+	}
+	var user *model.User
+	filter := bson.M{"_id": userOID}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err = r.DB.Collection("users").FindOneAndUpdate(context.Background(), filter, bson.M{"$set": setData}, opts).Decode(&user)
 	if err != nil {
 		return nil, err
 	}
-	return &model.Topic{
-		ID:      result.InsertedID.(primitive.ObjectID).Hex(),
-		Name:    input.Name,
-		Created: now,
-		Updated: now,
-	}, nil
+	return user, nil
 }
 
-func (r *mutationResolver) RemoveTopic(ctx context.Context, id string) (*model.Topic, error) {
+func (r *mutationResolver) ChangePassword(ctx context.Context, currentPassword string, newPassword string) (bool, error) {
 	auth, err := GetAuthFromContext(ctx)
 	if err != nil {
-		return nil, err
+		return false, err
 	}
-	if auth.Role != model.RoleAdmin.String() {
-		return nil, fmt.Errorf("Access denied")
+	userOID, err := primitive.ObjectIDFromHex(auth.UID)
+	if err != nil {
+		return false, err
 	}
-	topicOID, err := primitive.ObjectIDFromHex(id)
+	var user model.User
+	err = r.DB.Collection("users").FindOne(context.Background(), bson.M{"_id": userOID}).Decode(&user)
+	if err != nil {
+		return false, err
+	}
+	if !user.CheckPassword(currentPassword) {
+		return false, fmt.Errorf("Incorrect password")
+	}
+	if len(newPassword) < minPasswordLength {
+		return false, fmt.Errorf("Password must have at least %v characters", minPasswordLength)
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return false, err
+	}
+	now := time.Now().Unix()
+	// existing tokens are revoked, the user has to login again
+	update := bson.M{"$set": bson.M{"password": string(hashedPassword), "passwordChanged": now, "updated": now}}
+	_, err = r.DB.Collection("users").UpdateOne(context.Background(), bson.M{"_id": userOID}, update)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *mutationResolver) DisableUser(ctx context.Context, id string) (*model.User, error) {
+	auth, err := GetAuthFromContext(ctx)
 	if err != nil {
 		return nil, err
 	}
-	var topic *model.Topic
-	filter := bson.M{"_id": topicOID}
-	err = r.DB.Collection("topics").FindOneAndDelete(context.Background(), filter).Decode(&topic)
+	if id == auth.UID {
+		return nil, fmt.Errorf("You can't disable your own account")
+	}
+	return r.setUserDisabled(auth, id, true)
+}
+
+func (r *mutationResolver) EnableUser(ctx context.Context, id string) (*model.User, error) {
+	auth, err := GetAuthFromContext(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return topic, nil
+	return r.setUserDisabled(auth, id, false)
 }
 
-func (r *mutationResolver) UpdateTopic(ctx context.Context, id string, name string) (*model.Topic, error) {
+func (r *mutationResolver) RequestDataExport(ctx context.Context, userID *string) (*model.DataExport, error) {
 	auth, err := GetAuthFromContext(ctx)
 	if err != nil {
 		return nil, err
 	}
-	if auth.Role != model.RoleAdmin.String() {
-		return nil, fmt.Errorf("Access denied")
+	subjectID, err := subjectUserID(auth, userID)
+	if err != nil {
+		return nil, err
 	}
-	topicOID, err := primitive.ObjectIDFromHex(id)
+	userOID, err := primitive.ObjectIDFromHex(subjectID)
 	if err != nil {
 		return nil, err
 	}
-	var topic *model.Topic
-	filter := bson.M{"_id": topicOID}
-	update := bson.M{"$set": bson.M{"name": name}}
-	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
-	err = r.DB.Collection("topics").FindOneAndUpdate(context.Background(), filter, update, opts).Decode(&topic)
+	var user *model.User
+	err = r.DB.Collection("users").FindOne(context.Background(), bson.M{"_id": userOID}).Decode(&user)
 	if err != nil {
 		return nil, err
 	}
-	return topic, nil
+	data, err := r.exportUserData(user)
+	if err != nil {
+		return nil, err
+	}
+	err = r.recordDataRequest("EXPORT", subjectID, auth)
+	if err != nil {
+		return nil, err
+	}
+	return &model.DataExport{
+		UserID:  subjectID,
+		Created: time.Now().Unix(),
+		Data:    data,
+	}, nil
 }
 
-func (r *mutationResolver) CreateBook(ctx context.Context, input model.NewBook) (*model.Book, error) {
+func (r *mutationResolver) DeleteAccount(ctx context.Context, userID *string, password *string) (bool, error) {
 	auth, err := GetAuthFromContext(ctx)
 	if err != nil {
-		return nil, err
+		return false, err
 	}
-	if auth.Role != model.RoleAdmin.String() {
-		return nil, fmt.Errorf("Access denied!")
+	subjectID, err := subjectUserID(auth, userID)
+	if err != nil {
+		return false, err
 	}
-	now := time.Now().Unix()
-	bookData := bson.M{
-		"name":      input.Name,
-		"price":     input.Price,
-		"content":   input.Content,
-		"created":   now,
-		"updated":   now,
-		"topicsId":  input.TopicsID,
-		"authorsId": input.AuthorsID,
+	userOID, err := primitive.ObjectIDFromHex(subjectID)
+	if err != nil {
+		return false, err
 	}
-	result, err := r.DB.Collection("books").InsertOne(context.Background(), bookData)
+	var user model.User
+	err = r.DB.Collection("users").FindOne(context.Background(), bson.M{"_id": userOID}).Decode(&user)
 	if err != nil {
-		return nil, err
+		return false, err
 	}
-	return &model.Book{
-		ID:        result.InsertedID.(primitive.ObjectID).Hex(),
-		Name:      input.Name,
-		Price:     input.Price,
-		Content:   input.Content,
-		TopicsID:  input.TopicsID,
-		AuthorsID: input.AuthorsID,
-		Created:   now,
-		Updated:   now,
-	}, nil
+	if subjectID == auth.UID {
+		// accounts created from an IdP have no password, their deletion is handled by support
+		if password == nil || !user.CheckPassword(*password) {
+			return false, fmt.Errorf("Incorrect password")
+		}
+	} else if user.Role == model.RoleAdmin && auth.Role != model.RoleAdmin.String() {
+		return false, fmt.Errorf("Access denied")
+	}
+	err = r.deleteUserData(userOID)
+	if err != nil {
+		return false, err
+	}
+	err = r.recordDataRequest("DELETION", subjectID, auth)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
 }
 
-func (r *mutationResolver) RemoveBook(ctx context.Context, id string) (*model.Book, error) {
+func (r *mutationResolver) EnableTwoFactor(ctx context.Context) (*model.TwoFactorSetup, error) {
 	auth, err := GetAuthFromContext(ctx)
 	if err != nil {
 		return nil, err
 	}
-	if auth.Role != model.RoleAdmin.String() {
-		return nil, fmt.Errorf("Access denied")
+	user, err := r.findUserTwoFactor(auth.UID)
+	if err != nil {
+		return nil, err
 	}
-	bookOID, err := primitive.ObjectIDFromHex(id)
+	if user.TwoFactorEnabled {
+		return nil, fmt.Errorf("Two-factor authentication is already enabled")
+	}
+	secret, err := totp.GenerateSecret()
 	if err != nil {
 		return nil, err
 	}
-	var book *model.Book
-	filter := bson.M{"_id": bookOID}
-	err = r.DB.Collection("books").FindOneAndDelete(context.Background(), filter).Decode(&book)
+	userOID, err := primitive.ObjectIDFromHex(auth.UID)
 	if err != nil {
 		return nil, err
 	}
-	// remove all reviews
-	filter = bson.M{"bookId": id}
-	_, err = r.DB.Collection("reviews").DeleteMany(context.Background(), filter)
+	filter := bson.M{"_id": userOID}
+	update := bson.M{"$set": bson.M{"twoFactor.pendingSecret": secret}}
+	_, err = r.DB.Collection("users").UpdateOne(context.Background(), filter, update)
 	if err != nil {
 		return nil, err
 	}
-	return book, nil
+	return &model.TwoFactorSetup{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(model.TwoFactorIssuer, user.Email, secret),
+	}, nil
 }
 
-func (r *mutationResolver) UpdateBook(ctx context.Context, id string, update model.BookUpdate) (*model.Book, error) {
+func (r *mutationResolver) ConfirmTwoFactor(ctx context.Context, code string) ([]string, error) {
 	auth, err := GetAuthFromContext(ctx)
 	if err != nil {
 		return nil, err
 	}
-	if auth.Role != model.RoleAdmin.String() {
-		return nil, fmt.Errorf("Access denied")
+	user, err := r.findUserTwoFactor(auth.UID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactorEnabled {
+		return nil, fmt.Errorf("Two-factor authentication is already enabled")
 	}
-	bookOID, err := primitive.ObjectIDFromHex(id)
+	if user.TwoFactor.PendingSecret == "" {
+		return nil, fmt.Errorf("Two-factor authentication has not been enabled")
+	}
+	step, ok := totp.Validate(user.TwoFactor.PendingSecret, code, time.Now())
+	if !ok {
+		return nil, fmt.Errorf("Invalid two-factor code")
+	}
+	codes, hashes, err := generateRecoveryCodes()
 	if err != nil {
 		return nil, err
 	}
-	var book *model.Book
-	filter := bson.M{"_id": bookOID}
-	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
-	updateData := bson.M{}
-	if update.Name != nil {
-		updateData["$set"] = bson.M{"name": *update.Name}
+	userOID, err := primitive.ObjectIDFromHex(auth.UID)
+	if err != nil {
+		return nil, err
 	}
-	if update.Content != nil {
-		updateData["$set"] = bson.M{"content": *update.Content}
+	filter := bson.M{"_id": userOID}
+	update := bson.M{"$set": bson.M{
+		"twoFactorEnabled": true,
+		"twoFactor": model.TwoFactor{
+			Secret:        user.TwoFactor.PendingSecret,
+			RecoveryCodes: hashes,
+			LastStep:      step,
+		},
+		"updated": time.Now().Unix(),
+	}}
+	_, err = r.DB.Collection("users").UpdateOne(context.Background(), filter, update)
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (r *mutationResolver) DisableTwoFactor(ctx context.Context, code string) (*model.User, error) {
+	auth, err := GetAuthFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	user, err := r.findUserTwoFactor(auth.UID)
+	if err != nil {
+		return nil, err
 	}
-	if len(update.AddingTopicsID) > 0 {
-		updateData["$addToSet"] = bson.M{"topicsId": bson.M{"$each": update.AddingTopicsID}}
+	if !user.TwoFactorEnabled {
+		return nil, fmt.Errorf("Two-factor authentication is not enabled")
 	}
-	if len(update.AddingAuthorsID) > 0 {
-		updateData["$addToSet"] = bson.M{"authorsId": bson.M{"$each": update.AddingAuthorsID}}
+	if model.TwoFactorRequired(user.Role) {
+		return nil, fmt.Errorf("Two-factor authentication is required for %v accounts", user.Role)
 	}
-	err = r.DB.Collection("books").FindOneAndUpdate(context.Background(), filter, updateData, opts).Decode(&book)
+	err = r.verifyTwoFactorCode(user, code)
 	if err != nil {
 		return nil, err
 	}
-	updateData = bson.M{}
-	if len(update.RemovingTopicsID) > 0 {
-		updateData["$pull"] = bson.M{"topicsId": bson.M{"$in": update.RemovingTopicsID}}
+	userOID, err := primitive.ObjectIDFromHex(auth.UID)
+	if err != nil {
+		return nil, err
 	}
-	if len(update.RemovingAuthorsID) > 0 {
-		updateData["$pull"] = bson.M{"authorsId": bson.M{"$in": update.RemovingAuthorsID}}
+	var updatedUser *model.User
+	filter := bson.M{"_id": userOID}
+	update := bson.M{
+		"$set":   bson.M{"twoFactorEnabled": false, "updated": time.Now().Unix()},
+		"$unset": bson.M{"twoFactor": ""},
 	}
-	if _, ok := updateData["$pull"]; ok {
-		err = r.DB.Collection("books").FindOneAndUpdate(context.Background(), filter, updateData, opts).Decode(&book)
-		if err != nil {
-			return nil, err
-		}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err = r.DB.Collection("users").FindOneAndUpdate(context.Background(), filter, update, opts).Decode(&updatedUser)
+	if err != nil {
+		return nil, err
 	}
-	return book, nil
+	return updatedUser, nil
 }
 
-func (r *mutationResolver) CreateReview(ctx context.Context, input model.NewReview) (*model.Review, error) {
+func (r *mutationResolver) CreateStaffRole(ctx context.Context, input model.NewStaffRole) (*model.StaffRole, error) {
 	auth, err := GetAuthFromContext(ctx)
 	if err != nil {
 		return nil, err
 	}
+	err = checkGrantable(auth, input.Permissions)
+	if err != nil {
+		return nil, err
+	}
+	count, err := r.DB.Collection("staff-roles").CountDocuments(context.Background(), bson.M{"name": input.Name})
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, fmt.Errorf("Staff role %v already exists", input.Name)
+	}
 	now := time.Now().Unix()
-	reviewData := bson.M{
-		"content": input.Content,
-		"created": now,
-		"updated": now,
-		"bookId":  input.BookID,
-		"userId":  auth.UID,
+	roleData := bson.M{
+		"name":        input.Name,
+		"permissions": input.Permissions,
+		"created":     now,
+		"updated":     now,
 	}
-	result, err := r.DB.Collection("reviews").InsertOne(context.Background(), reviewData)
+	result, err := r.DB.Collection("staff-roles").InsertOne(context.Background(), roleData)
 	if err != nil {
 		return nil, err
 	}
-	return &model.Review{
-		ID:      result.InsertedID.(primitive.ObjectID).Hex(),
-		Content: input.Content,
-		Created: now,
-		Updated: now,
-		BookID:  input.BookID,
-		UserID:  auth.UID,
+	return &model.StaffRole{
+		ID:          result.InsertedID.(primitive.ObjectID).Hex(),
+		Name:        input.Name,
+		Permissions: input.Permissions,
+		Created:     now,
+		Updated:     now,
 	}, nil
 }
 
-func (r *mutationResolver) RemoveReview(ctx context.Context, bookID string, reviewID string) (*model.Review, error) {
+func (r *mutationResolver) UpdateStaffRole(ctx context.Context, id string, update model.StaffRoleUpdate) (*model.StaffRole, error) {
 	auth, err := GetAuthFromContext(ctx)
 	if err != nil {
 		return nil, err
 	}
-	reviewOID, err := primitive.ObjectIDFromHex(reviewID)
+	roleOID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return nil, err
 	}
-	var review *model.Review
-	filter := bson.M{"_id": reviewOID, "bookId": bookID, "userId": auth.UID}
-	err = r.DB.Collection("reviews").FindOneAndDelete(context.Background(), filter).Decode(&review)
+	setData := bson.M{"updated": time.Now().Unix()}
+	if update.Name != nil {
+		filter := bson.M{"name": *update.Name, "_id": bson.M{"$ne": roleOID}}
+		count, err := r.DB.Collection("staff-roles").CountDocuments(context.Background(), filter)
+		if err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			return nil, fmt.Errorf("Staff role %v already exists", *update.Name)
+		}
+		setData["name"] = *update.Name
+	}
+	if update.Permissions != nil {
+		err = checkGrantable(auth, update.Permissions)
+		if err != nil {
+			return nil, err
+		}
+		setData["permissions"] = update.Permissions
+	}
+	var role *model.StaffRole
+	filter := bson.M{"_id": roleOID}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err = r.DB.Collection("staff-roles").FindOneAndUpdate(context.Background(), filter, bson.M{"$set": setData}, opts).Decode(&role)
 	if err != nil {
 		return nil, err
 	}
-	return review, nil
+	return role, nil
 }
 
-func (r *mutationResolver) UpdateReview(ctx context.Context, bookID string, reviewID string, content string) (*model.Review, error) {
-	auth, err := GetAuthFromContext(ctx)
+func (r *mutationResolver) RemoveStaffRole(ctx context.Context, id string) (*model.StaffRole, error) {
+	roleOID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return nil, err
 	}
-	reviewOID, err := primitive.ObjectIDFromHex(reviewID)
+	var role *model.StaffRole
+	filter := bson.M{"_id": roleOID}
+	err = r.DB.Collection("staff-roles").FindOneAndDelete(context.Background(), filter).Decode(&role)
 	if err != nil {
 		return nil, err
 	}
-	var review *model.Review
-	filter := bson.M{"_id": reviewOID, "bookId": bookID, "userId": auth.UID}
-	update := bson.M{"$set": bson.M{"content": content}}
-	options := options.FindOneAndUpdate().SetReturnDocument(options.After)
-	err = r.DB.Collection("reviews").FindOneAndUpdate(context.Background(), filter, update, options).Decode(&review)
+	// unassign the role from all users
+	filter = bson.M{"staffRolesId": id}
+	update := bson.M{"$pull": bson.M{"staffRolesId": id}}
+	_, err = r.DB.Collection("users").UpdateMany(context.Background(), filter, update)
 	if err != nil {
 		return nil, err
 	}
-	return review, nil
+	return role, nil
 }
 
-func (r *mutationResolver) SetCart(ctx context.Context, input model.CartData) (*model.Cart, error) {
+func (r *mutationResolver) SetUserStaffRoles(ctx context.Context, userID string, staffRolesID []string) (*model.User, error) {
 	auth, err := GetAuthFromContext(ctx)
 	if err != nil {
 		return nil, err
 	}
-	filter := bson.M{"userId": auth.UID}
-	update := bson.M{"$set": bson.M{"items": input.Items}}
-	opts := options.Update().SetUpsert(true)
-	result, err := r.DB.Collection("carts").UpdateOne(context.Background(), filter, update, opts)
+	if userID == auth.UID {
+		return nil, fmt.Errorf("You can't change your own staff roles")
+	}
+	userOID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
 		return nil, err
 	}
-	items := []*model.CartItem{}
-	for _, item := range input.Items {
-		items = append(items, &model.CartItem{
-			BookID:   item.BookID,
-			Quantity: item.Quantity,
-		})
-	}
-	return &model.Cart{
-		ID:     result.UpsertedID.(primitive.ObjectID).Hex(),
-		UserID: auth.UID,
-		Items:  items,
-	}, nil
-}
-
-func (r *mutationResolver) UpdateWishList(ctx context.Context, input model.WishListUpdate) (*model.WishList, error) {
-	auth, err := GetAuthFromContext(ctx)
+	roles, err := r.findStaffRoles(staffRolesID)
 	if err != nil {
 		return nil, err
 	}
-	var wishList *model.WishList
-	filter := bson.M{"userId": auth.UID}
-	opts := options.FindOneAndUpdate().SetReturnDocument(options.After).SetUpsert(true)
-	if len(input.Add) > 0 {
-		addUpdate := bson.M{"$addToSet": bson.M{"booksId": bson.M{"$each": input.Add}}}
-		err = r.DB.Collection("wish-lists").FindOneAndUpdate(context.Background(), filter, addUpdate, opts).Decode(&wishList)
+	rolesId := []string{}
+	for _, role := range roles {
+		err = checkGrantable(auth, role.Permissions)
 		if err != nil {
 			return nil, err
 		}
+		rolesId = append(rolesId, role.ID)
 	}
-	if len(input.Remove) > 0 {
-		removeUpdate := bson.M{"$pull": bson.M{"booksId": bson.M{"$in": input.Remove}}}
-		err = r.DB.Collection("wish-lists").FindOneAndUpdate(context.Background(), filter, removeUpdate, opts).Decode(&wishList)
-		if err != nil {
-			return nil, err
-		}
+	if len(rolesId) != len(staffRolesID) {
+		return nil, fmt.Errorf("Some staff roles don't exist")
 	}
-	return wishList, nil
+	var user *model.User
+	filter := bson.M{"_id": userOID}
+	update := bson.M{"$set": bson.M{"staffRolesId": rolesId, "updated": time.Now().Unix()}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err = r.DB.Collection("users").FindOneAndUpdate(context.Background(), filter, update, opts).Decode(&user)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
 }
 
-// Mutation returns generated.MutationResolver implementation.
-func (r *Resolver) Mutation() generated.MutationResolver { return &mutationResolver{r} }
-
-type mutationResolver struct{ *Resolver }
+func (r *mutationResolver) CreateAPIKey(ctx context.Context, input model.NewAPIKey) (*model.IssuedAPIKey, error) {
+	auth, err := GetAuthFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().Unix()
+	if input.Expires != nil && *input.Expires <= now {
+		return nil, fmt.Errorf("Expiry must be in the future")
+	}
+	key, prefix, hash, err := generateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+	apiKeyData := bson.M{
+		"name":        input.Name,
+		"prefix":      prefix,
+		"hash":        hash,
+		"permissions": input.Permissions,
+		"expires":     input.Expires,
+		"revoked":     false,
+		"createdBy":   auth.UID,
+		"created":     now,
+		"updated":     now,
+	}
+	result, err := r.DB.Collection("api-keys").InsertOne(context.Background(), apiKeyData)
+	if err != nil {
+		return nil, err
+	}
+	return &model.IssuedAPIKey{
+		Key: key,
+		APIKey: &model.APIKey{
+			ID:          result.InsertedID.(primitive.ObjectID).Hex(),
+			Name:        input.Name,
+			Prefix:      prefix,
+			Permissions: input.Permissions,
+			Expires:     input.Expires,
+			Revoked:     false,
+			CreatedBy:   auth.UID,
+			Created:     now,
+			Updated:     now,
+		},
+	}, nil
+}
+
+func (r *mutationResolver) RevokeAPIKey(ctx context.Context, id string) (*model.APIKey, error) {
+	apiKeyOID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	var apiKey *model.APIKey
+	filter := bson.M{"_id": apiKeyOID}
+	update := bson.M{"$set": bson.M{"revoked": true, "updated": time.Now().Unix()}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err = r.DB.Collection("api-keys").FindOneAndUpdate(context.Background(), filter, update, opts).Decode(&apiKey)
+	if err != nil {
+		return nil, err
+	}
+	return apiKey, nil
+}
+
+func (r *mutationResolver) CreateTopic(ctx context.Context, input model.NewTopic) (*model.Topic, error) {
+	if input.ParentID != nil {
+		_, err := r.findTopic(*input.ParentID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	now := time.Now().Unix()
+	topicData := bson.M{
+		"name":     input.Name,
+		"parentId": input.ParentID,
+		"created":  now,
+		"updated":  now,
+	}
+	result, err := r.DB.Collection("topics").InsertOne(context.Background(), topicData)
+	if err != nil {
+		return nil, err
+	}
+	return &model.Topic{
+		ID:       result.InsertedID.(primitive.ObjectID).Hex(),
+		Name:     input.Name,
+		ParentID: input.ParentID,
+		Created:  now,
+		Updated:  now,
+	}, nil
+}
+
+func (r *mutationResolver) RemoveTopic(ctx context.Context, id string, policy *model.RemovalPolicy, reassignTo *string) (*model.Topic, error) {
+	topicOID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	var topic *model.Topic
+	filter := bson.M{"_id": topicOID}
+	err = r.DB.Collection("topics").FindOne(context.Background(), filter).Decode(&topic)
+	if err != nil {
+		return nil, err
+	}
+	err = r.applyRemovalPolicy("topics", "Topic", "topicsId", id, policy, reassignTo)
+	if err != nil {
+		return nil, err
+	}
+	// the children of a removed topic move up to its parent
+	update := bson.M{"$set": bson.M{"parentId": topic.ParentID, "updated": time.Now().Unix()}}
+	_, err = r.DB.Collection("topics").UpdateMany(context.Background(), bson.M{"parentId": id}, update)
+	if err != nil {
+		return nil, err
+	}
+	_, err = r.DB.Collection("topics").DeleteOne(context.Background(), filter)
+	if err != nil {
+		return nil, err
+	}
+	return topic, nil
+}
+
+func (r *mutationResolver) UpdateTopic(ctx context.Context, id string, name *string, parentID *string) (*model.Topic, error) {
+	topicOID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	setData := bson.M{}
+	if name != nil {
+		if strings.TrimSpace(*name) == "" {
+			return nil, fmt.Errorf("Name must not be empty")
+		}
+		setData["name"] = strings.TrimSpace(*name)
+	}
+	if parentID != nil {
+		// an empty parent moves the topic to the top level
+		if *parentID == "" {
+			setData["parentId"] = nil
+		} else {
+			err = r.checkTopicParent(id, *parentID)
+			if err != nil {
+				return nil, err
+			}
+			setData["parentId"] = *parentID
+		}
+	}
+	if len(setData) == 0 {
+		return nil, fmt.Errorf("Nothing to update")
+	}
+	setData["updated"] = time.Now().Unix()
+	var topic *model.Topic
+	filter := bson.M{"_id": topicOID}
+	update := bson.M{"$set": setData}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err = r.DB.Collection("topics").FindOneAndUpdate(context.Background(), filter, update, opts).Decode(&topic)
+	if err != nil {
+		return nil, err
+	}
+	return topic, nil
+}
+
+func (r *mutationResolver) CreatePublisher(ctx context.Context, input model.NewPublisher) (*model.Publisher, error) {
+	now := time.Now().Unix()
+	publisher := &model.Publisher{
+		Name:    input.Name,
+		Website: input.Website,
+		Country: input.Country,
+		Created: now,
+		Updated: now,
+	}
+	err := publisher.Validate()
+	if err != nil {
+		return nil, err
+	}
+	publisherData := bson.M{
+		"name":    publisher.Name,
+		"website": publisher.Website,
+		"country": publisher.Country,
+		"created": now,
+		"updated": now,
+	}
+	result, err := r.DB.Collection("publishers").InsertOne(context.Background(), publisherData)
+	if err != nil {
+		return nil, err
+	}
+	publisher.ID = result.InsertedID.(primitive.ObjectID).Hex()
+	return publisher, nil
+}
+
+func (r *mutationResolver) RemovePublisher(ctx context.Context, id string) (*model.Publisher, error) {
+	publisherOID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	count, err := r.DB.Collection("books").CountDocuments(context.Background(), bson.M{"publisherId": id})
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, fmt.Errorf("Publisher %v is used by %v books", id, count)
+	}
+	var publisher *model.Publisher
+	filter := bson.M{"_id": publisherOID}
+	err = r.DB.Collection("publishers").FindOneAndDelete(context.Background(), filter).Decode(&publisher)
+	if err != nil {
+		return nil, err
+	}
+	return publisher, nil
+}
+
+func (r *mutationResolver) UpdatePublisher(ctx context.Context, id string, update model.PublisherUpdate) (*model.Publisher, error) {
+	publisherOID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	var publisher *model.Publisher
+	filter := bson.M{"_id": publisherOID}
+	err = r.DB.Collection("publishers").FindOne(context.Background(), filter).Decode(&publisher)
+	if err != nil {
+		return nil, err
+	}
+	publisher.ApplyUpdate(update)
+	err = publisher.Validate()
+	if err != nil {
+		return nil, err
+	}
+	publisher.Updated = time.Now().Unix()
+	updateData := bson.M{"$set": bson.M{
+		"name":    publisher.Name,
+		"website": publisher.Website,
+		"country": publisher.Country,
+		"updated": publisher.Updated,
+	}}
+	_, err = r.DB.Collection("publishers").UpdateOne(context.Background(), filter, updateData)
+	if err != nil {
+		return nil, err
+	}
+	return publisher, nil
+}
+
+func (r *mutationResolver) CreateSeries(ctx context.Context, input model.NewSeries) (*model.Series, error) {
+	now := time.Now().Unix()
+	series := &model.Series{
+		Name:        input.Name,
+		Description: input.Description,
+		Created:     now,
+		Updated:     now,
+	}
+	err := series.Validate()
+	if err != nil {
+		return nil, err
+	}
+	seriesData := bson.M{
+		"name":        series.Name,
+		"description": series.Description,
+		"created":     now,
+		"updated":     now,
+	}
+	result, err := r.DB.Collection("series").InsertOne(context.Background(), seriesData)
+	if err != nil {
+		return nil, err
+	}
+	series.ID = result.InsertedID.(primitive.ObjectID).Hex()
+	return series, nil
+}
+
+func (r *mutationResolver) RemoveSeries(ctx context.Context, id string) (*model.Series, error) {
+	seriesOID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	var series *model.Series
+	filter := bson.M{"_id": seriesOID}
+	err = r.DB.Collection("series").FindOneAndDelete(context.Background(), filter).Decode(&series)
+	if err != nil {
+		return nil, err
+	}
+	// take the books out of the series, the books themselves are kept
+	filter = bson.M{"seriesId": id}
+	update := bson.M{"$unset": bson.M{"seriesId": "", "seriesPosition": ""}, "$set": bson.M{"updated": time.Now().Unix()}}
+	_, err = r.DB.Collection("books").UpdateMany(context.Background(), filter, update)
+	if err != nil {
+		return nil, err
+	}
+	return series, nil
+}
+
+func (r *mutationResolver) UpdateSeries(ctx context.Context, id string, update model.SeriesUpdate) (*model.Series, error) {
+	seriesOID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	var series *model.Series
+	filter := bson.M{"_id": seriesOID}
+	err = r.DB.Collection("series").FindOne(context.Background(), filter).Decode(&series)
+	if err != nil {
+		return nil, err
+	}
+	series.ApplyUpdate(update)
+	err = series.Validate()
+	if err != nil {
+		return nil, err
+	}
+	series.Updated = time.Now().Unix()
+	updateData := bson.M{"$set": bson.M{
+		"name":        series.Name,
+		"description": series.Description,
+		"updated":     series.Updated,
+	}}
+	_, err = r.DB.Collection("series").UpdateOne(context.Background(), filter, updateData)
+	if err != nil {
+		return nil, err
+	}
+	return series, nil
+}
+
+func (r *mutationResolver) SetBookSeries(ctx context.Context, bookID string, seriesID *string, position *float64) (*model.Book, error) {
+	bookOID, err := primitive.ObjectIDFromHex(bookID)
+	if err != nil {
+		return nil, err
+	}
+	update := bson.M{"$set": bson.M{"updated": time.Now().Unix()}}
+	if seriesID == nil {
+		update["$unset"] = bson.M{"seriesId": "", "seriesPosition": ""}
+	} else {
+		_, err = r.findSeries(*seriesID)
+		if err != nil {
+			return nil, err
+		}
+		seriesPosition, err := r.seriesPosition(*seriesID, bookID, position)
+		if err != nil {
+			return nil, err
+		}
+		update["$set"] = bson.M{"seriesId": *seriesID, "seriesPosition": seriesPosition, "updated": time.Now().Unix()}
+	}
+	var book *model.Book
+	filter := bson.M{"_id": bookOID}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err = r.DB.Collection("books").FindOneAndUpdate(context.Background(), filter, update, opts).Decode(&book)
+	if err != nil {
+		return nil, err
+	}
+	return book, nil
+}
+
+func (r *mutationResolver) CreateBook(ctx context.Context, input model.NewBook) (*model.Book, error) {
+	errs := input.Validate()
+	errs = append(errs, r.checkBookReferences("", input.TopicsID, input.AuthorsID, input.PublisherID)...)
+	if input.Isbn != nil && len(errs) == 0 {
+		errs = append(errs, r.checkISBNUnique(*input.Isbn, "")...)
+	}
+	if len(errs) > 0 {
+		return nil, validationError(ctx, errs)
+	}
+	now := time.Now().Unix()
+	book := &model.Book{
+		Name:            input.Name,
+		Price:           input.Price,
+		Content:         input.Content,
+		Isbn:            input.Isbn,
+		PublisherID:     input.PublisherID,
+		PublicationDate: input.PublicationDate,
+		Language:        input.Language,
+		PageCount:       input.PageCount,
+		Format:          input.Format,
+		Edition:         input.Edition,
+		TopicsID:        input.TopicsID,
+		AuthorsID:       input.AuthorsID,
+		Created:         now,
+		Updated:         now,
+	}
+	bookData := bson.M{
+		"name":            book.Name,
+		"price":           book.Price,
+		"content":         book.Content,
+		"isbn":            book.Isbn,
+		"publisherId":     book.PublisherID,
+		"publicationDate": book.PublicationDate,
+		"language":        book.Language,
+		"pageCount":       book.PageCount,
+		"format":          book.Format,
+		"edition":         book.Edition,
+		"created":         now,
+		"updated":         now,
+		"topicsId":        book.TopicsID,
+		"authorsId":       book.AuthorsID,
+	}
+	result, err := r.DB.Collection("books").InsertOne(context.Background(), bookData)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, model.FieldError("isbn", "A book with ISBN %v already exists", *book.Isbn)
+	}
+	if err != nil {
+		return nil, err
+	}
+	book.ID = result.InsertedID.(primitive.ObjectID).Hex()
+	auth, err := GetAuthFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	userID, apiKeyID := priceChanger(auth)
+	err = r.recordPriceChange(&model.PriceChange{BookID: book.ID, NewPrice: book.Price, Reason: model.PriceChangeReasonCreated, UserID: userID, APIKeyID: apiKeyID})
+	if err != nil {
+		return nil, err
+	}
+	return book, nil
+}
+
+func (r *mutationResolver) RemoveBook(ctx context.Context, id string) (*model.Book, error) {
+	bookOID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	image, err := r.findCoverImage(bookOID)
+	if err != nil {
+		return nil, err
+	}
+	var book *model.Book
+	filter := bson.M{"_id": bookOID}
+	err = r.DB.Collection("books").FindOneAndDelete(context.Background(), filter).Decode(&book)
+	if err != nil {
+		return nil, err
+	}
+	err = r.deleteCoverBlobs(ctx, image)
+	if err != nil {
+		return nil, err
+	}
+	// remove all reviews
+	filter = bson.M{"bookId": id}
+	_, err = r.DB.Collection("reviews").DeleteMany(context.Background(), filter)
+	if err != nil {
+		return nil, err
+	}
+	return book, nil
+}
+
+func (r *mutationResolver) UpdateBook(ctx context.Context, id string, update model.BookUpdate) (*model.Book, error) {
+	bookOID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	errs := update.Validate()
+	errs = append(errs, r.checkBookReferences("adding", update.AddingTopicsID, update.AddingAuthorsID, model.Optional(update.PublisherID))...)
+	if update.Isbn != nil && *update.Isbn != "" && len(errs) == 0 {
+		errs = append(errs, r.checkISBNUnique(*update.Isbn, id)...)
+	}
+	if len(errs) > 0 {
+		return nil, validationError(ctx, errs)
+	}
+	// every field is changed by a single pipeline update, so a partial failure can't leave the book half updated
+	updateData := bson.M{"updated": time.Now().Unix()}
+	if update.Name != nil {
+		updateData["name"] = bson.M{"$literal": *update.Name}
+	}
+	if update.Price != nil {
+		updateData["price"] = *update.Price
+	}
+	if update.Content != nil {
+		updateData["content"] = bson.M{"$literal": *update.Content}
+	}
+	if update.Isbn != nil {
+		updateData["isbn"] = bson.M{"$literal": model.Optional(update.Isbn)}
+	}
+	if update.PublisherID != nil {
+		updateData["publisherId"] = bson.M{"$literal": model.Optional(update.PublisherID)}
+	}
+	if update.PublicationDate != nil {
+		updateData["publicationDate"] = bson.M{"$literal": model.Optional(update.PublicationDate)}
+	}
+	if update.Language != nil {
+		updateData["language"] = bson.M{"$literal": model.Optional(update.Language)}
+	}
+	if update.PageCount != nil {
+		updateData["pageCount"] = *update.PageCount
+	}
+	if update.Format != nil {
+		updateData["format"] = *update.Format
+	}
+	if update.Edition != nil {
+		updateData["edition"] = bson.M{"$literal": model.Optional(update.Edition)}
+	}
+	if len(update.AddingTopicsID) > 0 || len(update.RemovingTopicsID) > 0 {
+		updateData["topicsId"] = mergeIDs("topicsId", update.AddingTopicsID, update.RemovingTopicsID)
+	}
+	if len(update.AddingAuthorsID) > 0 || len(update.RemovingAuthorsID) > 0 {
+		updateData["authorsId"] = mergeIDs("authorsId", update.AddingAuthorsID, update.RemovingAuthorsID)
+	}
+	var oldPrice model.Money
+	if update.Price != nil {
+		oldPrice, err = r.currentPrice(id, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var book *model.Book
+	filter := bson.M{"_id": bookOID}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	pipeline := bson.A{bson.M{"$set": updateData}}
+	err = r.DB.Collection("books").FindOneAndUpdate(context.Background(), filter, pipeline, opts).Decode(&book)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, model.FieldError("isbn", "A book with ISBN %v already exists", *update.Isbn)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if update.Price != nil {
+		auth, err := GetAuthFromContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		userID, apiKeyID := priceChanger(auth)
+		err = r.recordPriceChange(&model.PriceChange{BookID: id, OldPrice: &oldPrice, NewPrice: book.Price, Reason: model.PriceChangeReasonManual, UserID: userID, APIKeyID: apiKeyID})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return book, nil
+}
+
+func (r *mutationResolver) UploadBookCover(ctx context.Context, bookID string, file graphql.Upload) (*model.Book, error) {
+	bookOID, err := primitive.ObjectIDFromHex(bookID)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := r.findCoverImage(bookOID)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(io.LimitReader(file.File, cover.MaxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	renditions, err := cover.Process(data)
+	if err != nil {
+		return nil, model.FieldError("file", err.Error())
+	}
+	image, err := r.storeCoverRenditions(ctx, bookID, renditions)
+	if err != nil {
+		return nil, err
+	}
+	var book *model.Book
+	filter := bson.M{"_id": bookOID}
+	update := bson.M{"$set": bson.M{"coverImage": image, "updated": time.Now().Unix()}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err = r.DB.Collection("books").FindOneAndUpdate(context.Background(), filter, update, opts).Decode(&book)
+	if err != nil {
+		r.deleteCoverBlobs(ctx, image)
+		return nil, err
+	}
+	// the previous cover is only deleted once the book points to the new one
+	err = r.deleteCoverBlobs(ctx, previous)
+	if err != nil {
+		graphql.AddErrorf(ctx, "The previous cover couldn't be deleted: %v", err.Error())
+	}
+	return book, nil
+}
+
+func (r *mutationResolver) RemoveBookCover(ctx context.Context, bookID string) (*model.Book, error) {
+	bookOID, err := primitive.ObjectIDFromHex(bookID)
+	if err != nil {
+		return nil, err
+	}
+	var book *model.Book
+	filter := bson.M{"_id": bookOID}
+	update := bson.M{"$unset": bson.M{"coverImage": ""}, "$set": bson.M{"updated": time.Now().Unix()}}
+	image, err := r.findCoverImage(bookOID)
+	if err != nil {
+		return nil, err
+	}
+	err = r.DB.Collection("books").FindOneAndUpdate(context.Background(), filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&book)
+	if err != nil {
+		return nil, err
+	}
+	err = r.deleteCoverBlobs(ctx, image)
+	if err != nil {
+		return nil, err
+	}
+	return book, nil
+}
+
+func (r *mutationResolver) ImportCatalog(ctx context.Context, file graphql.Upload, format model.ImportFormat, dryRun bool) (*model.ImportJob, error) {
+	auth, err := GetAuthFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(file.File)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().Unix()
+	job := &model.ImportJob{
+		UserID:    auth.UID,
+		FileName:  file.Filename,
+		Format:    format,
+		DryRun:    dryRun,
+		Status:    model.ImportJobStatusRunning,
+		RowErrors: []*model.ImportRowError{},
+		Created:   now,
+		Updated:   now,
+	}
+	jobData := bson.M{
+		"userId":       job.UserID,
+		"fileName":     job.FileName,
+		"format":       job.Format,
+		"dryRun":       job.DryRun,
+		"status":       job.Status,
+		"total":        0,
+		"processed":    0,
+		"booksCreated": 0,
+		"booksUpdated": 0,
+		"failed":       0,
+		"rowErrors":    job.RowErrors,
+		"created":      now,
+		"updated":      now,
+	}
+	result, err := r.DB.Collection("import-jobs").InsertOne(context.Background(), jobData)
+	if err != nil {
+		return nil, err
+	}
+	job.ID = result.InsertedID.(primitive.ObjectID).Hex()
+	// the importer works on its own copy, the returned job is the job as it was queued
+	queued := *job
+	go r.newCatalogImporter(job).run(data)
+	return &queued, nil
+}
+
+func (r *mutationResolver) SchedulePrice(ctx context.Context, input model.NewPriceSchedule) (*model.PriceSchedule, error) {
+	auth, err := GetAuthFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if input.Price.Amount < 0 {
+		return nil, model.FieldError("price", "Price must be a positive number")
+	}
+	now := time.Now().Unix()
+	if input.EndsAt != nil && *input.EndsAt <= input.StartsAt {
+		return nil, model.FieldError("endsAt", "The end must be after the start")
+	}
+	if input.EndsAt != nil && *input.EndsAt <= now {
+		return nil, model.FieldError("endsAt", "The end must be in the future")
+	}
+	price, err := r.currentPrice(input.BookID, input.VariantID)
+	if err != nil {
+		return nil, err
+	}
+	// the price is reverted at the end of the schedule, so it has to stay in the same currency
+	if input.Price.Currency != price.Currency {
+		return nil, model.FieldError("price", "The scheduled price must be in %v", price.Currency)
+	}
+	// the book is locked from the overlap check to the insert, so overlapping schedules can't both pass
+	locked, err := r.lockPriceSchedules(input.BookID, now)
+	if err != nil {
+		return nil, err
+	}
+	if !locked {
+		return nil, fmt.Errorf("Another price of this book is being scheduled, please try again")
+	}
+	defer r.unlockPriceSchedules(input.BookID, now)
+	count, err := r.DB.Collection("price-schedules").CountDocuments(context.Background(), priceScheduleFilter(input.BookID, input.VariantID, input.StartsAt, input.EndsAt))
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, fmt.Errorf("Another price is already scheduled during this period")
+	}
+	schedule := &model.PriceSchedule{
+		BookID:    input.BookID,
+		VariantID: input.VariantID,
+		Price:     input.Price,
+		StartsAt:  input.StartsAt,
+		EndsAt:    input.EndsAt,
+		Status:    model.PriceScheduleStatusScheduled,
+		Created:   now,
+		Updated:   now,
+	}
+	schedule.UserID, schedule.APIKeyID = priceChanger(auth)
+	scheduleData := bson.M{
+		"bookId":    schedule.BookID,
+		"variantId": schedule.VariantID,
+		"price":     schedule.Price,
+		"startsAt":  schedule.StartsAt,
+		"endsAt":    schedule.EndsAt,
+		"status":    schedule.Status,
+		"userId":    schedule.UserID,
+		"apiKeyId":  schedule.APIKeyID,
+		"created":   now,
+		"updated":   now,
+	}
+	result, err := r.DB.Collection("price-schedules").InsertOne(context.Background(), scheduleData)
+	if err != nil {
+		return nil, err
+	}
+	schedule.ID = result.InsertedID.(primitive.ObjectID).Hex()
+	// a schedule starting now is applied right away rather than at the next run of the scheduler
+	if schedule.StartsAt <= now {
+		err = r.startPriceSchedule(result.InsertedID.(primitive.ObjectID), now)
+		if err != nil {
+			return nil, err
+		}
+		schedule.Status = model.PriceScheduleStatusActive
+	}
+	return schedule, nil
+}
+
+func (r *mutationResolver) CancelPriceSchedule(ctx context.Context, id string) (*model.PriceSchedule, error) {
+	scheduleOID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().Unix()
+	schedule, err := r.endPriceSchedule(scheduleOID, model.PriceScheduleStatusCancelled, now)
+	if err == nil {
+		return schedule, nil
+	}
+	if err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+	filter := bson.M{"_id": scheduleOID, "status": model.PriceScheduleStatusScheduled}
+	update := bson.M{"$set": bson.M{"status": model.PriceScheduleStatusCancelled, "updated": now}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err = r.DB.Collection("price-schedules").FindOneAndUpdate(context.Background(), filter, update, opts).Decode(&schedule)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("Price schedule %v isn't scheduled or active", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+func (r *mutationResolver) ExportCatalog(ctx context.Context, format model.ExportFormat) (*model.ExportJob, error) {
+	auth, err := GetAuthFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().Unix()
+	job := &model.ExportJob{
+		UserID:  auth.UID,
+		Format:  format,
+		Status:  model.ExportJobStatusRunning,
+		Created: now,
+		Updated: now,
+	}
+	jobData := bson.M{
+		"userId":  job.UserID,
+		"format":  job.Format,
+		"status":  job.Status,
+		"total":   0,
+		"created": now,
+		"updated": now,
+	}
+	result, err := r.DB.Collection("export-jobs").InsertOne(context.Background(), jobData)
+	if err != nil {
+		return nil, err
+	}
+	job.ID = result.InsertedID.(primitive.ObjectID).Hex()
+	queued := *job
+	go r.runExport(job)
+	return &queued, nil
+}
+
+func (r *mutationResolver) SetExchangeRate(ctx context.Context, input model.NewExchangeRate) (*model.ExchangeRate, error) {
+	auth, err := GetAuthFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if errs := input.Validate(); len(errs) > 0 {
+		return nil, validationError(ctx, errs)
+	}
+	now := time.Now().Unix()
+	rate := &model.ExchangeRate{
+		Currency:          input.Currency,
+		Rate:              input.Rate,
+		EffectiveFrom:     now,
+		Rounding:          input.Rounding,
+		RoundingIncrement: input.RoundingIncrement,
+		UserID:            auth.UID,
+		Created:           now,
+	}
+	if input.EffectiveFrom != nil {
+		rate.EffectiveFrom = *input.EffectiveFrom
+	}
+	rateData := bson.M{
+		"currency":          rate.Currency,
+		"rate":              rate.Rate,
+		"effectiveFrom":     rate.EffectiveFrom,
+		"rounding":          rate.Rounding,
+		"roundingIncrement": rate.RoundingIncrement,
+		"userId":            rate.UserID,
+		"created":           rate.Created,
+	}
+	result, err := r.DB.Collection("exchange-rates").InsertOne(context.Background(), rateData)
+	if err != nil {
+		return nil, err
+	}
+	rate.ID = result.InsertedID.(primitive.ObjectID).Hex()
+	return rate, nil
+}
+
+func (r *mutationResolver) RemoveExchangeRate(ctx context.Context, id string) (*model.ExchangeRate, error) {
+	rateOID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	var rate *model.ExchangeRate
+	err = r.DB.Collection("exchange-rates").FindOneAndDelete(context.Background(), bson.M{"_id": rateOID}).Decode(&rate)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("Exchange rate %v doesn't exist", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return rate, nil
+}
+
+func (r *mutationResolver) CreateShippingMethod(ctx context.Context, input model.ShippingMethodInput) (*model.ShippingMethod, error) {
+	if errs := input.Validate(); len(errs) > 0 {
+		return nil, validationError(ctx, errs)
+	}
+	now := time.Now().Unix()
+	method := &model.ShippingMethod{
+		Name:       input.Name,
+		Type:       input.Type,
+		WeightStep: input.WeightStep,
+		Zones:      input.ShippingZones(),
+		MinDays:    input.MinDays,
+		MaxDays:    input.MaxDays,
+		Active:     input.Active,
+		Created:    now,
+		Updated:    now,
+	}
+	methodData := bson.M{
+		"name":       method.Name,
+		"type":       method.Type,
+		"weightStep": method.WeightStep,
+		"zones":      method.Zones,
+		"minDays":    method.MinDays,
+		"maxDays":    method.MaxDays,
+		"active":     method.Active,
+		"created":    now,
+		"updated":    now,
+	}
+	result, err := r.DB.Collection("shipping-methods").InsertOne(context.Background(), methodData)
+	if err != nil {
+		return nil, err
+	}
+	method.ID = result.InsertedID.(primitive.ObjectID).Hex()
+	return method, nil
+}
+
+func (r *mutationResolver) UpdateShippingMethod(ctx context.Context, id string, input model.ShippingMethodInput) (*model.ShippingMethod, error) {
+	methodOID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	if errs := input.Validate(); len(errs) > 0 {
+		return nil, validationError(ctx, errs)
+	}
+	update := bson.M{"$set": bson.M{
+		"name":       input.Name,
+		"type":       input.Type,
+		"weightStep": input.WeightStep,
+		"zones":      input.ShippingZones(),
+		"minDays":    input.MinDays,
+		"maxDays":    input.MaxDays,
+		"active":     input.Active,
+		"updated":    time.Now().Unix(),
+	}}
+	var method *model.ShippingMethod
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err = r.DB.Collection("shipping-methods").FindOneAndUpdate(context.Background(), bson.M{"_id": methodOID}, update, opts).Decode(&method)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("Shipping method %v doesn't exist", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return method, nil
+}
+
+func (r *mutationResolver) RemoveShippingMethod(ctx context.Context, id string) (*model.ShippingMethod, error) {
+	methodOID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	var method *model.ShippingMethod
+	err = r.DB.Collection("shipping-methods").FindOneAndDelete(context.Background(), bson.M{"_id": methodOID}).Decode(&method)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("Shipping method %v doesn't exist", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return method, nil
+}
+
+func (r *mutationResolver) AddBookVariant(ctx context.Context, bookID string, input model.NewBookVariant) (*model.BookVariant, error) {
+	bookOID, err := primitive.ObjectIDFromHex(bookID)
+	if err != nil {
+		return nil, err
+	}
+	variantOID := primitive.NewObjectID()
+	variant := &model.BookVariant{
+		ID:     variantOID.Hex(),
+		Sku:    input.Sku,
+		Format: input.Format,
+		Price:  input.Price,
+		Stock:  input.Stock,
+		Weight: input.Weight,
+	}
+	errs := variant.Validate()
+	if len(errs) == 0 {
+		errs = r.checkSKUUnique(variant.Sku, variantOID)
+	}
+	if len(errs) > 0 {
+		return nil, validationError(ctx, errs)
+	}
+	filter := bson.M{"_id": bookOID}
+	update := bson.M{
+		"$push": bson.M{"variants": variantData(variant, variantOID)},
+		"$set":  bson.M{"updated": time.Now().Unix()},
+	}
+	result, err := r.DB.Collection("books").UpdateOne(context.Background(), filter, update)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, model.FieldError("sku", "A variant with SKU %v already exists", variant.Sku)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if result.MatchedCount == 0 {
+		return nil, fmt.Errorf("Book %v doesn't exist", bookID)
+	}
+	auth, err := GetAuthFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	userID, apiKeyID := priceChanger(auth)
+	err = r.recordPriceChange(&model.PriceChange{BookID: bookID, VariantID: &variant.ID, NewPrice: variant.Price, Reason: model.PriceChangeReasonCreated, UserID: userID, APIKeyID: apiKeyID})
+	if err != nil {
+		return nil, err
+	}
+	return variant, nil
+}
+
+func (r *mutationResolver) UpdateBookVariant(ctx context.Context, id string, update model.BookVariantUpdate) (*model.BookVariant, error) {
+	book, variant, err := r.findBookByVariant(id)
+	if err != nil {
+		return nil, err
+	}
+	variantOID, _ := primitive.ObjectIDFromHex(id)
+	oldPrice := variant.Price
+	variant.ApplyUpdate(update)
+	errs := variant.Validate()
+	if len(errs) == 0 {
+		errs = r.checkSKUUnique(variant.Sku, variantOID)
+	}
+	if len(errs) > 0 {
+		return nil, validationError(ctx, errs)
+	}
+	filter := bson.M{"variants._id": variantOID}
+	updateData := bson.M{"$set": bson.M{
+		"variants.$": variantData(variant, variantOID),
+		"updated":    time.Now().Unix(),
+	}}
+	_, err = r.DB.Collection("books").UpdateOne(context.Background(), filter, updateData)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, model.FieldError("sku", "A variant with SKU %v already exists", variant.Sku)
+	}
+	if err != nil {
+		return nil, err
+	}
+	auth, err := GetAuthFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	userID, apiKeyID := priceChanger(auth)
+	err = r.recordPriceChange(&model.PriceChange{BookID: book.ID, VariantID: &variant.ID, OldPrice: &oldPrice, NewPrice: variant.Price, Reason: model.PriceChangeReasonManual, UserID: userID, APIKeyID: apiKeyID})
+	if err != nil {
+		return nil, err
+	}
+	return variant, nil
+}
+
+func (r *mutationResolver) RemoveBookVariant(ctx context.Context, id string) (*model.BookVariant, error) {
+	_, variant, err := r.findBookByVariant(id)
+	if err != nil {
+		return nil, err
+	}
+	variantOID, _ := primitive.ObjectIDFromHex(id)
+	filter := bson.M{"variants._id": variantOID}
+	update := bson.M{
+		"$pull": bson.M{"variants": bson.M{"_id": variantOID}},
+		"$set":  bson.M{"updated": time.Now().Unix()},
+	}
+	_, err = r.DB.Collection("books").UpdateOne(context.Background(), filter, update)
+	if err != nil {
+		return nil, err
+	}
+	return variant, nil
+}
+
+func (r *mutationResolver) CreateReview(ctx context.Context, input model.NewReview) (*model.Review, error) {
+	auth, err := GetAuthFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().Unix()
+	reviewData := bson.M{
+		"content": input.Content,
+		"created": now,
+		"updated": now,
+		"bookId":  input.BookID,
+		"userId":  auth.UID,
+	}
+	result, err := r.DB.Collection("reviews").InsertOne(context.Background(), reviewData)
+	if err != nil {
+		return nil, err
+	}
+	return &model.Review{
+		ID:      result.InsertedID.(primitive.ObjectID).Hex(),
+		Content: input.Content,
+		Created: now,
+		Updated: now,
+		BookID:  input.BookID,
+		UserID:  auth.UID,
+	}, nil
+}
+
+func (r *mutationResolver) RemoveReview(ctx context.Context, bookID string, reviewID string) (*model.Review, error) {
+	auth, err := GetAuthFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reviewOID, err := primitive.ObjectIDFromHex(reviewID)
+	if err != nil {
+		return nil, err
+	}
+	var review *model.Review
+	filter := bson.M{"_id": reviewOID, "bookId": bookID}
+	// moderators are allowed to remove reviews of any user
+	if !auth.HasPermission(model.PermissionReviewsModerate) {
+		filter["userId"] = auth.UID
+	}
+	err = r.DB.Collection("reviews").FindOneAndDelete(context.Background(), filter).Decode(&review)
+	if err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+func (r *mutationResolver) UpdateReview(ctx context.Context, bookID string, reviewID string, content string) (*model.Review, error) {
+	auth, err := GetAuthFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reviewOID, err := primitive.ObjectIDFromHex(reviewID)
+	if err != nil {
+		return nil, err
+	}
+	var review *model.Review
+	filter := bson.M{"_id": reviewOID, "bookId": bookID, "userId": auth.UID}
+	update := bson.M{"$set": bson.M{"content": content}}
+	options := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err = r.DB.Collection("reviews").FindOneAndUpdate(context.Background(), filter, update, options).Decode(&review)
+	if err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+func (r *mutationResolver) SetCart(ctx context.Context, input model.CartData) (*model.Cart, error) {
+	auth, err := GetAuthFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	filter := bson.M{"userId": auth.UID}
+	update := bson.M{"$set": bson.M{"items": input.Items}}
+	opts := options.Update().SetUpsert(true)
+	result, err := r.DB.Collection("carts").UpdateOne(context.Background(), filter, update, opts)
+	if err != nil {
+		return nil, err
+	}
+	items := []*model.CartItem{}
+	for _, item := range input.Items {
+		items = append(items, &model.CartItem{
+			BookID:    item.BookID,
+			VariantID: item.VariantID,
+			Quantity:  item.Quantity,
+		})
+	}
+	return &model.Cart{
+		ID:     result.UpsertedID.(primitive.ObjectID).Hex(),
+		UserID: auth.UID,
+		Items:  items,
+	}, nil
+}
+
+func (r *mutationResolver) CreateAddress(ctx context.Context, input model.NewAddress) (*model.Address, error) {
+	auth, err := GetAuthFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().Unix()
+	address := &model.Address{
+		UserID:     auth.UID,
+		Name:       input.Name,
+		Line1:      input.Line1,
+		Line2:      input.Line2,
+		City:       input.City,
+		Region:     input.Region,
+		PostalCode: input.PostalCode,
+		Country:    input.Country,
+		Phone:      input.Phone,
+		Created:    now,
+		Updated:    now,
+	}
+	err = address.Validate()
+	if err != nil {
+		return nil, err
+	}
+	count, err := r.DB.Collection("addresses").CountDocuments(context.Background(), bson.M{"userId": auth.UID})
+	if err != nil {
+		return nil, err
+	}
+	// the first address of a user is the default one
+	address.DefaultShipping = count == 0 || (input.DefaultShipping != nil && *input.DefaultShipping)
+	address.DefaultBilling = count == 0 || (input.DefaultBilling != nil && *input.DefaultBilling)
+	addressData := bson.M{
+		"userId":          address.UserID,
+		"name":            address.Name,
+		"line1":           address.Line1,
+		"line2":           address.Line2,
+		"city":            address.City,
+		"region":          address.Region,
+		"postalCode":      address.PostalCode,
+		"country":         address.Country,
+		"phone":           address.Phone,
+		"defaultShipping": address.DefaultShipping,
+		"defaultBilling":  address.DefaultBilling,
+		"created":         now,
+		"updated":         now,
+	}
+	result, err := r.DB.Collection("addresses").InsertOne(context.Background(), addressData)
+	if err != nil {
+		return nil, err
+	}
+	addressOID := result.InsertedID.(primitive.ObjectID)
+	address.ID = addressOID.Hex()
+	if address.DefaultShipping {
+		err = r.clearDefaultAddress(auth.UID, "defaultShipping", addressOID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if address.DefaultBilling {
+		err = r.clearDefaultAddress(auth.UID, "defaultBilling", addressOID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return address, nil
+}
+
+func (r *mutationResolver) UpdateAddress(ctx context.Context, id string, update model.AddressUpdate) (*model.Address, error) {
+	auth, err := GetAuthFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	address, err := r.findAddress(auth.UID, id)
+	if err != nil {
+		return nil, err
+	}
+	if update.Name != nil {
+		address.Name = *update.Name
+	}
+	if update.Line1 != nil {
+		address.Line1 = *update.Line1
+	}
+	if update.Line2 != nil {
+		address.Line2 = update.Line2
+	}
+	if update.City != nil {
+		address.City = *update.City
+	}
+	if update.Region != nil {
+		address.Region = update.Region
+	}
+	if update.PostalCode != nil {
+		address.PostalCode = *update.PostalCode
+	}
+	if update.Country != nil {
+		address.Country = *update.Country
+	}
+	if update.Phone != nil {
+		address.Phone = update.Phone
+	}
+	if update.DefaultShipping != nil {
+		address.DefaultShipping = *update.DefaultShipping
+	}
+	if update.DefaultBilling != nil {
+		address.DefaultBilling = *update.DefaultBilling
+	}
+	err = address.Validate()
+	if err != nil {
+		return nil, err
+	}
+	address.Updated = time.Now().Unix()
+	addressOID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	filter := bson.M{"_id": addressOID, "userId": auth.UID}
+	updateData := bson.M{"$set": bson.M{
+		"name":            address.Name,
+		"line1":           address.Line1,
+		"line2":           address.Line2,
+		"city":            address.City,
+		"region":          address.Region,
+		"postalCode":      address.PostalCode,
+		"country":         address.Country,
+		"phone":           address.Phone,
+		"defaultShipping": address.DefaultShipping,
+		"defaultBilling":  address.DefaultBilling,
+		"updated":         address.Updated,
+	}}
+	_, err = r.DB.Collection("addresses").UpdateOne(context.Background(), filter, updateData)
+	if err != nil {
+		return nil, err
+	}
+	if address.DefaultShipping {
+		err = r.clearDefaultAddress(auth.UID, "defaultShipping", addressOID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if address.DefaultBilling {
+		err = r.clearDefaultAddress(auth.UID, "defaultBilling", addressOID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return address, nil
+}
+
+func (r *mutationResolver) RemoveAddress(ctx context.Context, id string) (*model.Address, error) {
+	auth, err := GetAuthFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	addressOID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	var address *model.Address
+	filter := bson.M{"_id": addressOID, "userId": auth.UID}
+	err = r.DB.Collection("addresses").FindOneAndDelete(context.Background(), filter).Decode(&address)
+	if err != nil {
+		return nil, err
+	}
+	return address, nil
+}
+
+func (r *mutationResolver) PlaceOrder(ctx context.Context, input model.NewOrder) (*model.Order, error) {
+	auth, err := GetAuthFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var cart *model.Cart
+	err = r.DB.Collection("carts").FindOne(context.Background(), bson.M{"userId": auth.UID}).Decode(&cart)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("Cart is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+	var shippingAddress *model.Address
+	if input.ShippingAddressID != nil {
+		shippingAddress, err = r.findAddress(auth.UID, *input.ShippingAddressID)
+	} else {
+		shippingAddress, err = r.findDefaultAddress(auth.UID, "defaultShipping")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if shippingAddress == nil {
+		return nil, fmt.Errorf("Please provide a shipping address")
+	}
+	var billingAddress *model.Address
+	if input.BillingAddressID != nil {
+		billingAddress, err = r.findAddress(auth.UID, *input.BillingAddressID)
+	} else {
+		billingAddress, err = r.findDefaultAddress(auth.UID, "defaultBilling")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if billingAddress == nil {
+		billingAddress = shippingAddress
+	}
+	items, err := r.orderItems(cart)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().Unix()
+	currency := requestCurrency(ctx, input.Currency)
+	if currency == "" {
+		currency = model.StoreCurrency()
+	}
+	// every unit price is rounded before it's multiplied, so the total is the sum of the item lines
+	baseItems, exchangeRate, err := r.convertOrderItems(items, currency, now)
+	if err != nil {
+		return nil, err
+	}
+	shipping, err := r.orderShipping(items, input.ShippingMethodID, shippingAddress.Country, currency, now)
+	if err != nil {
+		return nil, err
+	}
+	var shippingCost, baseShippingCost *model.Money
+	if shipping != nil {
+		baseShipping, err := r.orderShipping(baseItems, input.ShippingMethodID, shippingAddress.Country, model.StoreCurrency(), now)
+		if err != nil {
+			return nil, err
+		}
+		shippingCost, baseShippingCost = &shipping.Cost, &baseShipping.Cost
+	}
+	location := taxLocation(shippingAddress.Country, shippingAddress.Region)
+	taxes, err := r.computeTax(items, shippingCost, location, currency)
+	if err != nil {
+		return nil, err
+	}
+	baseTaxes, err := r.computeTax(baseItems, baseShippingCost, location, model.StoreCurrency())
+	if err != nil {
+		return nil, err
+	}
+	// the shipping is taxed as a line of its own, the totals include it
+	total, baseTotal := taxes.Gross, baseTaxes.Gross
+	err = r.reserveStock(items)
+	if err != nil {
+		return nil, err
+	}
+	order := &model.Order{
+		UserID:          auth.UID,
+		Items:           items,
+		Total:           total,
+		Tax:             taxes,
+		Shipping:        shipping,
+		Currency:        currency,
+		BaseTotal:       baseTotal,
+		ExchangeRate:    exchangeRate,
+		Status:          model.OrderStatusPending,
+		ShippingAddress: shippingAddress.OrderAddress(),
+		BillingAddress:  billingAddress.OrderAddress(),
+		Created:         now,
+		Updated:         now,
+	}
+	orderData := bson.M{
+		"userId":          order.UserID,
+		"items":           order.Items,
+		"total":           order.Total,
+		"tax":             order.Tax,
+		"shipping":        order.Shipping,
+		"currency":        order.Currency,
+		"baseTotal":       order.BaseTotal,
+		"exchangeRate":    order.ExchangeRate,
+		"status":          order.Status,
+		"shippingAddress": order.ShippingAddress,
+		"billingAddress":  order.BillingAddress,
+		"created":         now,
+		"updated":         now,
+	}
+	result, err := r.DB.Collection("orders").InsertOne(context.Background(), orderData)
+	if err != nil {
+		r.releaseStock(items)
+		return nil, err
+	}
+	order.ID = result.InsertedID.(primitive.ObjectID).Hex()
+	_, err = r.DB.Collection("carts").UpdateOne(context.Background(), bson.M{"userId": auth.UID}, bson.M{"$set": bson.M{"items": bson.A{}}})
+	if err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+func (r *mutationResolver) UpdateOrderStatus(ctx context.Context, id string, status model.OrderStatus) (*model.Order, error) {
+	auth, err := GetAuthFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	order, err := r.findOrder(auth, id)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("Order %v doesn't exist", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	previous := order.Status
+	if !previous.CanBecome(status) {
+		return nil, fmt.Errorf("Order %v can't go from %v to %v", id, previous, status)
+	}
+	orderOID, err := primitive.ObjectIDFromHex(order.ID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().Unix()
+	// filtering on the previous status makes a concurrent change of status fail
+	filter := bson.M{"_id": orderOID, "status": previous}
+	update := bson.M{"$set": bson.M{"status": status, "updated": now}}
+	result, err := r.DB.Collection("orders").UpdateOne(context.Background(), filter, update)
+	if err != nil {
+		return nil, err
+	}
+	if result.ModifiedCount == 0 {
+		return nil, fmt.Errorf("Order %v was changed meanwhile, please try again", id)
+	}
+	order.Status = status
+	order.Updated = now
+	switch status {
+	case model.OrderStatusPaid:
+		_, err = r.issueInvoice(order)
+	case model.OrderStatusCancelled:
+		err = r.releaseStock(order.Items)
+		if err == nil && previous == model.OrderStatusPaid {
+			quantities := make([]int64, len(order.Items))
+			for i, item := range order.Items {
+				quantities[i] = item.Quantity
+			}
+			_, err = r.refund(ctx, order, quantities, true, "Order cancelled")
+		}
+	}
+	// the status has changed anyway
+	if err != nil {
+		graphql.AddError(ctx, err)
+	}
+	return order, nil
+}
+
+func (r *mutationResolver) RefundCreditNote(ctx context.Context, id string) (*model.Invoice, error) {
+	note, err := r.findInvoiceByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if note.Kind != model.InvoiceKindCreditNote {
+		return nil, fmt.Errorf("%v isn't a credit note", note.Number)
+	}
+	err = r.refundCreditNote(ctx, note)
+	if err != nil {
+		return nil, err
+	}
+	return note, nil
+}
+
+func (r *mutationResolver) RequestReturn(ctx context.Context, orderID string, lines []*model.ReturnLineInput, reason string) (*model.Return, error) {
+	auth, err := GetAuthFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	order, err := r.findOrder(auth, orderID)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("Order %v doesn't exist", orderID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != model.OrderStatusShipped && order.Status != model.OrderStatusDelivered {
+		return nil, fmt.Errorf("Order %v can't be returned before it's shipped", orderID)
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, fmt.Errorf("Please give a reason for the return")
+	}
+	returnLines, err := r.returnLines(order, lines)
+	if err != nil {
+		return nil, err
+	}
+	// a concurrent request of the same items fails to claim them
+	quantities := returnQuantities(order, returnLines)
+	claimed, err := r.claimQuantities(order, "returnedQuantities", quantities, func() ([]int64, error) {
+		return r.returnedQuantities(order)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, fmt.Errorf("Items of order %v were returned meanwhile, please try again", orderID)
+	}
+	now := time.Now().Unix()
+	ret := &model.Return{
+		OrderID: order.ID,
+		UserID:  order.UserID,
+		Lines:   returnLines,
+		Reason:  reason,
+		Status:  model.ReturnStatusRequested,
+		Created: now,
+		Updated: now,
+	}
+	result, err := r.DB.Collection("returns").InsertOne(context.Background(), bson.M{
+		"orderId":   ret.OrderID,
+		"userId":    ret.UserID,
+		"lines":     ret.Lines,
+		"reason":    ret.Reason,
+		"status":    ret.Status,
+		"restocked": false,
+		"created":   now,
+		"updated":   now,
+	})
+	if err != nil {
+		r.releaseQuantities(order, "returnedQuantities", quantities)
+		return nil, err
+	}
+	ret.ID = result.InsertedID.(primitive.ObjectID).Hex()
+	return ret, nil
+}
+
+func (r *mutationResolver) ApproveReturn(ctx context.Context, id string, restock *bool, refundShipping *bool) (*model.Return, error) {
+	auth, err := GetAuthFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ret, err := r.findReturn(id)
+	if err != nil {
+		return nil, err
+	}
+	if ret.Status != model.ReturnStatusRequested && ret.Status != model.ReturnStatusApproved {
+		return nil, fmt.Errorf("Return %v is already %v", id, ret.Status)
+	}
+	order, err := r.findOrder(auth, ret.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	returnOID, err := primitive.ObjectIDFromHex(ret.ID)
+	if err != nil {
+		return nil, err
+	}
+	quantities := returnQuantities(order, ret.Lines)
+	now := time.Now().Unix()
+	// the approval claims the return, so two approvals never issue its credit note both
+	switch {
+	case ret.Status == model.ReturnStatusRequested:
+		// a return which can't be credited is left waiting for approval
+		invoiced, err := r.findInvoice(order.ID)
+		if err != nil {
+			return nil, err
+		}
+		if invoiced == nil {
+			return nil, fmt.Errorf("Order %v has no invoice to credit", order.ID)
+		}
+		_, _, err = creditLines(order, quantities)
+		if err != nil {
+			return nil, err
+		}
+		filter := bson.M{"_id": returnOID, "status": model.ReturnStatusRequested}
+		update := bson.M{"$set": bson.M{"status": model.ReturnStatusApproved, "updated": now}}
+		result, err := r.DB.Collection("returns").UpdateOne(context.Background(), filter, update)
+		if err != nil {
+			return nil, err
+		}
+		if result.ModifiedCount == 0 {
+			return nil, fmt.Errorf("Return %v was changed meanwhile, please try again", id)
+		}
+		ret.Status = model.ReturnStatusApproved
+	case ret.CreditNoteID == nil:
+		// an approval which stopped before issuing the credit note is taken over once it's stale
+		stale := now - int64(ReturnApprovalTimeout.Seconds())
+		filter := bson.M{"_id": returnOID, "status": model.ReturnStatusApproved, "creditNoteId": nil, "updated": bson.M{"$lt": stale}}
+		result, err := r.DB.Collection("returns").UpdateOne(context.Background(), filter, bson.M{"$set": bson.M{"updated": now}})
+		if err != nil {
+			return nil, err
+		}
+		if result.ModifiedCount == 0 {
+			return nil, fmt.Errorf("Return %v is being approved, please try again later", id)
+		}
+	}
+	if ret.CreditNoteID == nil {
+		shipping := refundShipping != nil && *refundShipping
+		note, err := r.issueCreditNote(order, quantities, shipping, ret.Reason)
+		if note == nil {
+			// the return waits for approval again
+			filter := bson.M{"_id": returnOID, "status": model.ReturnStatusApproved, "creditNoteId": nil}
+			update := bson.M{"$set": bson.M{"status": model.ReturnStatusRequested, "updated": time.Now().Unix()}}
+			_, updateErr := r.DB.Collection("returns").UpdateOne(context.Background(), filter, update)
+			if updateErr != nil {
+				return nil, updateErr
+			}
+			return nil, err
+		}
+		// the PDF is rendered again when it's downloaded
+		if err != nil {
+			graphql.AddError(ctx, err)
+		}
+		_, err = r.DB.Collection("returns").UpdateOne(context.Background(), bson.M{"_id": returnOID}, bson.M{"$set": bson.M{"creditNoteId": note.ID}})
+		if err != nil {
+			return nil, err
+		}
+		ret.CreditNoteID = &note.ID
+	}
+	if restock != nil && *restock && !ret.Restocked {
+		// claiming the flag first puts the items back in stock once
+		filter := bson.M{"_id": returnOID, "restocked": bson.M{"$ne": true}}
+		result, err := r.DB.Collection("returns").UpdateOne(context.Background(), filter, bson.M{"$set": bson.M{"restocked": true}})
+		if err != nil {
+			return nil, err
+		}
+		if result.ModifiedCount > 0 {
+			err = r.releaseStock(returnedItems(order, ret))
+			if err != nil {
+				r.DB.Collection("returns").UpdateOne(context.Background(), bson.M{"_id": returnOID}, bson.M{"$set": bson.M{"restocked": false}})
+				return nil, err
+			}
+		}
+		ret.Restocked = true
+	}
+	note, err := r.findInvoiceByID(*ret.CreditNoteID)
+	if err != nil {
+		return nil, err
+	}
+	err = r.refundCreditNote(ctx, note)
+	if err != nil {
+		message := err.Error()
+		_, updateErr := r.DB.Collection("returns").UpdateOne(context.Background(), bson.M{"_id": returnOID}, bson.M{"$set": bson.M{"error": message}})
+		if updateErr != nil {
+			return nil, updateErr
+		}
+		ret.Error = &message
+		graphql.AddError(ctx, err)
+		return ret, nil
+	}
+	return r.findReturn(id)
+}
+
+func (r *mutationResolver) RejectReturn(ctx context.Context, id string, note string) (*model.Return, error) {
+	note = strings.TrimSpace(note)
+	if note == "" {
+		return nil, fmt.Errorf("Please give a reason for the rejection")
+	}
+	returnOID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	var ret *model.Return
+	filter := bson.M{"_id": returnOID, "status": model.ReturnStatusRequested}
+	update := bson.M{"$set": bson.M{"status": model.ReturnStatusRejected, "note": note, "updated": time.Now().Unix()}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err = r.DB.Collection("returns").FindOneAndUpdate(context.Background(), filter, update, opts).Decode(&ret)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("Return %v isn't waiting for approval", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// the rejected items can be returned again
+	auth, err := GetAuthFromContext(ctx)
+	if err == nil {
+		var order *model.Order
+		order, err = r.findOrder(auth, ret.OrderID)
+		if err == nil {
+			err = r.releaseQuantities(order, "returnedQuantities", returnQuantities(order, ret.Lines))
+		}
+	}
+	// the return is rejected anyway
+	if err != nil {
+		graphql.AddError(ctx, err)
+	}
+	return ret, nil
+}
 
-// !!! WARNING !!!
-// The code below was going to be deleted when updating resolvers. It has been copied here so you have
-// one last chance to move it out of harms way if you want. There are two reasons this happens:
-//  - When renaming or deleting a resolver the old code will be put in here. You can safely delete
-//    it when you're done.
-//  - You have helper methods in this file. Move them out to keep these resolver files clean.
-func (r *mutationResolver) GetUser(ctx context.Context, id string) (*model.User, error) {
-	panic(fmt.Errorf("not implemented"))
+func (r *mutationResolver) UpdateWishList(ctx context.Context, input model.WishListUpdate) (*model.WishList, error) {
+	auth, err := GetAuthFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var wishList *model.WishList
+	filter := bson.M{"userId": auth.UID}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After).SetUpsert(true)
+	if len(input.Add) > 0 {
+		addUpdate := bson.M{"$addToSet": bson.M{"booksId": bson.M{"$each": input.Add}}}
+		err = r.DB.Collection("wish-lists").FindOneAndUpdate(context.Background(), filter, addUpdate, opts).Decode(&wishList)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(input.Remove) > 0 {
+		removeUpdate := bson.M{"$pull": bson.M{"booksId": bson.M{"$in": input.Remove}}}
+		err = r.DB.Collection("wish-lists").FindOneAndUpdate(context.Background(), filter, removeUpdate, opts).Decode(&wishList)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return wishList, nil
 }
+
+// Mutation returns generated.MutationResolver implementation.
+func (r *Resolver) Mutation() generated.MutationResolver { return &mutationResolver{r} }
+
+type mutationResolver struct{ *Resolver }
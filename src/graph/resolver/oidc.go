@@ -0,0 +1,121 @@
+package resolver
+
+import (
+	"book-store/graph/model"
+	"book-store/oidc"
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// LoginWithOIDC creates or updates the user of a verified identity and returns our own token.
+// groupRoles maps an IdP group to ADMIN or to the name of a staff role. The role and staff roles are
+// given from the IdP groups to the accounts created on first login, and kept on every later login and
+// on linked local accounts unless syncRoles is set. A local account with two-factor authentication
+// isn't linked, and when the provider didn't check a second factor the user of an account with
+// two-factor authentication gets the same challenge as with Login instead of a token.
+func (r *Resolver) LoginWithOIDC(identity *oidc.Identity, groupRoles map[string]string, syncRoles bool) (*model.LoginResult, error) {
+	if identity.Email == "" {
+		return nil, fmt.Errorf("ID token has no email")
+	}
+	role := model.RoleClient
+	staffRoleNames := []string{}
+	for _, group := range identity.Groups {
+		target, ok := groupRoles[group]
+		if !ok {
+			continue
+		}
+		if target == model.RoleAdmin.String() {
+			role = model.RoleAdmin
+		} else {
+			staffRoleNames = append(staffRoleNames, target)
+		}
+	}
+	staffRolesId := []string{}
+	if len(staffRoleNames) > 0 {
+		cs, err := r.DB.Collection("staff-roles").Find(context.Background(), bson.M{"name": bson.M{"$in": staffRoleNames}})
+		if err != nil {
+			return nil, err
+		}
+		var roles []*model.StaffRole
+		defer cs.Close(context.Background())
+		err = cs.All(context.Background(), &roles)
+		if err != nil {
+			return nil, err
+		}
+		for _, staffRole := range roles {
+			staffRolesId = append(staffRolesId, staffRole.ID)
+		}
+	}
+
+	filter := bson.M{"oidc.issuer": identity.Issuer, "oidc.subject": identity.Subject}
+	count, err := r.DB.Collection("users").CountDocuments(context.Background(), filter)
+	if err != nil {
+		return nil, err
+	}
+	// link an existing local account, only when the provider has verified the email
+	if count == 0 && identity.EmailVerified {
+		var local model.User
+		err = r.DB.Collection("users").FindOne(context.Background(), bson.M{"email": identity.Email}).Decode(&local)
+		if err == nil {
+			// the provider's password alone mustn't get past the second factor of the account
+			if local.TwoFactorEnabled {
+				return nil, fmt.Errorf("Account %v has two-factor authentication, please login with your password", identity.Email)
+			}
+			filter = bson.M{"email": identity.Email}
+		} else if err != mongo.ErrNoDocuments {
+			return nil, err
+		}
+	}
+	now := time.Now().Unix()
+	setData := bson.M{
+		"email":   identity.Email,
+		"oidc":    bson.M{"issuer": identity.Issuer, "subject": identity.Subject},
+		"updated": now,
+	}
+	// an empty password hash never matches, accounts created from the IdP can't log in with a password
+	setOnInsert := bson.M{"password": "", "twoFactorEnabled": false, "created": now}
+	roleData := bson.M{"role": role, "staffRolesId": staffRolesId}
+	if syncRoles {
+		for key, value := range roleData {
+			setData[key] = value
+		}
+	} else {
+		for key, value := range roleData {
+			setOnInsert[key] = value
+		}
+	}
+	if identity.Name != "" {
+		setData["name"] = identity.Name
+	}
+	update := bson.M{"$set": setData, "$setOnInsert": setOnInsert}
+	var user model.User
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After).SetUpsert(true)
+	err = r.DB.Collection("users").FindOneAndUpdate(context.Background(), filter, update, opts).Decode(&user)
+	if err != nil {
+		return nil, err
+	}
+	if user.Disabled {
+		return nil, fmt.Errorf("Account is disabled")
+	}
+	if user.TwoFactorEnabled && !identity.MFA {
+		challenge, err := user.CreateTwoFactorChallenge()
+		if err != nil {
+			return nil, err
+		}
+		return &model.LoginResult{Token: challenge, TwoFactorRequired: true}, nil
+	}
+	permissions, err := r.permissionsOf(&user)
+	if err != nil {
+		return nil, err
+	}
+	token, err := user.CreateJWT(identity.MFA, permissions)
+	if err != nil {
+		return nil, err
+	}
+	return &model.LoginResult{Token: token, TwoFactorRequired: false}, nil
+}
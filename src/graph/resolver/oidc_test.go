@@ -0,0 +1,74 @@
+package resolver
+
+import (
+	"book-store/graph/model"
+	"book-store/oidc"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestLoginWithOIDCTwoFactor(t *testing.T) {
+	t.Setenv("JWT_LIFE_TIME", "7")
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+	identity := &oidc.Identity{Issuer: "https://idp", Subject: "s", Email: "a@example.com", EmailVerified: true}
+	user := bson.D{
+		{Key: "_id", Value: primitive.NewObjectID()},
+		{Key: "email", Value: identity.Email},
+		{Key: "role", Value: model.RoleClient},
+		{Key: "twoFactorEnabled", Value: true},
+	}
+
+	mt.Run("local account with two-factor isn't linked", func(mt *mtest.T) {
+		r := &Resolver{DB: mt.DB}
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "db.users", mtest.FirstBatch),
+			mtest.CreateCursorResponse(0, "db.users", mtest.FirstBatch, user),
+		)
+		if _, err := r.LoginWithOIDC(identity, nil, false); err == nil {
+			mt.Fatal("expected the account with two-factor not to be linked")
+		}
+		if updates := commands(mt)["findAndModify"]; len(updates) != 0 {
+			mt.Fatal("expected the account not to be updated")
+		}
+	})
+
+	mt.Run("linked account with two-factor gets a challenge", func(mt *mtest.T) {
+		r := &Resolver{DB: mt.DB}
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "db.users", mtest.FirstBatch, bson.D{{Key: "n", Value: 1}}),
+			mtest.CreateSuccessResponse(bson.E{Key: "value", Value: user}),
+		)
+		result, err := r.LoginWithOIDC(identity, nil, false)
+		if err != nil {
+			mt.Fatal(err)
+		}
+		if !result.TwoFactorRequired {
+			mt.Fatal("expected a two-factor challenge instead of a token")
+		}
+		claims, err := parseToken(result.Token)
+		if err != nil || claims["challenge"] != "2fa" {
+			mt.Fatalf("expected a challenge token, got %v %v", claims, err)
+		}
+	})
+
+	mt.Run("provider checked a second factor", func(mt *mtest.T) {
+		r := &Resolver{DB: mt.DB}
+		withMFA := *identity
+		withMFA.MFA = true
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "db.users", mtest.FirstBatch, bson.D{{Key: "n", Value: 1}}),
+			mtest.CreateSuccessResponse(bson.E{Key: "value", Value: user}),
+		)
+		result, err := r.LoginWithOIDC(&withMFA, nil, false)
+		if err != nil {
+			mt.Fatal(err)
+		}
+		if result.TwoFactorRequired {
+			mt.Fatal("expected a token when the provider checked a second factor")
+		}
+	})
+}
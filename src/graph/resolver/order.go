@@ -0,0 +1,149 @@
+package resolver
+
+import (
+	"book-store/graph/model"
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func (r *Resolver) findAddress(userID string, id string) (*model.Address, error) {
+	addressOID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	var address *model.Address
+	filter := bson.M{"_id": addressOID, "userId": userID}
+	err = r.DB.Collection("addresses").FindOne(context.Background(), filter).Decode(&address)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("Address %v doesn't exist", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return address, nil
+}
+
+// findDefaultAddress returns the address flagged with field (defaultShipping or defaultBilling), or nil.
+func (r *Resolver) findDefaultAddress(userID string, field string) (*model.Address, error) {
+	var address *model.Address
+	filter := bson.M{"userId": userID, field: true}
+	err := r.DB.Collection("addresses").FindOne(context.Background(), filter).Decode(&address)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return address, nil
+}
+
+// clearDefaultAddress removes the default flag field from the other addresses of a user.
+func (r *Resolver) clearDefaultAddress(userID string, field string, exceptID primitive.ObjectID) error {
+	filter := bson.M{"userId": userID, field: true, "_id": bson.M{"$ne": exceptID}}
+	update := bson.M{"$set": bson.M{field: false}}
+	_, err := r.DB.Collection("addresses").UpdateMany(context.Background(), filter, update)
+	return err
+}
+
+// cartBooks returns the books of the items of a cart by id, read with a single query.
+func (r *Resolver) cartBooks(cart *model.Cart) (map[string]*model.Book, error) {
+	if len(cart.Items) == 0 {
+		return map[string]*model.Book{}, nil
+	}
+	var booksId []primitive.ObjectID
+	for _, item := range cart.Items {
+		bookOID, err := primitive.ObjectIDFromHex(item.BookID)
+		if err != nil {
+			return nil, err
+		}
+		booksId = append(booksId, bookOID)
+	}
+	cs, err := r.DB.Collection("books").Find(context.Background(), bson.M{"_id": bson.M{"$in": booksId}})
+	if err != nil {
+		return nil, err
+	}
+	var books []*model.Book
+	defer cs.Close(context.Background())
+	err = cs.All(context.Background(), &books)
+	if err != nil {
+		return nil, err
+	}
+	booksById := map[string]*model.Book{}
+	for _, book := range books {
+		booksById[book.ID] = book
+	}
+	return booksById, nil
+}
+
+// orderItems snapshots the name and price of the books or book variants in a cart, so that
+// later changes to the catalog don't change placed orders. The prices are in the catalog currency.
+func (r *Resolver) orderItems(cart *model.Cart) ([]*model.OrderItem, error) {
+	booksById, err := r.cartBooks(cart)
+	if err != nil {
+		return nil, err
+	}
+	return cartOrderItemsOf(cart, booksById)
+}
+
+// cartOrderItemsOf returns the items of a cart priced with the books of booksById.
+func cartOrderItemsOf(cart *model.Cart, booksById map[string]*model.Book) ([]*model.OrderItem, error) {
+	items := []*model.OrderItem{}
+	for _, item := range cart.Items {
+		if item.Quantity <= 0 {
+			continue
+		}
+		book, ok := booksById[item.BookID]
+		if !ok {
+			return nil, fmt.Errorf("Book %v doesn't exist", item.BookID)
+		}
+		orderItem := &model.OrderItem{
+			BookID:   book.ID,
+			Format:   book.Format,
+			Name:     book.Name,
+			Price:    book.Price,
+			Quantity: item.Quantity,
+		}
+		if len(book.Variants) > 0 {
+			if item.VariantID == nil {
+				return nil, fmt.Errorf("Please choose a format of %v", book.Name)
+			}
+			variant := book.Variant(*item.VariantID)
+			if variant == nil {
+				return nil, fmt.Errorf("Variant %v doesn't exist", *item.VariantID)
+			}
+			orderItem.VariantID = &variant.ID
+			orderItem.Sku = &variant.Sku
+			orderItem.Format = &variant.Format
+			orderItem.Price = variant.Price
+			orderItem.Weight = variant.Weight
+		}
+		items = append(items, orderItem)
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("Cart is empty")
+	}
+	return items, nil
+}
+
+func (r *Resolver) findOrder(auth *Auth, id string) (*model.Order, error) {
+	orderOID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	var order *model.Order
+	err = r.DB.Collection("orders").FindOne(context.Background(), bson.M{"_id": orderOID}).Decode(&order)
+	if err != nil {
+		return nil, err
+	}
+	if order.UserID != auth.UID && !auth.HasPermission(model.PermissionOrdersManage) {
+		return nil, fmt.Errorf("Access denied")
+	}
+	return order, nil
+}
+
+var ordersSort = options.Find().SetSort(bson.M{"created": -1})
@@ -0,0 +1,57 @@
+package resolver
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+
+import (
+	"book-store/graph/generated"
+	"book-store/graph/model"
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func (r *orderResolver) InvoiceURL(ctx context.Context, obj *model.Order) (*string, error) {
+	inv, err := r.findInvoice(obj.ID)
+	if err != nil {
+		return nil, err
+	}
+	// an invoice which failed to be issued when the order was paid is issued now
+	if inv == nil && obj.Status != model.OrderStatusPending && obj.Status != model.OrderStatusCancelled {
+		inv, err = r.issueInvoice(obj)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if inv == nil {
+		return nil, nil
+	}
+	url, err := r.invoicePDF(inv)
+	if err != nil {
+		return nil, err
+	}
+	return &url, nil
+}
+
+func (r *orderResolver) CreditNotes(ctx context.Context, obj *model.Order) ([]*model.Invoice, error) {
+	notes := []*model.Invoice{}
+	err := findAll(r.DB, "invoices", bson.M{"orderId": obj.ID, "kind": model.InvoiceKindCreditNote}, &notes)
+	if err != nil {
+		return nil, err
+	}
+	return notes, nil
+}
+
+func (r *orderResolver) Returns(ctx context.Context, obj *model.Order) ([]*model.Return, error) {
+	returns := []*model.Return{}
+	err := findAll(r.DB, "returns", bson.M{"orderId": obj.ID}, &returns)
+	if err != nil {
+		return nil, err
+	}
+	return returns, nil
+}
+
+// Order returns generated.OrderResolver implementation.
+func (r *Resolver) Order() generated.OrderResolver { return &orderResolver{r} }
+
+type orderResolver struct{ *Resolver }
@@ -0,0 +1,81 @@
+package resolver
+
+import (
+	"book-store/graph/model"
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (r *Resolver) findStaffRoles(ids []string) ([]*model.StaffRole, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var rolesId []primitive.ObjectID
+	for _, id := range ids {
+		objId, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return nil, err
+		}
+		rolesId = append(rolesId, objId)
+	}
+	filter := bson.M{"_id": bson.M{"$in": rolesId}}
+	cs, err := r.DB.Collection("staff-roles").Find(context.Background(), filter)
+	if err != nil {
+		return nil, err
+	}
+	var roles []*model.StaffRole
+	defer cs.Close(context.Background())
+	err = cs.All(context.Background(), &roles)
+	if err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+// permissionsOf returns the permissions granted to a user, an ADMIN is granted every permission
+// while other users get the union of the permissions of their staff roles.
+func (r *Resolver) permissionsOf(user *model.User) ([]model.Permission, error) {
+	if user.Role == model.RoleAdmin {
+		return model.AllPermission, nil
+	}
+	roles, err := r.findStaffRoles(user.StaffRolesID)
+	if err != nil {
+		return nil, err
+	}
+	permissions := []model.Permission{}
+	granted := map[model.Permission]bool{}
+	for _, role := range roles {
+		for _, permission := range role.Permissions {
+			if !granted[permission] {
+				granted[permission] = true
+				permissions = append(permissions, permission)
+			}
+		}
+	}
+	return permissions, nil
+}
+
+func (auth *Auth) HasPermission(permission model.Permission) bool {
+	if auth.Role == model.RoleAdmin.String() {
+		return true
+	}
+	for _, granted := range auth.Permissions {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// checkGrantable makes sure a user only grants permissions they hold themselves.
+func checkGrantable(auth *Auth, permissions []model.Permission) error {
+	for _, permission := range permissions {
+		if !auth.HasPermission(permission) {
+			return fmt.Errorf("You can't grant the %v permission, you don't hold it", permission)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+package resolver
+
+import (
+	"book-store/graph/model"
+	"testing"
+)
+
+func TestCheckGrantable(t *testing.T) {
+	manager := &Auth{Role: model.RoleClient.String(), Permissions: []model.Permission{model.PermissionUsersManage, model.PermissionOrdersManage}}
+	if err := checkGrantable(manager, []model.Permission{model.PermissionOrdersManage}); err != nil {
+		t.Fatalf("expected a held permission to be grantable: %v", err)
+	}
+	if err := checkGrantable(manager, []model.Permission{model.PermissionOrdersManage, model.PermissionCatalogWrite}); err == nil {
+		t.Fatal("expected a permission which isn't held to be refused")
+	}
+	admin := &Auth{Role: model.RoleAdmin.String()}
+	if err := checkGrantable(admin, model.AllPermission); err != nil {
+		t.Fatalf("expected an admin to grant every permission: %v", err)
+	}
+}
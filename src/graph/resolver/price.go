@@ -0,0 +1,258 @@
+package resolver
+
+import (
+	"book-store/graph/model"
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// priceChanger returns who changes a price, the user or else the API key of a machine client.
+func priceChanger(auth *Auth) (*string, *string) {
+	if auth.APIKeyID != "" {
+		return nil, &auth.APIKeyID
+	}
+	return &auth.UID, nil
+}
+
+// recordPriceChange adds a change to the price history, a change to the same price is skipped.
+func (r *Resolver) recordPriceChange(change *model.PriceChange) error {
+	if change.OldPrice != nil && *change.OldPrice == change.NewPrice {
+		return nil
+	}
+	change.Created = time.Now().Unix()
+	changeData := bson.M{
+		"bookId":     change.BookID,
+		"variantId":  change.VariantID,
+		"oldPrice":   change.OldPrice,
+		"newPrice":   change.NewPrice,
+		"reason":     change.Reason,
+		"userId":     change.UserID,
+		"apiKeyId":   change.APIKeyID,
+		"scheduleId": change.ScheduleID,
+		"created":    change.Created,
+	}
+	_, err := r.DB.Collection("price-changes").InsertOne(context.Background(), changeData)
+	return err
+}
+
+// currentPrice returns the price of a book, or of one of its variants when variantID is set.
+func (r *Resolver) currentPrice(bookID string, variantID *string) (model.Money, error) {
+	bookOID, err := primitive.ObjectIDFromHex(bookID)
+	if err != nil {
+		return model.Money{}, err
+	}
+	var book *model.Book
+	err = r.DB.Collection("books").FindOne(context.Background(), bson.M{"_id": bookOID}).Decode(&book)
+	if err == mongo.ErrNoDocuments {
+		return model.Money{}, fmt.Errorf("Book %v doesn't exist", bookID)
+	}
+	if err != nil {
+		return model.Money{}, err
+	}
+	if variantID == nil {
+		return book.Price, nil
+	}
+	variant := book.Variant(*variantID)
+	if variant == nil {
+		return model.Money{}, fmt.Errorf("Variant %v doesn't exist", *variantID)
+	}
+	return variant.Price, nil
+}
+
+// setPrice changes the price of a book or of one of its variants, when expected is set the
+// price is only changed if it still is the expected price. It returns whether the price changed.
+func (r *Resolver) setPrice(bookID string, variantID *string, price model.Money, expected *model.Money) (bool, error) {
+	bookOID, err := primitive.ObjectIDFromHex(bookID)
+	if err != nil {
+		return false, err
+	}
+	filter := bson.M{"_id": bookOID}
+	set := bson.M{"price": price, "updated": time.Now().Unix()}
+	if expected != nil {
+		filter["price"] = *expected
+	}
+	if variantID != nil {
+		variantOID, err := primitive.ObjectIDFromHex(*variantID)
+		if err != nil {
+			return false, err
+		}
+		variantFilter := bson.M{"_id": variantOID}
+		if expected != nil {
+			variantFilter["price"] = *expected
+		}
+		filter = bson.M{"_id": bookOID, "variants": bson.M{"$elemMatch": variantFilter}}
+		set = bson.M{"variants.$.price": price, "updated": time.Now().Unix()}
+	}
+	result, err := r.DB.Collection("books").UpdateOne(context.Background(), filter, bson.M{"$set": set})
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// priceScheduleFilter finds the schedules of a book or variant which overlap [startsAt, endsAt).
+func priceScheduleFilter(bookID string, variantID *string, startsAt int64, endsAt *int64) bson.M {
+	filter := bson.M{
+		"bookId":    bookID,
+		"variantId": variantID,
+		"status":    bson.M{"$in": bson.A{model.PriceScheduleStatusScheduled, model.PriceScheduleStatusActive}},
+		"$or":       bson.A{bson.M{"endsAt": nil}, bson.M{"endsAt": bson.M{"$gt": startsAt}}},
+	}
+	if endsAt != nil {
+		filter["startsAt"] = bson.M{"$lt": *endsAt}
+	}
+	return filter
+}
+
+// PriceScheduleLockTimeout is how long scheduling a price may lock the book.
+const PriceScheduleLockTimeout = 30 * time.Second
+
+// lockPriceSchedules locks the schedules of a book while a new one is checked and inserted, it
+// returns false if they're locked already. A lock which wasn't released expires after a timeout.
+func (r *Resolver) lockPriceSchedules(bookID string, now int64) (bool, error) {
+	bookOID, err := primitive.ObjectIDFromHex(bookID)
+	if err != nil {
+		return false, err
+	}
+	filter := bson.M{"_id": bookOID, "priceScheduleLock": bson.M{"$not": bson.M{"$gt": now}}}
+	update := bson.M{"$set": bson.M{"priceScheduleLock": now + int64(PriceScheduleLockTimeout.Seconds())}}
+	result, err := r.DB.Collection("books").UpdateOne(context.Background(), filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+// unlockPriceSchedules releases the lock taken at now, unless it expired and was taken over.
+func (r *Resolver) unlockPriceSchedules(bookID string, now int64) error {
+	bookOID, err := primitive.ObjectIDFromHex(bookID)
+	if err != nil {
+		return err
+	}
+	filter := bson.M{"_id": bookOID, "priceScheduleLock": now + int64(PriceScheduleLockTimeout.Seconds())}
+	_, err = r.DB.Collection("books").UpdateOne(context.Background(), filter, bson.M{"$unset": bson.M{"priceScheduleLock": ""}})
+	return err
+}
+
+// startPriceSchedule applies a scheduled price, the status is changed first so that
+// a schedule is applied once even with several schedulers running.
+func (r *Resolver) startPriceSchedule(scheduleOID primitive.ObjectID, now int64) error {
+	var schedule *model.PriceSchedule
+	filter := bson.M{"_id": scheduleOID, "status": model.PriceScheduleStatusScheduled}
+	update := bson.M{"$set": bson.M{"status": model.PriceScheduleStatusActive, "updated": now}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := r.DB.Collection("price-schedules").FindOneAndUpdate(context.Background(), filter, update, opts).Decode(&schedule)
+	if err == mongo.ErrNoDocuments {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	previous, err := r.currentPrice(schedule.BookID, schedule.VariantID)
+	if err != nil {
+		return err
+	}
+	_, err = r.DB.Collection("price-schedules").UpdateOne(context.Background(), bson.M{"_id": scheduleOID}, bson.M{"$set": bson.M{"previousPrice": previous}})
+	if err != nil {
+		return err
+	}
+	changed, err := r.setPrice(schedule.BookID, schedule.VariantID, schedule.Price, &previous)
+	if err != nil || !changed {
+		return err
+	}
+	return r.recordPriceChange(&model.PriceChange{
+		BookID:     schedule.BookID,
+		VariantID:  schedule.VariantID,
+		OldPrice:   &previous,
+		NewPrice:   schedule.Price,
+		Reason:     model.PriceChangeReasonScheduleStart,
+		UserID:     schedule.UserID,
+		APIKeyID:   schedule.APIKeyID,
+		ScheduleID: &schedule.ID,
+	})
+}
+
+// endPriceSchedule reverts the price of an active schedule to the previous price, unless
+// the price was changed since the schedule started.
+func (r *Resolver) endPriceSchedule(scheduleOID primitive.ObjectID, status model.PriceScheduleStatus, now int64) (*model.PriceSchedule, error) {
+	var schedule *model.PriceSchedule
+	filter := bson.M{"_id": scheduleOID, "status": model.PriceScheduleStatusActive}
+	update := bson.M{"$set": bson.M{"status": status, "updated": now}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := r.DB.Collection("price-schedules").FindOneAndUpdate(context.Background(), filter, update, opts).Decode(&schedule)
+	if err != nil {
+		return nil, err
+	}
+	if schedule.PreviousPrice == nil {
+		return schedule, nil
+	}
+	changed, err := r.setPrice(schedule.BookID, schedule.VariantID, *schedule.PreviousPrice, &schedule.Price)
+	if err != nil || !changed {
+		return schedule, err
+	}
+	return schedule, r.recordPriceChange(&model.PriceChange{
+		BookID:     schedule.BookID,
+		VariantID:  schedule.VariantID,
+		OldPrice:   &schedule.Price,
+		NewPrice:   *schedule.PreviousPrice,
+		Reason:     model.PriceChangeReasonScheduleEnd,
+		UserID:     schedule.UserID,
+		APIKeyID:   schedule.APIKeyID,
+		ScheduleID: &schedule.ID,
+	})
+}
+
+// applyPriceSchedules ends the active schedules past their end and starts the schedules due.
+func (r *Resolver) applyPriceSchedules(now int64) error {
+	var ending []*model.PriceSchedule
+	filter := bson.M{"status": model.PriceScheduleStatusActive, "endsAt": bson.M{"$lte": now}}
+	if err := findAll(r.DB, "price-schedules", filter, &ending); err != nil {
+		return err
+	}
+	for _, schedule := range ending {
+		scheduleOID, _ := primitive.ObjectIDFromHex(schedule.ID)
+		_, err := r.endPriceSchedule(scheduleOID, model.PriceScheduleStatusCompleted, now)
+		if err != nil && err != mongo.ErrNoDocuments {
+			return err
+		}
+	}
+	var starting []*model.PriceSchedule
+	filter = bson.M{"status": model.PriceScheduleStatusScheduled, "startsAt": bson.M{"$lte": now}}
+	if err := findAll(r.DB, "price-schedules", filter, &starting); err != nil {
+		return err
+	}
+	for _, schedule := range starting {
+		scheduleOID, _ := primitive.ObjectIDFromHex(schedule.ID)
+		if schedule.EndsAt != nil && *schedule.EndsAt <= now {
+			// the schedule was missed entirely, e.g. while the server was down
+			update := bson.M{"$set": bson.M{"status": model.PriceScheduleStatusCompleted, "updated": now}}
+			_, err := r.DB.Collection("price-schedules").UpdateOne(context.Background(), bson.M{"_id": scheduleOID, "status": model.PriceScheduleStatusScheduled}, update)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if err := r.startPriceSchedule(scheduleOID, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RunPriceScheduler applies the price schedules every interval, it never returns.
+func (r *Resolver) RunPriceScheduler(interval time.Duration) {
+	for {
+		err := r.applyPriceSchedules(time.Now().Unix())
+		if err != nil {
+			log.Printf("Error when applying price schedules: %v", err.Error())
+		}
+		time.Sleep(interval)
+	}
+}
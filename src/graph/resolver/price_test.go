@@ -0,0 +1,163 @@
+package resolver
+
+import (
+	"book-store/graph/model"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestPriceChanger(t *testing.T) {
+	userID, apiKeyID := priceChanger(&Auth{UID: "u"})
+	if userID == nil || *userID != "u" || apiKeyID != nil {
+		t.Fatalf("expected a user to change the price, got %v %v", userID, apiKeyID)
+	}
+	userID, apiKeyID = priceChanger(&Auth{APIKeyID: "k"})
+	if userID != nil || apiKeyID == nil || *apiKeyID != "k" {
+		t.Fatalf("expected an API key to change the price, got %v %v", userID, apiKeyID)
+	}
+}
+
+// commands returns the commands sent to the mock deployment, by name.
+func commands(mt *mtest.T) map[string][]bson.Raw {
+	sent := map[string][]bson.Raw{}
+	for event := mt.GetStartedEvent(); event != nil; event = mt.GetStartedEvent() {
+		sent[event.CommandName] = append(sent[event.CommandName], event.Command)
+	}
+	return sent
+}
+
+func updated(n int) bson.D {
+	return mtest.CreateSuccessResponse(bson.E{Key: "n", Value: n}, bson.E{Key: "nModified", Value: n})
+}
+
+func TestPriceSchedules(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+	bookID, variantID, scheduleID := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	regular := model.Money{Amount: 2000, Currency: "EUR"}
+	sale := model.Money{Amount: 1500, Currency: "EUR"}
+	schedule := func(status model.PriceScheduleStatus, extra ...bson.E) bson.D {
+		return append(bson.D{
+			{Key: "_id", Value: scheduleID},
+			{Key: "bookId", Value: bookID.Hex()},
+			{Key: "price", Value: sale},
+			{Key: "startsAt", Value: int64(100)},
+			{Key: "status", Value: status},
+			{Key: "userId", Value: "u"},
+		}, extra...)
+	}
+	book := bson.D{{Key: "_id", Value: bookID}, {Key: "name", Value: "A"}, {Key: "price", Value: regular}}
+
+	mt.Run("set the price of a variant", func(mt *mtest.T) {
+		r := &Resolver{DB: mt.DB}
+		variant := variantID.Hex()
+		mt.AddMockResponses(updated(1), updated(0))
+		changed, err := r.setPrice(bookID.Hex(), &variant, sale, &regular)
+		if err != nil || !changed {
+			mt.Fatalf("expected the price to change, got %v", err)
+		}
+		// the price was changed meanwhile
+		changed, err = r.setPrice(bookID.Hex(), &variant, sale, &regular)
+		if err != nil || changed {
+			mt.Fatalf("expected the price not to change, got %v", err)
+		}
+		update := commands(mt)["update"][0].Lookup("updates").Array().Index(0).Value().Document()
+		match := update.Lookup("q", "variants", "$elemMatch").Document()
+		if match.Lookup("_id").ObjectID() != variantID || match.Lookup("price").Type != bson.TypeEmbeddedDocument {
+			mt.Fatalf("expected the variant and its expected price to be matched, got %v", match)
+		}
+		if _, ok := update.Lookup("u", "$set").Document().Lookup("variants.$.price").DocumentOK(); !ok {
+			mt.Fatalf("expected the price of the variant to be set, got %v", update)
+		}
+	})
+
+	mt.Run("start a schedule", func(mt *mtest.T) {
+		r := &Resolver{DB: mt.DB}
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(bson.E{Key: "value", Value: schedule(model.PriceScheduleStatusActive)}),
+			mtest.CreateCursorResponse(0, "db.books", mtest.FirstBatch, book),
+			updated(1), // previousPrice
+			updated(1), // the price
+			mtest.CreateSuccessResponse(),
+		)
+		err := r.startPriceSchedule(scheduleID, 100)
+		if err != nil {
+			mt.Fatal(err)
+		}
+		sent := commands(mt)
+		if len(sent["update"]) != 2 || len(sent["insert"]) != 1 {
+			mt.Fatalf("expected the price to be set and recorded, got %v", sent)
+		}
+		change := sent["insert"][0].Lookup("documents").Array().Index(0).Value().Document()
+		if change.Lookup("reason").StringValue() != string(model.PriceChangeReasonScheduleStart) || change.Lookup("scheduleId").StringValue() != scheduleID.Hex() {
+			mt.Fatalf("expected the start of the schedule to be recorded, got %v", change)
+		}
+	})
+
+	mt.Run("start a schedule started already", func(mt *mtest.T) {
+		r := &Resolver{DB: mt.DB}
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "value", Value: nil}))
+		err := r.startPriceSchedule(scheduleID, 100)
+		if err != nil {
+			mt.Fatal(err)
+		}
+		if sent := commands(mt); len(sent) != 1 {
+			mt.Fatalf("expected the schedule to be applied once, got %v", sent)
+		}
+	})
+
+	mt.Run("end a schedule", func(mt *mtest.T) {
+		r := &Resolver{DB: mt.DB}
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(bson.E{Key: "value", Value: schedule(model.PriceScheduleStatusCompleted, bson.E{Key: "previousPrice", Value: regular})}),
+			updated(1),
+			mtest.CreateSuccessResponse(),
+		)
+		ended, err := r.endPriceSchedule(scheduleID, model.PriceScheduleStatusCompleted, 200)
+		if err != nil {
+			mt.Fatal(err)
+		}
+		if ended.Status != model.PriceScheduleStatusCompleted || len(commands(mt)["insert"]) != 1 {
+			mt.Fatalf("expected the previous price to be restored and recorded, got %+v", ended)
+		}
+	})
+
+	mt.Run("end a schedule whose price was changed", func(mt *mtest.T) {
+		r := &Resolver{DB: mt.DB}
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(bson.E{Key: "value", Value: schedule(model.PriceScheduleStatusCancelled, bson.E{Key: "previousPrice", Value: regular})}),
+			updated(0),
+		)
+		_, err := r.endPriceSchedule(scheduleID, model.PriceScheduleStatusCancelled, 200)
+		if err != nil {
+			mt.Fatal(err)
+		}
+		if len(commands(mt)["insert"]) != 0 {
+			mt.Fatal("expected a price changed by hand to be kept")
+		}
+	})
+
+	mt.Run("apply a missed schedule", func(mt *mtest.T) {
+		r := &Resolver{DB: mt.DB}
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "db.price-schedules", mtest.FirstBatch),
+			mtest.CreateCursorResponse(0, "db.price-schedules", mtest.FirstBatch, schedule(model.PriceScheduleStatusScheduled, bson.E{Key: "endsAt", Value: int64(150)})),
+			updated(1),
+		)
+		err := r.applyPriceSchedules(200)
+		if err != nil {
+			mt.Fatal(err)
+		}
+		sent := commands(mt)
+		if len(sent["findAndModify"]) != 0 || len(sent["update"]) != 1 {
+			mt.Fatalf("expected a missed schedule to be completed without changing the price, got %v", sent)
+		}
+		set := sent["update"][0].Lookup("updates").Array().Index(0).Value().Document().Lookup("u", "$set").Document()
+		if set.Lookup("status").StringValue() != string(model.PriceScheduleStatusCompleted) {
+			mt.Fatalf("expected the schedule to be completed, got %v", set)
+		}
+	})
+}
@@ -0,0 +1,147 @@
+package resolver
+
+import (
+	"book-store/graph/model"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const deletedUserName = "Deleted user"
+
+// subjectUserID returns the user targeted by a data-subject request, only users with the
+// USERS_MANAGE permission can handle the request of another user.
+func subjectUserID(auth *Auth, userID *string) (string, error) {
+	if userID == nil || *userID == auth.UID {
+		return auth.UID, nil
+	}
+	if !auth.HasPermission(model.PermissionUsersManage) {
+		return "", fmt.Errorf("Access denied")
+	}
+	return *userID, nil
+}
+
+func (r *Resolver) recordDataRequest(requestType string, userID string, auth *Auth) error {
+	requestData := bson.M{
+		"type":        requestType,
+		"userId":      userID,
+		"requestedBy": auth.UID,
+		"created":     time.Now().Unix(),
+	}
+	_, err := r.DB.Collection("data-requests").InsertOne(context.Background(), requestData)
+	return err
+}
+
+func findAll(db *mongo.Database, collection string, filter bson.M, results interface{}) error {
+	cs, err := db.Collection(collection).Find(context.Background(), filter)
+	if err != nil {
+		return err
+	}
+	defer cs.Close(context.Background())
+	return cs.All(context.Background(), results)
+}
+
+// exportUserData collects everything tied to the ID of a user into a JSON document.
+func (r *Resolver) exportUserData(user *model.User) (string, error) {
+	addresses := []*model.Address{}
+	if err := findAll(r.DB, "addresses", bson.M{"userId": user.ID}, &addresses); err != nil {
+		return "", err
+	}
+	carts := []*model.Cart{}
+	if err := findAll(r.DB, "carts", bson.M{"userId": user.ID}, &carts); err != nil {
+		return "", err
+	}
+	wishLists := []*model.WishList{}
+	if err := findAll(r.DB, "wish-lists", bson.M{"userId": user.ID}, &wishLists); err != nil {
+		return "", err
+	}
+	reviews := []*model.Review{}
+	if err := findAll(r.DB, "reviews", bson.M{"userId": user.ID}, &reviews); err != nil {
+		return "", err
+	}
+	orders := []*model.Order{}
+	if err := findAll(r.DB, "orders", bson.M{"userId": user.ID}, &orders); err != nil {
+		return "", err
+	}
+	returns := []*model.Return{}
+	if err := findAll(r.DB, "returns", bson.M{"userId": user.ID}, &returns); err != nil {
+		return "", err
+	}
+	invoices := []*model.Invoice{}
+	if err := findAll(r.DB, "invoices", bson.M{"userId": user.ID}, &invoices); err != nil {
+		return "", err
+	}
+	apiKeys := []*model.APIKey{}
+	if err := findAll(r.DB, "api-keys", bson.M{"createdBy": user.ID}, &apiKeys); err != nil {
+		return "", err
+	}
+	data, err := json.MarshalIndent(map[string]interface{}{
+		"profile":   user,
+		"addresses": addresses,
+		"carts":     carts,
+		"wishLists": wishLists,
+		"reviews":   reviews,
+		"orders":    orders,
+		"returns":   returns,
+		"invoices":  invoices,
+		"apiKeys":   apiKeys,
+	}, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// deleteUserData anonymizes reviews, removes carts, wish lists, addresses and API keys, keeps orders
+// with their personal fields scrubbed and returns unlinked, and finally removes the user.
+// Invoices and credit notes are legal records which have to be kept as issued for the retention
+// period of the tax law, so they're only unlinked from the user and their PDFs are kept.
+func (r *Resolver) deleteUserData(userOID primitive.ObjectID) error {
+	userID := userOID.Hex()
+	filter := bson.M{"userId": userID}
+	update := bson.M{"$set": bson.M{"userId": "", "anonymized": true}}
+	if _, err := r.DB.Collection("reviews").UpdateMany(context.Background(), filter, update); err != nil {
+		return err
+	}
+	for _, collection := range []string{"carts", "wish-lists", "addresses"} {
+		if _, err := r.DB.Collection(collection).DeleteMany(context.Background(), filter); err != nil {
+			return err
+		}
+	}
+	if _, err := r.DB.Collection("api-keys").DeleteMany(context.Background(), bson.M{"createdBy": userID}); err != nil {
+		return err
+	}
+	for _, collection := range []string{"returns", "invoices"} {
+		if _, err := r.DB.Collection(collection).UpdateMany(context.Background(), filter, update); err != nil {
+			return err
+		}
+	}
+	// the country and postal code are kept for accounting and taxes
+	update = bson.M{
+		"$set": bson.M{
+			"userId":                "",
+			"anonymized":            true,
+			"shippingAddress.name":  deletedUserName,
+			"shippingAddress.line1": "",
+			"shippingAddress.line2": nil,
+			"shippingAddress.city":  "",
+			"shippingAddress.phone": nil,
+			"billingAddress.name":   deletedUserName,
+			"billingAddress.line1":  "",
+			"billingAddress.line2":  nil,
+			"billingAddress.city":   "",
+			"billingAddress.phone":  nil,
+			"updated":               time.Now().Unix(),
+		},
+	}
+	if _, err := r.DB.Collection("orders").UpdateMany(context.Background(), filter, update); err != nil {
+		return err
+	}
+	_, err := r.DB.Collection("users").DeleteOne(context.Background(), bson.M{"_id": userOID})
+	return err
+}
@@ -0,0 +1,26 @@
+package resolver
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+
+import (
+	"book-store/graph/generated"
+	"book-store/graph/model"
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func (r *publisherResolver) Books(ctx context.Context, obj *model.Publisher) ([]*model.Book, error) {
+	var books []*model.Book
+	err := findAll(r.DB, "books", bson.M{"publisherId": obj.ID}, &books)
+	if err != nil {
+		return nil, err
+	}
+	return books, nil
+}
+
+// Publisher returns generated.PublisherResolver implementation.
+func (r *Resolver) Publisher() generated.PublisherResolver { return &publisherResolver{r} }
+
+type publisherResolver struct{ *Resolver }
@@ -8,25 +8,139 @@ import (
 	"book-store/graph/model"
 	"context"
 	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
-func (r *queryResolver) Login(ctx context.Context, input *model.Login) (string, error) {
+func (r *queryResolver) Login(ctx context.Context, input *model.Login) (*model.LoginResult, error) {
 	var user model.User
 	err := r.DB.Collection("users").FindOne(context.Background(), bson.M{"email": input.Email}).Decode(&user)
 	if err != nil {
-		return "", fmt.Errorf("Email %v doesn't exist", input.Email)
+		return nil, fmt.Errorf("Email %v doesn't exist", input.Email)
 	}
 	if !user.CheckPassword(input.Password) {
-		return "", fmt.Errorf("Incorrect password")
+		return nil, fmt.Errorf("Incorrect password")
 	}
-	tokenString, err := user.CreateJWT()
+	if user.Disabled {
+		return nil, fmt.Errorf("Account is disabled")
+	}
+	if user.TwoFactorEnabled {
+		challenge, err := user.CreateTwoFactorChallenge()
+		if err != nil {
+			return nil, err
+		}
+		return &model.LoginResult{Token: challenge, TwoFactorRequired: true}, nil
+	}
+	permissions, err := r.permissionsOf(&user)
+	if err != nil {
+		return nil, err
+	}
+	tokenString, err := user.CreateJWT(false, permissions)
+	if err != nil {
+		return nil, err
+	}
+	return &model.LoginResult{Token: tokenString, TwoFactorRequired: false}, nil
+}
+
+func (r *queryResolver) LoginTwoFactor(ctx context.Context, input model.TwoFactorLogin) (string, error) {
+	claims, err := parseToken(input.Challenge)
+	if err != nil {
+		return "", err
+	}
+	if claims["challenge"] != "2fa" {
+		return "", fmt.Errorf("Invalid challenge")
+	}
+	uid, _ := claims["uid"].(string)
+	user, err := r.findUserTwoFactor(uid)
 	if err != nil {
 		return "", err
 	}
-	return tokenString, nil
+	if !user.TwoFactorEnabled {
+		return "", fmt.Errorf("Two-factor authentication is not enabled")
+	}
+	if user.Disabled {
+		return "", fmt.Errorf("Account is disabled")
+	}
+	err = r.verifyTwoFactorCode(user, input.Code)
+	if err != nil {
+		return "", err
+	}
+	permissions, err := r.permissionsOf(&user.User)
+	if err != nil {
+		return "", err
+	}
+	return user.CreateJWT(true, permissions)
+}
+
+func (r *queryResolver) Me(ctx context.Context) (*model.User, error) {
+	auth, err := GetAuthFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	userOID, err := primitive.ObjectIDFromHex(auth.UID)
+	if err != nil {
+		return nil, err
+	}
+	var user *model.User
+	err = r.DB.Collection("users").FindOne(context.Background(), bson.M{"_id": userOID}).Decode(&user)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (r *queryResolver) Users(ctx context.Context, filter *model.UserFilter, pagination *model.Pagination) ([]*model.User, error) {
+	query := bson.M{}
+	if filter != nil {
+		if filter.Search != nil && *filter.Search != "" {
+			pattern := primitive.Regex{Pattern: regexp.QuoteMeta(*filter.Search), Options: "i"}
+			query["$or"] = bson.A{bson.M{"name": pattern}, bson.M{"email": pattern}}
+		}
+		if filter.Role != nil {
+			query["role"] = *filter.Role
+		}
+		if filter.Disabled != nil {
+			if *filter.Disabled {
+				query["disabled"] = true
+			} else {
+				query["disabled"] = bson.M{"$ne": true}
+			}
+		}
+	}
+	opts := paginationOptions(pagination).SetSort(bson.M{"created": 1})
+	cs, err := r.DB.Collection("users").Find(context.Background(), query, opts)
+	if err != nil {
+		return nil, err
+	}
+	var users []*model.User
+	defer cs.Close(context.Background())
+	err = cs.All(context.Background(), &users)
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (r *queryResolver) User(ctx context.Context, id string) (*model.User, error) {
+	userOID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	var user *model.User
+	err = r.DB.Collection("users").FindOne(context.Background(), bson.M{"_id": userOID}).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
 }
 
 func (r *queryResolver) Authors(ctx context.Context) ([]*model.Author, error) {
@@ -71,6 +185,72 @@ func (r *queryResolver) Books(ctx context.Context) ([]*model.Book, error) {
 	return books, nil
 }
 
+func (r *queryResolver) BookByIsbn(ctx context.Context, isbn string) (*model.Book, error) {
+	normalized, err := model.NormalizeISBN(isbn)
+	if err != nil {
+		return nil, err
+	}
+	var book *model.Book
+	err = r.DB.Collection("books").FindOne(context.Background(), bson.M{"isbn": normalized}).Decode(&book)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return book, nil
+}
+
+func (r *queryResolver) Publishers(ctx context.Context) ([]*model.Publisher, error) {
+	var publishers []*model.Publisher
+	err := findAll(r.DB, "publishers", bson.M{}, &publishers)
+	if err != nil {
+		return nil, err
+	}
+	return publishers, nil
+}
+
+func (r *queryResolver) Publisher(ctx context.Context, id string) (*model.Publisher, error) {
+	publisherOID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	var publisher *model.Publisher
+	err = r.DB.Collection("publishers").FindOne(context.Background(), bson.M{"_id": publisherOID}).Decode(&publisher)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return publisher, nil
+}
+
+func (r *queryResolver) SeriesList(ctx context.Context) ([]*model.Series, error) {
+	var seriesList []*model.Series
+	err := findAll(r.DB, "series", bson.M{}, &seriesList)
+	if err != nil {
+		return nil, err
+	}
+	return seriesList, nil
+}
+
+func (r *queryResolver) Series(ctx context.Context, id string) (*model.Series, error) {
+	seriesOID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	var series *model.Series
+	err = r.DB.Collection("series").FindOne(context.Background(), bson.M{"_id": seriesOID}).Decode(&series)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return series, nil
+}
+
 func (r *queryResolver) Cart(ctx context.Context) (*model.Cart, error) {
 	auth, err := GetAuthFromContext(ctx)
 	if err != nil {
@@ -105,17 +285,238 @@ func (r *queryResolver) WishList(ctx context.Context) (*model.WishList, error) {
 	return wishList, nil
 }
 
+func (r *queryResolver) Addresses(ctx context.Context) ([]*model.Address, error) {
+	auth, err := GetAuthFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cs, err := r.DB.Collection("addresses").Find(context.Background(), bson.M{"userId": auth.UID})
+	if err != nil {
+		return nil, err
+	}
+	var addresses []*model.Address
+	defer cs.Close(context.Background())
+	err = cs.All(context.Background(), &addresses)
+	if err != nil {
+		return nil, err
+	}
+	return addresses, nil
+}
+
+func (r *queryResolver) Orders(ctx context.Context) ([]*model.Order, error) {
+	auth, err := GetAuthFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cs, err := r.DB.Collection("orders").Find(context.Background(), bson.M{"userId": auth.UID}, ordersSort)
+	if err != nil {
+		return nil, err
+	}
+	var orders []*model.Order
+	defer cs.Close(context.Background())
+	err = cs.All(context.Background(), &orders)
+	if err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+func (r *queryResolver) Order(ctx context.Context, id string) (*model.Order, error) {
+	auth, err := GetAuthFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	order, err := r.findOrder(auth, id)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+func (r *queryResolver) Invoices(ctx context.Context, kind *model.InvoiceKind, pagination *model.Pagination) ([]*model.Invoice, error) {
+	filter := bson.M{}
+	if kind != nil {
+		filter["kind"] = *kind
+	}
+	opts := paginationOptions(pagination).SetSort(bson.D{{Key: "created", Value: -1}, {Key: "sequence", Value: -1}})
+	cs, err := r.DB.Collection("invoices").Find(context.Background(), filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	invoices := []*model.Invoice{}
+	defer cs.Close(context.Background())
+	err = cs.All(context.Background(), &invoices)
+	if err != nil {
+		return nil, err
+	}
+	return invoices, nil
+}
+
+func (r *queryResolver) Returns(ctx context.Context, status *model.ReturnStatus, pagination *model.Pagination) ([]*model.Return, error) {
+	filter := bson.M{}
+	if status != nil {
+		filter["status"] = *status
+	}
+	opts := paginationOptions(pagination).SetSort(bson.M{"created": -1})
+	cs, err := r.DB.Collection("returns").Find(context.Background(), filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	returns := []*model.Return{}
+	defer cs.Close(context.Background())
+	err = cs.All(context.Background(), &returns)
+	if err != nil {
+		return nil, err
+	}
+	return returns, nil
+}
+
+func (r *queryResolver) StaffRoles(ctx context.Context) ([]*model.StaffRole, error) {
+	cs, err := r.DB.Collection("staff-roles").Find(context.Background(), bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	var roles []*model.StaffRole
+	defer cs.Close(context.Background())
+	err = cs.All(context.Background(), &roles)
+	if err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+func (r *queryResolver) APIKeys(ctx context.Context) ([]*model.APIKey, error) {
+	cs, err := r.DB.Collection("api-keys").Find(context.Background(), bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	var apiKeys []*model.APIKey
+	defer cs.Close(context.Background())
+	err = cs.All(context.Background(), &apiKeys)
+	if err != nil {
+		return nil, err
+	}
+	return apiKeys, nil
+}
+
+func (r *queryResolver) IntegrityReport(ctx context.Context) (*model.IntegrityReport, error) {
+	return r.integrityReport()
+}
+
+func (r *queryResolver) ImportJobs(ctx context.Context, pagination *model.Pagination) ([]*model.ImportJob, error) {
+	opts := paginationOptions(pagination).SetSort(bson.M{"created": -1})
+	cs, err := r.DB.Collection("import-jobs").Find(context.Background(), bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	var jobs []*model.ImportJob
+	defer cs.Close(context.Background())
+	err = cs.All(context.Background(), &jobs)
+	if err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func (r *queryResolver) ImportJob(ctx context.Context, id string) (*model.ImportJob, error) {
+	jobOID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	var job *model.ImportJob
+	err = r.DB.Collection("import-jobs").FindOne(context.Background(), bson.M{"_id": jobOID}).Decode(&job)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+func (r *queryResolver) ExportJobs(ctx context.Context, pagination *model.Pagination) ([]*model.ExportJob, error) {
+	opts := paginationOptions(pagination).SetSort(bson.M{"created": -1})
+	cs, err := r.DB.Collection("export-jobs").Find(context.Background(), bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	var jobs []*model.ExportJob
+	defer cs.Close(context.Background())
+	err = cs.All(context.Background(), &jobs)
+	if err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func (r *queryResolver) ExportJob(ctx context.Context, id string) (*model.ExportJob, error) {
+	jobOID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	var job *model.ExportJob
+	err = r.DB.Collection("export-jobs").FindOne(context.Background(), bson.M{"_id": jobOID}).Decode(&job)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+func (r *queryResolver) BaseCurrency(ctx context.Context) (string, error) {
+	return model.StoreCurrency(), nil
+}
+
+func (r *queryResolver) Currencies(ctx context.Context) ([]string, error) {
+	filter := bson.M{"effectiveFrom": bson.M{"$lte": time.Now().Unix()}}
+	values, err := r.DB.Collection("exchange-rates").Distinct(context.Background(), "currency", filter)
+	if err != nil {
+		return nil, err
+	}
+	currencies := []string{model.StoreCurrency()}
+	for _, value := range values {
+		if currency, ok := value.(string); ok {
+			currencies = append(currencies, currency)
+		}
+	}
+	sort.Strings(currencies[1:])
+	return currencies, nil
+}
+
+func (r *queryResolver) ExchangeRates(ctx context.Context, currency *string, pagination *model.Pagination) ([]*model.ExchangeRate, error) {
+	filter := bson.M{}
+	if currency != nil && *currency != "" {
+		filter["currency"] = strings.ToUpper(*currency)
+	}
+	opts := paginationOptions(pagination).SetSort(bson.D{{Key: "effectiveFrom", Value: -1}, {Key: "created", Value: -1}})
+	cs, err := r.DB.Collection("exchange-rates").Find(context.Background(), filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	rates := []*model.ExchangeRate{}
+	defer cs.Close(context.Background())
+	err = cs.All(context.Background(), &rates)
+	if err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
+
+func (r *queryResolver) ShippingMethods(ctx context.Context) ([]*model.ShippingMethod, error) {
+	methods := []*model.ShippingMethod{}
+	err := findAll(r.DB, "shipping-methods", bson.M{}, &methods)
+	if err != nil {
+		return nil, err
+	}
+	return methods, nil
+}
+
 // Query returns generated.QueryResolver implementation.
 func (r *Resolver) Query() generated.QueryResolver { return &queryResolver{r} }
 
 type queryResolver struct{ *Resolver }
-
-// !!! WARNING !!!
-// The code below was going to be deleted when updating resolvers. It has been copied here so you have
-// one last chance to move it out of harms way if you want. There are two reasons this happens:
-//  - When renaming or deleting a resolver the old code will be put in here. You can safely delete
-//    it when you're done.
-//  - You have helper methods in this file. Move them out to keep these resolver files clean.
-func (r *queryResolver) GetUser(ctx context.Context, id string) (*model.User, error) {
-	panic(fmt.Errorf("not implemented"))
-}
@@ -2,12 +2,23 @@ package resolver
 
 //go:generate go run github.com/99designs/gqlgen generate
 
-import "go.mongodb.org/mongo-driver/mongo"
+import (
+	"book-store/payment"
+	"book-store/storage"
+	"book-store/tax"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
 
 // This file will not be regenerated automatically.
 //
 // It serves as dependency injection for your app, add any dependencies you require here.
 
 type Resolver struct {
-	DB *mongo.Database
+	DB    *mongo.Database
+	Blobs storage.BlobStore
+	// Documents keeps the invoices and the exports, which are private and only downloaded with a signed URL
+	Documents storage.BlobStore
+	Tax       tax.Calculator
+	Payments  payment.Gateway
 }
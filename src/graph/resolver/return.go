@@ -0,0 +1,191 @@
+package resolver
+
+import (
+	"book-store/graph/model"
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ReturnApprovalTimeout is how long an approval may take before another one can take over the return.
+const ReturnApprovalTimeout = 10 * time.Minute
+
+// orderItemIndex returns the index of the item of an order with a book and a variant, or -1.
+func orderItemIndex(order *model.Order, bookID string, variantID *string) int {
+	for i, item := range order.Items {
+		if item.BookID != bookID {
+			continue
+		}
+		if (item.VariantID == nil && variantID == nil) || (item.VariantID != nil && variantID != nil && *item.VariantID == *variantID) {
+			return i
+		}
+	}
+	return -1
+}
+
+// returnQuantities returns the quantity of every item of an order in return lines, quantities[i] of order.Items[i].
+func returnQuantities(order *model.Order, lines []*model.ReturnLine) []int64 {
+	quantities := make([]int64, len(order.Items))
+	for _, line := range lines {
+		if i := orderItemIndex(order, line.BookID, line.VariantID); i >= 0 {
+			quantities[i] += line.Quantity
+		}
+	}
+	return quantities
+}
+
+// returnedQuantities returns the quantity of every item of an order in returns which weren't rejected.
+func (r *Resolver) returnedQuantities(order *model.Order) ([]int64, error) {
+	var returns []*model.Return
+	filter := bson.M{"orderId": order.ID, "status": bson.M{"$ne": model.ReturnStatusRejected}}
+	err := findAll(r.DB, "returns", filter, &returns)
+	if err != nil {
+		return nil, err
+	}
+	quantities := make([]int64, len(order.Items))
+	for _, ret := range returns {
+		for i, quantity := range returnQuantities(order, ret.Lines) {
+			quantities[i] += quantity
+		}
+	}
+	return quantities, nil
+}
+
+// returnLines checks the lines of a return request against the items of an order which weren't returned yet.
+func (r *Resolver) returnLines(order *model.Order, input []*model.ReturnLineInput) ([]*model.ReturnLine, error) {
+	if len(input) == 0 {
+		return nil, fmt.Errorf("Please choose the items to return")
+	}
+	returned, err := r.returnedQuantities(order)
+	if err != nil {
+		return nil, err
+	}
+	return checkReturnLines(order, returned, input)
+}
+
+// checkReturnLines checks the lines of a return request against the items of an order, of which
+// returned[i] of order.Items[i] were returned already.
+func checkReturnLines(order *model.Order, returned []int64, input []*model.ReturnLineInput) ([]*model.ReturnLine, error) {
+	returned = append([]int64{}, returned...)
+	lines := []*model.ReturnLine{}
+	for _, line := range input {
+		i := orderItemIndex(order, line.BookID, line.VariantID)
+		if i < 0 {
+			return nil, fmt.Errorf("Book %v isn't in order %v", line.BookID, order.ID)
+		}
+		item := order.Items[i]
+		if line.Quantity <= 0 {
+			return nil, fmt.Errorf("Quantity of %v must be positive", item.Name)
+		}
+		returned[i] += line.Quantity
+		if returned[i] > item.Quantity {
+			return nil, fmt.Errorf("Only %v of %v can be returned", item.Quantity-(returned[i]-line.Quantity), item.Name)
+		}
+		lines = append(lines, &model.ReturnLine{
+			BookID:    item.BookID,
+			VariantID: item.VariantID,
+			Name:      item.Name,
+			Quantity:  line.Quantity,
+		})
+	}
+	return lines, nil
+}
+
+// claimQuantities adds quantities of the items of an order, quantities[i] of order.Items[i], to a
+// counter of the order such as returnedQuantities. The update only applies if no count goes over the
+// ordered quantity, so concurrent claims can't take more than was ordered and false is returned when
+// there isn't enough left. The counter of an order which has none starts from counted.
+func (r *Resolver) claimQuantities(order *model.Order, field string, quantities []int64, counted func() ([]int64, error)) (bool, error) {
+	orderOID, err := primitive.ObjectIDFromHex(order.ID)
+	if err != nil {
+		return false, err
+	}
+	missing := bson.M{"_id": orderOID, field: bson.M{"$exists": false}}
+	n, err := r.DB.Collection("orders").CountDocuments(context.Background(), missing)
+	if err != nil {
+		return false, err
+	}
+	if n > 0 {
+		start, err := counted()
+		if err != nil {
+			return false, err
+		}
+		// a concurrent claim may have started the counter already
+		_, err = r.DB.Collection("orders").UpdateOne(context.Background(), missing, bson.M{"$set": bson.M{field: start}})
+		if err != nil {
+			return false, err
+		}
+	}
+	filter := bson.M{"_id": orderOID}
+	inc := bson.M{}
+	for i, quantity := range quantities {
+		if quantity <= 0 {
+			continue
+		}
+		key := fmt.Sprintf("%v.%v", field, i)
+		filter[key] = bson.M{"$lte": order.Items[i].Quantity - quantity}
+		inc[key] = quantity
+	}
+	if len(inc) == 0 {
+		return true, nil
+	}
+	result, err := r.DB.Collection("orders").UpdateOne(context.Background(), filter, bson.M{"$inc": inc})
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// releaseQuantities takes quantities claimed with claimQuantities back off the counter, an order
+// without a counter counts them again when it starts one.
+func (r *Resolver) releaseQuantities(order *model.Order, field string, quantities []int64) error {
+	orderOID, err := primitive.ObjectIDFromHex(order.ID)
+	if err != nil {
+		return err
+	}
+	inc := bson.M{}
+	for i, quantity := range quantities {
+		if quantity > 0 {
+			inc[fmt.Sprintf("%v.%v", field, i)] = -quantity
+		}
+	}
+	if len(inc) == 0 {
+		return nil
+	}
+	filter := bson.M{"_id": orderOID, field: bson.M{"$exists": true}}
+	_, err = r.DB.Collection("orders").UpdateOne(context.Background(), filter, bson.M{"$inc": inc})
+	return err
+}
+
+// returnedItems are the items of an order in the quantities of a return, to be put back in stock.
+func returnedItems(order *model.Order, ret *model.Return) []*model.OrderItem {
+	items := []*model.OrderItem{}
+	for i, quantity := range returnQuantities(order, ret.Lines) {
+		if quantity > 0 {
+			item := *order.Items[i]
+			item.Quantity = quantity
+			items = append(items, &item)
+		}
+	}
+	return items
+}
+
+func (r *Resolver) findReturn(id string) (*model.Return, error) {
+	returnOID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	var ret *model.Return
+	err = r.DB.Collection("returns").FindOne(context.Background(), bson.M{"_id": returnOID}).Decode(&ret)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("Return %v doesn't exist", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
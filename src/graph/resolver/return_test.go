@@ -0,0 +1,180 @@
+package resolver
+
+import (
+	"book-store/graph/model"
+	"book-store/storage"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestReturnQuantities(t *testing.T) {
+	hardcover, ebook := "hardcover", "ebook"
+	order := &model.Order{Items: []*model.OrderItem{
+		{BookID: "a", VariantID: &hardcover, Quantity: 2},
+		{BookID: "a", VariantID: &ebook, Quantity: 1},
+		{BookID: "b", Quantity: 3},
+	}}
+	lines := []*model.ReturnLine{
+		{BookID: "a", VariantID: &ebook, Quantity: 1},
+		{BookID: "b", Quantity: 2},
+		{BookID: "c", Quantity: 1},
+	}
+	quantities := returnQuantities(order, lines)
+	if quantities[0] != 0 || quantities[1] != 1 || quantities[2] != 2 {
+		t.Fatalf("unexpected quantities %v", quantities)
+	}
+	if i := orderItemIndex(order, "a", nil); i != -1 {
+		t.Fatalf("expected a book with variants not to match without a variant, got %v", i)
+	}
+}
+
+func TestCheckReturnLines(t *testing.T) {
+	order := &model.Order{ID: "o", Items: []*model.OrderItem{
+		{BookID: "a", Name: "A", Quantity: 2},
+		{BookID: "b", Name: "B", Quantity: 1},
+	}}
+	returned := []int64{1, 0}
+	lines, err := checkReturnLines(order, returned, []*model.ReturnLineInput{{BookID: "a", Quantity: 1}, {BookID: "b", Quantity: 1}})
+	if err != nil || len(lines) != 2 {
+		t.Fatalf("expected the rest of the order to be returnable, got %v", err)
+	}
+	_, err = checkReturnLines(order, returned, []*model.ReturnLineInput{{BookID: "a", Quantity: 2}})
+	if err == nil || err.Error() != "Only 1 of A can be returned" {
+		t.Fatalf("expected returning more than is left to fail, got %v", err)
+	}
+	// the same item twice in a request counts twice
+	_, err = checkReturnLines(order, []int64{0, 0}, []*model.ReturnLineInput{{BookID: "b", Quantity: 1}, {BookID: "b", Quantity: 1}})
+	if err == nil {
+		t.Fatal("expected returning an item twice to fail")
+	}
+	if returned[0] != 1 || returned[1] != 0 {
+		t.Fatalf("expected the returned quantities to be left alone, got %v", returned)
+	}
+	_, err = checkReturnLines(order, returned, []*model.ReturnLineInput{{BookID: "c", Quantity: 1}})
+	if err == nil {
+		t.Fatal("expected a book out of the order to fail")
+	}
+}
+
+func TestApproveReturn(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+	orderID, returnID, invoiceID, noteID := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	order := bson.D{
+		{Key: "_id", Value: orderID},
+		{Key: "userId", Value: "u"},
+		{Key: "currency", Value: "EUR"},
+		{Key: "status", Value: model.OrderStatusDelivered},
+		{Key: "items", Value: bson.A{
+			bson.D{{Key: "bookId", Value: "a"}, {Key: "name", Value: "A"}, {Key: "quantity", Value: int64(2)},
+				{Key: "price", Value: model.Money{Amount: 1000, Currency: "EUR"}}},
+		}},
+		{Key: "shipping", Value: bson.D{{Key: "cost", Value: model.Money{Amount: 500, Currency: "EUR"}}}},
+	}
+	ret := func(status model.ReturnStatus, extra ...bson.E) bson.D {
+		return append(bson.D{
+			{Key: "_id", Value: returnID},
+			{Key: "orderId", Value: orderID.Hex()},
+			{Key: "userId", Value: "u"},
+			{Key: "lines", Value: bson.A{bson.D{{Key: "bookId", Value: "a"}, {Key: "name", Value: "A"}, {Key: "quantity", Value: int64(1)}}}},
+			{Key: "reason", Value: "Damaged"},
+			{Key: "status", Value: status},
+			{Key: "updated", Value: time.Now().Unix()},
+		}, extra...)
+	}
+	invoiced := bson.D{{Key: "_id", Value: invoiceID}, {Key: "kind", Value: model.InvoiceKindInvoice}, {Key: "number", Value: "INV-000001"}, {Key: "orderId", Value: orderID.Hex()}}
+	note := bson.D{
+		{Key: "_id", Value: noteID},
+		{Key: "kind", Value: model.InvoiceKindCreditNote},
+		{Key: "orderId", Value: orderID.Hex()},
+		{Key: "total", Value: model.Money{Amount: 1500, Currency: "EUR"}},
+	}
+	cursor := func(coll string, docs ...bson.D) bson.D {
+		return mtest.CreateCursorResponse(0, "db."+coll, mtest.FirstBatch, docs...)
+	}
+	auth := &Auth{UID: "staff", Permissions: []model.Permission{model.PermissionOrdersManage}}
+	ctx := graphql.WithResponseContext(context.WithValue(context.Background(), authContextKey{}, auth), graphql.DefaultErrorPresenter, nil)
+	shipping := true
+
+	mt.Run("requested to refunded", func(mt *mtest.T) {
+		payments := &fakeGateway{}
+		documents, _ := storage.NewLocalStore(mt.TempDir(), "")
+		r := &mutationResolver{&Resolver{DB: mt.DB, Documents: documents, Payments: payments}}
+		mt.AddMockResponses(
+			cursor("returns", ret(model.ReturnStatusRequested)),
+			cursor("orders", order),
+			cursor("invoices", invoiced),
+			updated(1), // REQUESTED to APPROVED
+			cursor("invoices", invoiced),
+			cursor("orders"), // the order counts its credited quantities
+			updated(1),
+			cursor("invoices"), // no shipping credited before
+			updated(1),
+			cursor("invoices", bson.D{{Key: "sequence", Value: int64(3)}}),
+			mtest.CreateSuccessResponse(),
+			cursor("invoices", invoiced),
+			updated(1), // rendered
+			updated(1), // creditNoteId
+			cursor("invoices", note),
+			updated(1), // refundId
+			updated(1), // APPROVED to REFUNDED
+			cursor("returns", ret(model.ReturnStatusRefunded, bson.E{Key: "creditNoteId", Value: noteID.Hex()})),
+		)
+		result, err := r.ApproveReturn(ctx, returnID.Hex(), nil, &shipping)
+		if err != nil {
+			mt.Fatal(err)
+		}
+		if result.Status != model.ReturnStatusRefunded {
+			mt.Fatalf("expected the return to be refunded, got %v", result.Status)
+		}
+		if len(payments.refunds) != 1 || payments.refunds[0].Amount != 1500 || payments.refunds[0].Key != noteID.Hex() {
+			mt.Fatalf("expected one refund of the item and the shipping, got %+v", payments.refunds)
+		}
+		approve := mt.GetStartedEvent()
+		for approve != nil && approve.CommandName != "update" {
+			approve = mt.GetStartedEvent()
+		}
+		filter := approve.Command.Lookup("updates").Array().Index(0).Value().Document().Lookup("q").Document()
+		if filter.Lookup("status").StringValue() != string(model.ReturnStatusRequested) {
+			mt.Fatalf("expected the approval to claim a requested return, got %v", filter)
+		}
+	})
+
+	mt.Run("no invoice", func(mt *mtest.T) {
+		r := &mutationResolver{&Resolver{DB: mt.DB, Payments: &fakeGateway{}}}
+		mt.AddMockResponses(
+			cursor("returns", ret(model.ReturnStatusRequested)),
+			cursor("orders", order),
+			cursor("invoices"),
+		)
+		_, err := r.ApproveReturn(ctx, returnID.Hex(), nil, nil)
+		if err == nil {
+			mt.Fatal("expected a return of an order without invoice to fail")
+		}
+		for event := mt.GetStartedEvent(); event != nil; event = mt.GetStartedEvent() {
+			if event.CommandName == "update" {
+				mt.Fatal("expected the return to be left waiting for approval")
+			}
+		}
+	})
+
+	mt.Run("being approved", func(mt *mtest.T) {
+		r := &mutationResolver{&Resolver{DB: mt.DB, Payments: &fakeGateway{}}}
+		mt.AddMockResponses(
+			cursor("returns", ret(model.ReturnStatusApproved)),
+			cursor("orders", order),
+			updated(0), // the approval isn't stale
+		)
+		_, err := r.ApproveReturn(ctx, returnID.Hex(), nil, nil)
+		if err == nil || !strings.Contains(err.Error(), "is being approved") {
+			mt.Fatalf("expected a concurrent approval to fail, got %v", err)
+		}
+	})
+}
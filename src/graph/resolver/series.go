@@ -0,0 +1,72 @@
+package resolver
+
+import (
+	"book-store/graph/model"
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func (r *Resolver) findSeries(id string) (*model.Series, error) {
+	seriesOID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	var series *model.Series
+	err = r.DB.Collection("series").FindOne(context.Background(), bson.M{"_id": seriesOID}).Decode(&series)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("Series %v doesn't exist", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return series, nil
+}
+
+// seriesPosition checks that a position is free in a series, when position is nil
+// the book is appended after the last book of the series.
+func (r *Resolver) seriesPosition(seriesID string, bookID string, position *float64) (float64, error) {
+	if position != nil {
+		if *position <= 0 {
+			return 0, fmt.Errorf("Position must be a positive number")
+		}
+		bookOID, _ := primitive.ObjectIDFromHex(bookID)
+		filter := bson.M{"seriesId": seriesID, "seriesPosition": *position, "_id": bson.M{"$ne": bookOID}}
+		count, err := r.DB.Collection("books").CountDocuments(context.Background(), filter)
+		if err != nil {
+			return 0, err
+		}
+		if count > 0 {
+			return 0, fmt.Errorf("Position %v of the series is already taken", *position)
+		}
+		return *position, nil
+	}
+	last, err := r.bookInSeries(seriesID, bson.M{"$exists": true}, -1)
+	if err != nil {
+		return 0, err
+	}
+	if last == nil || last.SeriesPosition == nil {
+		return 1, nil
+	}
+	return float64(int64(*last.SeriesPosition) + 1), nil
+}
+
+// bookInSeries returns the first book of a series whose position matches positionFilter,
+// in the given sort direction, or nil.
+func (r *Resolver) bookInSeries(seriesID string, positionFilter bson.M, direction int) (*model.Book, error) {
+	filter := bson.M{"seriesId": seriesID, "seriesPosition": positionFilter}
+	opts := options.FindOne().SetSort(bson.M{"seriesPosition": direction})
+	var book *model.Book
+	err := r.DB.Collection("books").FindOne(context.Background(), filter, opts).Decode(&book)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return book, nil
+}
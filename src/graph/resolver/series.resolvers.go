@@ -0,0 +1,34 @@
+package resolver
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+
+import (
+	"book-store/graph/generated"
+	"book-store/graph/model"
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func (r *seriesResolver) Books(ctx context.Context, obj *model.Series) ([]*model.Book, error) {
+	filter := bson.M{"seriesId": obj.ID}
+	opts := options.Find().SetSort(bson.M{"seriesPosition": 1})
+	cs, err := r.DB.Collection("books").Find(context.Background(), filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var books []*model.Book
+	defer cs.Close(context.Background())
+	err = cs.All(context.Background(), &books)
+	if err != nil {
+		return nil, err
+	}
+	return books, nil
+}
+
+// Series returns generated.SeriesResolver implementation.
+func (r *Resolver) Series() generated.SeriesResolver { return &seriesResolver{r} }
+
+type seriesResolver struct{ *Resolver }
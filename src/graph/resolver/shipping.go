@@ -0,0 +1,115 @@
+package resolver
+
+import (
+	"book-store/graph/model"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// isDigital tells whether an item is downloaded rather than shipped, as ebooks and audiobooks are.
+func isDigital(item *model.OrderItem) bool {
+	return item.Format != nil && (*item.Format == model.BookFormatEbook || *item.Format == model.BookFormatAudiobook)
+}
+
+// needsShipping tells whether some items are physical.
+func needsShipping(items []*model.OrderItem) bool {
+	for _, item := range items {
+		if !isDigital(item) {
+			return true
+		}
+	}
+	return false
+}
+
+// shippedSubtotal is the price of the physical items, the free shipping threshold applies to it.
+func shippedSubtotal(items []*model.OrderItem, currency string) model.Money {
+	subtotal := model.Money{Currency: currency}
+	for _, item := range items {
+		if !isDigital(item) {
+			subtotal.Amount += item.Price.Times(item.Quantity).Amount
+		}
+	}
+	return subtotal
+}
+
+// shippingWeight is the weight of the items in grams, an item without weight weighs nothing.
+func shippingWeight(items []*model.OrderItem) int64 {
+	weight := int64(0)
+	for _, item := range items {
+		if item.Weight != nil {
+			weight += *item.Weight * item.Quantity
+		}
+	}
+	return weight
+}
+
+// shippingQuotes returns the cost of the active shipping methods which ship to a country, the item
+// prices and the costs are in currency. There are no quotes when every item is digital.
+func (r *Resolver) shippingQuotes(items []*model.OrderItem, country string, currency string, at int64) ([]*model.ShippingQuote, error) {
+	quotes := []*model.ShippingQuote{}
+	if !needsShipping(items) {
+		return quotes, nil
+	}
+	subtotal := shippedSubtotal(items, currency)
+	weight := shippingWeight(items)
+	var methods []*model.ShippingMethod
+	err := findAll(r.DB, "shipping-methods", bson.M{"active": true}, &methods)
+	if err != nil {
+		return nil, err
+	}
+	for _, method := range methods {
+		zone := method.Zone(country)
+		if zone == nil {
+			continue
+		}
+		cost, err := r.convertPrice(method.Cost(zone, weight), currency, at)
+		if err != nil {
+			return nil, err
+		}
+		if zone.FreeOver != nil {
+			threshold, err := r.convertPrice(*zone.FreeOver, currency, at)
+			if err != nil {
+				return nil, err
+			}
+			if subtotal.Amount >= threshold.Amount {
+				cost.Amount = 0
+			}
+		}
+		quotes = append(quotes, &model.ShippingQuote{
+			Method:  method,
+			Zone:    zone.Name,
+			Cost:    cost,
+			MinDays: method.MinDays,
+			MaxDays: method.MaxDays,
+		})
+	}
+	return quotes, nil
+}
+
+// orderShipping returns the shipping of an order with the chosen method, an order of digital items has none.
+func (r *Resolver) orderShipping(items []*model.OrderItem, methodID *string, country string, currency string, at int64) (*model.OrderShipping, error) {
+	if !needsShipping(items) {
+		return nil, nil
+	}
+	if methodID == nil {
+		return nil, fmt.Errorf("Please choose a shipping method")
+	}
+	quotes, err := r.shippingQuotes(items, country, currency, at)
+	if err != nil {
+		return nil, err
+	}
+	for _, quote := range quotes {
+		if quote.Method.ID == *methodID {
+			return &model.OrderShipping{
+				MethodID: quote.Method.ID,
+				Name:     quote.Method.Name,
+				Zone:     quote.Zone,
+				Cost:     quote.Cost,
+				MinDays:  quote.MinDays,
+				MaxDays:  quote.MaxDays,
+			}, nil
+		}
+	}
+	return nil, fmt.Errorf("Shipping method %v isn't available for this address", *methodID)
+}
@@ -0,0 +1,77 @@
+package resolver
+
+import (
+	"book-store/graph/model"
+	"book-store/tax"
+	"fmt"
+)
+
+// taxLocation is where an order is shipped, for the tax rules.
+func taxLocation(country string, region *string) tax.Location {
+	location := tax.Location{Country: country}
+	if region != nil {
+		location.Region = *region
+	}
+	return location
+}
+
+// taxProductType is the tax category of the format of a book or of a variant, a book without format is a book.
+func taxProductType(format *model.BookFormat) model.TaxProductType {
+	if format == nil {
+		return model.TaxProductTypeBook
+	}
+	switch *format {
+	case model.BookFormatEbook:
+		return model.TaxProductTypeEbook
+	case model.BookFormatAudiobook:
+		return model.TaxProductTypeAudiobook
+	}
+	return model.TaxProductTypeBook
+}
+
+// computeTax computes the tax of order items shipped to a location and of the shipping cost, if
+// any, as a line of its own. The item prices and the cost are in currency.
+func (r *Resolver) computeTax(items []*model.OrderItem, shipping *model.Money, location tax.Location, currency string) (*model.TaxBreakdown, error) {
+	if r.Tax == nil {
+		return nil, fmt.Errorf("Tax calculator isn't configured")
+	}
+	lines := make([]tax.Line, len(items))
+	for i, item := range items {
+		lines[i] = tax.Line{
+			ProductType: tax.ProductType(taxProductType(item.Format)),
+			Amount:      item.Price.Times(item.Quantity).Amount,
+		}
+	}
+	if shipping != nil {
+		lines = append(lines, tax.Line{ProductType: tax.ProductShipping, Amount: shipping.Amount})
+	}
+	taxes, err := r.Tax.Calculate(location, lines)
+	if err != nil {
+		return nil, err
+	}
+	zero := model.Money{Currency: currency}
+	breakdown := &model.TaxBreakdown{Lines: []*model.TaxLine{}, Net: zero, Tax: zero, Gross: zero}
+	for i, lineTax := range taxes {
+		line := &model.TaxLine{
+			ProductType: model.TaxProductTypeShipping,
+			Name:        lineTax.Name,
+			Rate:        lineTax.Rate,
+			Inclusive:   lineTax.Inclusive,
+			Net:         model.Money{Amount: lineTax.Net, Currency: currency},
+			Tax:         model.Money{Amount: lineTax.Tax, Currency: currency},
+			Gross:       model.Money{Amount: lineTax.Gross, Currency: currency},
+		}
+		if i < len(items) {
+			line.BookID = &items[i].BookID
+			line.VariantID = items[i].VariantID
+			line.ProductType = taxProductType(items[i].Format)
+			breakdown.Lines = append(breakdown.Lines, line)
+		} else {
+			breakdown.Shipping = line
+		}
+		breakdown.Net.Amount += lineTax.Net
+		breakdown.Tax.Amount += lineTax.Tax
+		breakdown.Gross.Amount += lineTax.Gross
+	}
+	return breakdown, nil
+}
@@ -0,0 +1,45 @@
+package resolver
+
+import (
+	"book-store/graph/model"
+	"book-store/tax"
+	"testing"
+)
+
+func TestComputeTaxOfShipping(t *testing.T) {
+	table, err := tax.NewRuleTable([]tax.Rule{{Country: "DE", Name: "MwSt", Rate: "19", ReducedRates: map[tax.ProductType]string{tax.ProductBook: "7"}}}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &Resolver{Tax: table}
+	items := []*model.OrderItem{{BookID: "a", Price: model.Money{Amount: 1000, Currency: "EUR"}, Quantity: 2}}
+	shipping := model.Money{Amount: 500, Currency: "EUR"}
+	taxes, err := r.computeTax(items, &shipping, tax.Location{Country: "DE"}, "EUR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(taxes.Lines) != 1 || *taxes.Lines[0].BookID != "a" || taxes.Lines[0].Tax.Amount != 140 {
+		t.Fatalf("expected the item line alone in the lines, got %+v", taxes.Lines)
+	}
+	if taxes.Shipping == nil || taxes.Shipping.ProductType != model.TaxProductTypeShipping || taxes.Shipping.Rate != "19" || taxes.Shipping.Tax.Amount != 95 {
+		t.Fatalf("expected the shipping to be taxed at the standard rate, got %+v", taxes.Shipping)
+	}
+	if taxes.Net.Amount != 2500 || taxes.Tax.Amount != 235 || taxes.Gross.Amount != 2735 {
+		t.Fatalf("expected the totals to include the shipping, got %+v", taxes)
+	}
+	taxes, err = r.computeTax(items, nil, tax.Location{Country: "DE"}, "EUR")
+	if err != nil || taxes.Shipping != nil || taxes.Gross.Amount != 2140 {
+		t.Fatalf("expected no shipping line, got %+v %v", taxes, err)
+	}
+}
+
+func TestShippedSubtotal(t *testing.T) {
+	ebook := model.BookFormatEbook
+	items := []*model.OrderItem{
+		{BookID: "a", Price: model.Money{Amount: 1500, Currency: "EUR"}, Quantity: 2},
+		{BookID: "b", Format: &ebook, Price: model.Money{Amount: 5000, Currency: "EUR"}, Quantity: 1},
+	}
+	if subtotal := shippedSubtotal(items, "EUR"); subtotal.Amount != 3000 {
+		t.Fatalf("expected digital items out of the subtotal, got %v", subtotal.Amount)
+	}
+}
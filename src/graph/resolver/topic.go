@@ -0,0 +1,81 @@
+package resolver
+
+import (
+	"book-store/graph/model"
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxTopicDepth bounds the walks up and down the topic tree, so a corrupted tree can't loop forever.
+const maxTopicDepth = 32
+
+func (r *Resolver) findTopic(id string) (*model.Topic, error) {
+	topicOID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid topic id %v", id)
+	}
+	var topic *model.Topic
+	err = r.DB.Collection("topics").FindOne(context.Background(), bson.M{"_id": topicOID}).Decode(&topic)
+	if err != nil {
+		return nil, fmt.Errorf("Topic %v doesn't exist", id)
+	}
+	return topic, nil
+}
+
+// topicAncestors returns the ancestors of a topic ordered from the root down to its parent.
+func (r *Resolver) topicAncestors(topic *model.Topic) ([]*model.Topic, error) {
+	ancestors := []*model.Topic{}
+	parentID := topic.ParentID
+	for parentID != nil && len(ancestors) < maxTopicDepth {
+		parent, err := r.findTopic(*parentID)
+		if err != nil {
+			return nil, err
+		}
+		ancestors = append([]*model.Topic{parent}, ancestors...)
+		parentID = parent.ParentID
+	}
+	return ancestors, nil
+}
+
+// topicDescendantIDs returns the ids of the children of a topic, their children and so on.
+func (r *Resolver) topicDescendantIDs(id string) ([]string, error) {
+	descendants := []string{}
+	level := []string{id}
+	for depth := 0; len(level) > 0 && depth < maxTopicDepth; depth++ {
+		var children []*model.Topic
+		err := findAll(r.DB, "topics", bson.M{"parentId": bson.M{"$in": level}}, &children)
+		if err != nil {
+			return nil, err
+		}
+		level = []string{}
+		for _, child := range children {
+			descendants = append(descendants, child.ID)
+			level = append(level, child.ID)
+		}
+	}
+	return descendants, nil
+}
+
+// checkTopicParent makes sure parentID exists and moving topic id under it doesn't create a cycle.
+func (r *Resolver) checkTopicParent(id string, parentID string) error {
+	if parentID == id {
+		return fmt.Errorf("A topic can't be its own parent")
+	}
+	parent, err := r.findTopic(parentID)
+	if err != nil {
+		return err
+	}
+	ancestors, err := r.topicAncestors(parent)
+	if err != nil {
+		return err
+	}
+	for _, ancestor := range ancestors {
+		if ancestor.ID == id {
+			return fmt.Errorf("Topic %v can't be moved under one of its descendants", id)
+		}
+	}
+	return nil
+}
@@ -11,8 +11,35 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 )
 
-func (r *topicResolver) Books(ctx context.Context, obj *model.Topic) ([]*model.Book, error) {
+func (r *topicResolver) Parent(ctx context.Context, obj *model.Topic) (*model.Topic, error) {
+	if obj.ParentID == nil {
+		return nil, nil
+	}
+	return r.findTopic(*obj.ParentID)
+}
+
+func (r *topicResolver) Children(ctx context.Context, obj *model.Topic) ([]*model.Topic, error) {
+	var children []*model.Topic
+	err := findAll(r.DB, "topics", bson.M{"parentId": obj.ID}, &children)
+	if err != nil {
+		return nil, err
+	}
+	return children, nil
+}
+
+func (r *topicResolver) Ancestors(ctx context.Context, obj *model.Topic) ([]*model.Topic, error) {
+	return r.topicAncestors(obj)
+}
+
+func (r *topicResolver) Books(ctx context.Context, obj *model.Topic, includeDescendants bool) ([]*model.Book, error) {
 	filter := bson.M{"topicsId": bson.M{"$all": bson.A{obj.ID}}}
+	if includeDescendants {
+		topicsId, err := r.topicDescendantIDs(obj.ID)
+		if err != nil {
+			return nil, err
+		}
+		filter = bson.M{"topicsId": bson.M{"$in": append(topicsId, obj.ID)}}
+	}
 	cs, err := r.DB.Collection("books").Find(context.Background(), filter)
 	if err != nil {
 		return nil, err
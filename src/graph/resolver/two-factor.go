@@ -0,0 +1,158 @@
+package resolver
+
+import (
+	"book-store/graph/model"
+	"book-store/totp"
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const recoveryCodeCount = 10
+
+const (
+	// a user may try maxTwoFactorAttempts codes, after which the codes are refused for twoFactorLockout
+	// so a code can't be guessed
+	maxTwoFactorAttempts = 5
+	twoFactorLockout     = 15 * time.Minute
+)
+
+type userTwoFactor struct {
+	model.User `bson:",inline"`
+	TwoFactor  model.TwoFactor `bson:"twoFactor"`
+}
+
+func (r *Resolver) findUserTwoFactor(uid string) (*userTwoFactor, error) {
+	userOID, err := primitive.ObjectIDFromHex(uid)
+	if err != nil {
+		return nil, err
+	}
+	var user userTwoFactor
+	err = r.DB.Collection("users").FindOne(context.Background(), bson.M{"_id": userOID}).Decode(&user)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// claimTwoFactorAttempt counts an attempt of a user before its code is checked, so concurrent
+// attempts can't get past the limit. Once the limit is reached the user is locked out for a while.
+func (r *Resolver) claimTwoFactorAttempt(userOID primitive.ObjectID, now time.Time) error {
+	locked := fmt.Errorf("Too many invalid two-factor codes, please try again later")
+	filter := bson.M{"_id": userOID, "twoFactor.lockedUntil": bson.M{"$not": bson.M{"$gt": now.Unix()}}}
+	update := bson.M{"$inc": bson.M{"twoFactor.attempts": 1}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var user userTwoFactor
+	err := r.DB.Collection("users").FindOneAndUpdate(context.Background(), filter, update, opts).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		return locked
+	}
+	if err != nil {
+		return err
+	}
+	if user.TwoFactor.Attempts > maxTwoFactorAttempts {
+		lock := bson.M{"$set": bson.M{
+			"twoFactor.attempts":    0,
+			"twoFactor.lockedUntil": now.Add(twoFactorLockout).Unix(),
+		}}
+		_, err = r.DB.Collection("users").UpdateOne(context.Background(), bson.M{"_id": userOID}, lock)
+		if err != nil {
+			return err
+		}
+		return locked
+	}
+	return nil
+}
+
+// resetTwoFactorAttempts starts counting the attempts again after a valid code.
+func (r *Resolver) resetTwoFactorAttempts(userOID primitive.ObjectID) error {
+	update := bson.M{"$set": bson.M{"twoFactor.attempts": 0}}
+	_, err := r.DB.Collection("users").UpdateOne(context.Background(), bson.M{"_id": userOID}, update)
+	return err
+}
+
+// verifyTwoFactorCode accepts either a TOTP code or an unused recovery code,
+// a recovery code is removed once it has been used. Every code counts as an attempt until
+// a valid one, see claimTwoFactorAttempt.
+func (r *Resolver) verifyTwoFactorCode(user *userTwoFactor, code string) error {
+	userOID, err := primitive.ObjectIDFromHex(user.ID)
+	if err != nil {
+		return err
+	}
+	err = r.claimTwoFactorAttempt(userOID, time.Now())
+	if err != nil {
+		return err
+	}
+	err = r.checkTwoFactorCode(userOID, user, code)
+	if err != nil {
+		return err
+	}
+	return r.resetTwoFactorAttempts(userOID)
+}
+
+func (r *Resolver) checkTwoFactorCode(userOID primitive.ObjectID, user *userTwoFactor, code string) error {
+	if step, ok := totp.Validate(user.TwoFactor.Secret, code, time.Now()); ok {
+		if step <= user.TwoFactor.LastStep {
+			return fmt.Errorf("Two-factor code has already been used")
+		}
+		filter := bson.M{"_id": userOID, "twoFactor.lastStep": user.TwoFactor.LastStep}
+		update := bson.M{"$set": bson.M{"twoFactor.lastStep": step}}
+		result, err := r.DB.Collection("users").UpdateOne(context.Background(), filter, update)
+		if err != nil {
+			return err
+		}
+		if result.ModifiedCount == 0 {
+			return fmt.Errorf("Two-factor code has already been used")
+		}
+		return nil
+	}
+	normalized := strings.ToLower(strings.TrimSpace(code))
+	for _, hashed := range user.TwoFactor.RecoveryCodes {
+		if bcrypt.CompareHashAndPassword([]byte(hashed), []byte(normalized)) != nil {
+			continue
+		}
+		filter := bson.M{"_id": userOID}
+		update := bson.M{"$pull": bson.M{"twoFactor.recoveryCodes": hashed}}
+		result, err := r.DB.Collection("users").UpdateOne(context.Background(), filter, update)
+		if err != nil {
+			return err
+		}
+		if result.ModifiedCount == 0 {
+			return fmt.Errorf("Recovery code has already been used")
+		}
+		return nil
+	}
+	return fmt.Errorf("Invalid two-factor code")
+}
+
+// generateRecoveryCodes returns the plain codes, which are shown to the user only once,
+// and their hashes which are stored.
+func generateRecoveryCodes() ([]string, []string, error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+	codes := []string{}
+	hashes := []string{}
+	for i := 0; i < recoveryCodeCount; i++ {
+		raw := make([]byte, 7)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, nil, err
+		}
+		encoded := strings.ToLower(encoding.EncodeToString(raw))
+		code := encoded[:5] + "-" + encoded[5:10]
+		hashed, err := bcrypt.GenerateFromPassword([]byte(code), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, nil, err
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, string(hashed))
+	}
+	return codes, hashes, nil
+}
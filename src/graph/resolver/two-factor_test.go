@@ -0,0 +1,78 @@
+package resolver
+
+import (
+	"book-store/graph/model"
+	"book-store/totp"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestVerifyTwoFactorCode(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+	userID := primitive.NewObjectID()
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	user := &userTwoFactor{User: model.User{ID: userID.Hex()}, TwoFactor: model.TwoFactor{Secret: secret}}
+	attempts := func(n int64) bson.D {
+		doc := bson.D{{Key: "_id", Value: userID}, {Key: "twoFactor", Value: bson.D{{Key: "attempts", Value: n}}}}
+		return mtest.CreateSuccessResponse(bson.E{Key: "value", Value: doc})
+	}
+
+	mt.Run("valid code", func(mt *mtest.T) {
+		r := &Resolver{DB: mt.DB}
+		code, _ := totp.Code(secret, totp.Step(time.Now()))
+		mt.AddMockResponses(attempts(1), updated(1), updated(1))
+		if err := r.verifyTwoFactorCode(user, code); err != nil {
+			mt.Fatal(err)
+		}
+		updates := commands(mt)["update"]
+		if len(updates) != 2 {
+			mt.Fatalf("expected the step to be used and the attempts to be reset, got %v updates", len(updates))
+		}
+		reset := updates[1].Lookup("updates").Array().Index(0).Value().Document()
+		if n := reset.Lookup("u", "$set", "twoFactor.attempts").Int32(); n != 0 {
+			mt.Fatalf("expected the attempts to be reset, got %v", n)
+		}
+	})
+
+	mt.Run("invalid code", func(mt *mtest.T) {
+		r := &Resolver{DB: mt.DB}
+		mt.AddMockResponses(attempts(2))
+		if err := r.verifyTwoFactorCode(user, "abcdef"); err == nil {
+			mt.Fatal("expected an invalid code to be refused")
+		}
+		if updates := commands(mt)["update"]; len(updates) != 0 {
+			mt.Fatalf("expected the attempt to stay counted, got %v updates", len(updates))
+		}
+	})
+
+	mt.Run("too many attempts", func(mt *mtest.T) {
+		r := &Resolver{DB: mt.DB}
+		code, _ := totp.Code(secret, totp.Step(time.Now()))
+		mt.AddMockResponses(attempts(maxTwoFactorAttempts+1), updated(1))
+		if err := r.verifyTwoFactorCode(user, code); err == nil {
+			mt.Fatal("expected a valid code past the limit to be refused")
+		}
+		lock := commands(mt)["update"][0].Lookup("updates").Array().Index(0).Value().Document()
+		if until := lock.Lookup("u", "$set", "twoFactor.lockedUntil").Int64(); until < time.Now().Add(twoFactorLockout-time.Minute).Unix() {
+			mt.Fatalf("expected the user to be locked out, got %v", until)
+		}
+	})
+
+	mt.Run("locked out", func(mt *mtest.T) {
+		r := &Resolver{DB: mt.DB}
+		code, _ := totp.Code(secret, totp.Step(time.Now()))
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "value", Value: nil}))
+		if err := r.verifyTwoFactorCode(user, code); err == nil {
+			mt.Fatal("expected a locked out user to be refused")
+		}
+		claim := commands(mt)["findAndModify"][0]
+		if _, ok := claim.Lookup("query", "twoFactor.lockedUntil").DocumentOK(); !ok {
+			mt.Fatalf("expected the attempt to be claimed only when not locked out, got %v", claim)
+		}
+	})
+}
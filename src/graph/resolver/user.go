@@ -0,0 +1,38 @@
+package resolver
+
+import (
+	"book-store/graph/model"
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const minPasswordLength = 8
+
+// setUserDisabled is shared by disableUser and enableUser, only an ADMIN can change the status of an ADMIN.
+func (r *Resolver) setUserDisabled(auth *Auth, id string, disabled bool) (*model.User, error) {
+	userOID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	var user *model.User
+	filter := bson.M{"_id": userOID}
+	err = r.DB.Collection("users").FindOne(context.Background(), filter).Decode(&user)
+	if err != nil {
+		return nil, err
+	}
+	if user.Role == model.RoleAdmin && auth.Role != model.RoleAdmin.String() {
+		return nil, fmt.Errorf("Access denied")
+	}
+	update := bson.M{"$set": bson.M{"disabled": disabled, "updated": time.Now().Unix()}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err = r.DB.Collection("users").FindOneAndUpdate(context.Background(), filter, update, opts).Decode(&user)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
@@ -0,0 +1,23 @@
+package resolver
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+
+import (
+	"book-store/graph/generated"
+	"book-store/graph/model"
+	"context"
+)
+
+func (r *userResolver) StaffRoles(ctx context.Context, obj *model.User) ([]*model.StaffRole, error) {
+	return r.findStaffRoles(obj.StaffRolesID)
+}
+
+func (r *userResolver) Permissions(ctx context.Context, obj *model.User) ([]model.Permission, error) {
+	return r.permissionsOf(obj)
+}
+
+// User returns generated.UserResolver implementation.
+func (r *Resolver) User() generated.UserResolver { return &userResolver{r} }
+
+type userResolver struct{ *Resolver }
@@ -0,0 +1,99 @@
+package resolver
+
+import (
+	"book-store/graph/model"
+	"context"
+	"fmt"
+
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// findBookByVariant returns the book holding the variant with the given id.
+func (r *Resolver) findBookByVariant(id string) (*model.Book, *model.BookVariant, error) {
+	variantOID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	var book *model.Book
+	err = r.DB.Collection("books").FindOne(context.Background(), bson.M{"variants._id": variantOID}).Decode(&book)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil, fmt.Errorf("Variant %v doesn't exist", id)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return book, book.Variant(id), nil
+}
+
+// checkSKUUnique returns a field error when a variant other than variantID already has the SKU,
+// the unique index still catches concurrent writes.
+func (r *Resolver) checkSKUUnique(sku string, variantID primitive.ObjectID) []*gqlerror.Error {
+	filter := bson.M{"variants": bson.M{"$elemMatch": bson.M{"sku": sku, "_id": bson.M{"$ne": variantID}}}}
+	count, err := r.DB.Collection("books").CountDocuments(context.Background(), filter)
+	if err != nil {
+		return []*gqlerror.Error{gqlerror.Errorf("%v", err.Error())}
+	}
+	if count > 0 {
+		return []*gqlerror.Error{model.FieldError("sku", "A variant with SKU %v already exists", sku)}
+	}
+	return nil
+}
+
+func variantData(variant *model.BookVariant, variantOID primitive.ObjectID) bson.M {
+	return bson.M{
+		"_id":    variantOID,
+		"sku":    variant.Sku,
+		"format": variant.Format,
+		"price":  variant.Price,
+		"stock":  variant.Stock,
+		"weight": variant.Weight,
+	}
+}
+
+// reserveStock takes the ordered quantities out of the stock of the variants, either every
+// item is reserved or none is.
+func (r *Resolver) reserveStock(items []*model.OrderItem) error {
+	for i, item := range items {
+		if item.VariantID == nil {
+			continue
+		}
+		variantOID, err := primitive.ObjectIDFromHex(*item.VariantID)
+		if err != nil {
+			return err
+		}
+		filter := bson.M{"variants": bson.M{"$elemMatch": bson.M{"_id": variantOID, "stock": bson.M{"$gte": item.Quantity}}}}
+		update := bson.M{"$inc": bson.M{"variants.$.stock": -item.Quantity}}
+		result, err := r.DB.Collection("books").UpdateOne(context.Background(), filter, update)
+		if err == nil && result.ModifiedCount == 0 {
+			err = fmt.Errorf("%v (%v) is out of stock", item.Name, *item.Sku)
+		}
+		if err != nil {
+			r.releaseStock(items[:i])
+			return err
+		}
+	}
+	return nil
+}
+
+// releaseStock puts the quantities of order items back into the stock of the variants.
+func (r *Resolver) releaseStock(items []*model.OrderItem) error {
+	for _, item := range items {
+		if item.VariantID == nil {
+			continue
+		}
+		variantOID, err := primitive.ObjectIDFromHex(*item.VariantID)
+		if err != nil {
+			return err
+		}
+		filter := bson.M{"variants._id": variantOID}
+		update := bson.M{"$inc": bson.M{"variants.$.stock": item.Quantity}}
+		_, err = r.DB.Collection("books").UpdateOne(context.Background(), filter, update)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
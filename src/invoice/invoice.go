@@ -0,0 +1,149 @@
+package invoice
+
+import (
+	"fmt"
+	"time"
+)
+
+// Line is a line of an invoice, the amounts are formatted by the caller.
+type Line struct {
+	Description string
+	Quantity    int64
+	UnitPrice   string
+	TaxRate     string
+	Amount      string
+}
+
+// Reference is a labelled value printed under the date, such as the order or the credited invoice.
+type Reference struct {
+	Label string
+	Value string
+}
+
+// Total is a row under the lines, the last one is the amount due and is printed in bold.
+type Total struct {
+	Label  string
+	Amount string
+}
+
+// Invoice is what is printed on an invoice or a credit note.
+type Invoice struct {
+	Title      string
+	Number     string
+	Date       time.Time
+	Seller     []string
+	BillTo     []string
+	ShipTo     []string
+	References []Reference
+	Lines      []Line
+	Totals     []Total
+	Notes      []string
+}
+
+const (
+	margin     = 50.0
+	right      = pageWidth - margin
+	top        = pageHeight - margin
+	bottom     = 90.0
+	lineHeight = 14.0
+	fontSize   = 9.0
+	// right edges of the columns
+	quantityColumn  = 340.0
+	unitPriceColumn = 420.0
+	taxRateColumn   = 470.0
+)
+
+// Render lays an invoice out on A4 pages, the lines go on to new pages under a repeated header.
+func Render(inv *Invoice) []byte {
+	d := &document{}
+	d.addPage()
+	y := top
+	d.text(margin, y, 20, true, inv.Title)
+	d.rightText(right, y, 12, true, inv.Number)
+	y -= 2 * lineHeight
+	details := []Reference{{"Date", inv.Date.UTC().Format("2006-01-02")}}
+	details = append(details, inv.References...)
+	for i := 0; i < len(inv.Seller) || i < len(details); i++ {
+		if i < len(inv.Seller) {
+			d.text(margin, y, fontSize, i == 0, inv.Seller[i])
+		}
+		if i < len(details) {
+			d.rightText(right, y, fontSize, false, details[i].Label+": "+details[i].Value)
+		}
+		y -= lineHeight
+	}
+	y -= lineHeight
+	if len(inv.BillTo) > 0 || len(inv.ShipTo) > 0 {
+		d.text(margin, y, fontSize, true, "Bill to")
+		if len(inv.ShipTo) > 0 {
+			d.text(300, y, fontSize, true, "Ship to")
+		}
+		y -= lineHeight
+		for i := 0; i < len(inv.BillTo) || i < len(inv.ShipTo); i++ {
+			if i < len(inv.BillTo) {
+				d.text(margin, y, fontSize, false, inv.BillTo[i])
+			}
+			if i < len(inv.ShipTo) {
+				d.text(300, y, fontSize, false, inv.ShipTo[i])
+			}
+			y -= lineHeight
+		}
+		y -= lineHeight
+	}
+	y = lineHeader(d, y)
+	for _, line := range inv.Lines {
+		if y < bottom {
+			d.addPage()
+			d.rightText(right, top, fontSize, false, inv.Number)
+			y = lineHeader(d, top-2*lineHeight)
+		}
+		d.text(margin, y, fontSize, false, fit(line.Description, quantityColumn-margin-40, fontSize))
+		d.rightText(quantityColumn, y, fontSize, false, fmt.Sprint(line.Quantity))
+		d.rightText(unitPriceColumn, y, fontSize, false, line.UnitPrice)
+		d.rightText(taxRateColumn, y, fontSize, false, line.TaxRate)
+		d.rightText(right, y, fontSize, false, line.Amount)
+		y -= lineHeight
+	}
+	d.line(margin, y+lineHeight-4, right, y+lineHeight-4)
+	y -= 4
+	if y-float64(len(inv.Totals)+len(inv.Notes)+1)*lineHeight < margin {
+		d.addPage()
+		d.rightText(right, top, fontSize, false, inv.Number)
+		y = top - 2*lineHeight
+	}
+	for i, total := range inv.Totals {
+		last := i == len(inv.Totals)-1
+		d.rightText(taxRateColumn, y, fontSize, last, total.Label)
+		d.rightText(right, y, fontSize, last, total.Amount)
+		y -= lineHeight
+	}
+	y -= lineHeight
+	for _, note := range inv.Notes {
+		d.text(margin, y, fontSize, false, note)
+		y -= lineHeight
+	}
+	return d.bytes()
+}
+
+// lineHeader writes the column titles of the lines and returns the baseline of the first line.
+func lineHeader(d *document, y float64) float64 {
+	d.text(margin, y, fontSize, true, "Description")
+	d.rightText(quantityColumn, y, fontSize, true, "Qty")
+	d.rightText(unitPriceColumn, y, fontSize, true, "Unit price")
+	d.rightText(taxRateColumn, y, fontSize, true, "Tax")
+	d.rightText(right, y, fontSize, true, "Amount")
+	d.line(margin, y-4, right, y-4)
+	return y - lineHeight - 4
+}
+
+// fit shortens a text with an ellipsis so it is at most width wide.
+func fit(s string, width float64, size float64) string {
+	if textWidth(s, size) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && textWidth(string(runes)+"...", size) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
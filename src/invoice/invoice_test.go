@@ -0,0 +1,57 @@
+package invoice
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRender(t *testing.T) {
+	inv := &Invoice{
+		Title:      "Invoice",
+		Number:     "INV-000042",
+		Date:       time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC),
+		Seller:     []string{"Book Store"},
+		BillTo:     []string{"Zoë (home)"},
+		References: []Reference{{"Order", "abc"}},
+		Totals:     []Total{{"Total", "12.00 EUR"}},
+	}
+	for i := 0; i < 80; i++ {
+		inv.Lines = append(inv.Lines, Line{Description: fmt.Sprintf("Book %v", i), Quantity: 1, UnitPrice: "0.15", TaxRate: "7%", Amount: "0.15"})
+	}
+	data := Render(inv)
+	if !bytes.HasPrefix(data, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(data, []byte("%%EOF\n")) {
+		t.Fatal("expected a PDF file")
+	}
+	for _, s := range []string{"(INV-000042)", "(Date: 2026-10-19)", "(Zo\xeb \\(home\\))", "(Book 79)", "/Count 2"} {
+		if !bytes.Contains(data, []byte(s)) {
+			t.Fatalf("expected %q in the PDF", s)
+		}
+	}
+	// every offset of the cross-reference table points at its object
+	start := regexp.MustCompile(`startxref\n(\d+)`).FindSubmatch(data)
+	xref, _ := strconv.Atoi(string(start[1]))
+	if !bytes.HasPrefix(data[xref:], []byte("xref\n")) {
+		t.Fatal("expected startxref to point at the cross-reference table")
+	}
+	offsets := regexp.MustCompile(`(\d{10}) 00000 n`).FindAllSubmatch(data[xref:], -1)
+	for i, offset := range offsets {
+		n, _ := strconv.Atoi(string(offset[1]))
+		if !bytes.HasPrefix(data[n:], []byte(fmt.Sprintf("%v 0 obj\n", i+1))) {
+			t.Fatalf("expected object %v at offset %v", i+1, n)
+		}
+	}
+}
+
+func TestFit(t *testing.T) {
+	if s := fit("Short", 100, 9); s != "Short" {
+		t.Fatalf("expected a short text to be kept, got %q", s)
+	}
+	s := fit("A rather long title that cannot possibly fit in the column", 100, 9)
+	if textWidth(s, 9) > 100 || s[len(s)-3:] != "..." {
+		t.Fatalf("expected a shortened text, got %q", s)
+	}
+}
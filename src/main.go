@@ -2,9 +2,17 @@ package main
 
 import (
 	"book-store/db"
+	"book-store/graph/resolver"
 	"book-store/middleware"
+	"book-store/oidc"
+	"book-store/payment"
+	"book-store/storage"
+	"book-store/tax"
 	"context"
+	"log"
 	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -13,13 +21,145 @@ func main() {
 
 	mongoClient := db.Connect(os.Getenv("MONGODB_CONNECTTION_URI"))
 	defer mongoClient.Disconnect(context.Background())
+	db.EnsureIndexes(mongoClient.Database("book-store"))
+	db.Migrate(mongoClient.Database("book-store"))
+
+	// the signed URLs of invoices and exports have a key of their own, so a leaked one can't sign sessions
+	if secret := os.Getenv("SIGNED_URL_SECRET"); secret == "" || secret == os.Getenv("JWT_SECRET") {
+		log.Fatalf("SIGNED_URL_SECRET must be set to a secret other than JWT_SECRET")
+	}
 
 	router := gin.Default()
 
 	router.Use(middleware.GinContextToGQLContext())
 
-	router.POST("/gql", middleware.GraphqlHandler(mongoClient.Database("book-store")))
+	blobs := blobStore(router)
+	documents := documentStore()
+	router.POST("/gql", middleware.GraphqlHandler(mongoClient.Database("book-store"), blobs, documents, taxCalculator(), paymentGateway()))
+	router.GET("/exports/:id", middleware.ExportDownloadHandler(mongoClient.Database("book-store"), documents))
+	router.GET("/invoices/:id", middleware.InvoiceDownloadHandler(mongoClient.Database("book-store"), documents))
 	router.GET("/", middleware.PlaygroundHandler())
 
+	jobs := &resolver.Resolver{DB: mongoClient.Database("book-store"), Blobs: blobs}
+	if err := jobs.FailInterruptedJobs(); err != nil {
+		log.Fatalf("Error when failing the interrupted jobs: %v", err.Error())
+	}
+	go jobs.RunPriceScheduler(time.Minute)
+
+	if issuer := os.Getenv("OIDC_ISSUER"); issuer != "" {
+		provider, err := oidc.NewProvider(context.Background(), oidc.Config{
+			Issuer:       issuer,
+			ClientID:     os.Getenv("OIDC_CLIENT_ID"),
+			ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+			RedirectURL:  os.Getenv("OIDC_REDIRECT_URL"),
+			GroupsClaim:  os.Getenv("OIDC_GROUPS_CLAIM"),
+		}, nil)
+		if err != nil {
+			log.Fatalf("Error when connecting to OIDC provider: %v", err.Error())
+		}
+		groupRoles := middleware.ParseGroupRoles(os.Getenv("OIDC_GROUP_ROLES"))
+		router.GET("/auth/oidc/login", middleware.OIDCLoginHandler(provider))
+		router.GET("/auth/oidc/callback", middleware.OIDCCallbackHandler(provider, mongoClient.Database("book-store"), groupRoles, os.Getenv("OIDC_SYNC_ROLES") == "true"))
+	}
+
 	router.Run(":9090")
 }
+
+// blobStore returns the S3 compatible store when BLOB_STORE is "s3", or else a store in the
+// local BLOB_LOCAL_DIR directory whose covers are then served under /blobs.
+func blobStore(router *gin.Engine) storage.BlobStore {
+	if os.Getenv("BLOB_STORE") == "s3" {
+		return storage.NewS3Store(
+			os.Getenv("S3_ENDPOINT"),
+			os.Getenv("S3_REGION"),
+			os.Getenv("S3_BUCKET"),
+			os.Getenv("S3_ACCESS_KEY_ID"),
+			os.Getenv("S3_SECRET_ACCESS_KEY"),
+			os.Getenv("S3_PUBLIC_URL"),
+		)
+	}
+	dir := os.Getenv("BLOB_LOCAL_DIR")
+	if dir == "" {
+		dir = "blobs"
+	}
+	baseURL := os.Getenv("BLOB_BASE_URL")
+	if baseURL == "" {
+		baseURL = "/blobs"
+	}
+	store, err := storage.NewLocalStore(dir, baseURL)
+	if err != nil {
+		log.Fatalf("Error when creating the blob directory: %v", err.Error())
+	}
+	router.Static("/blobs/covers", filepath.Join(dir, "covers"))
+	return store
+}
+
+// documentStore returns the private store of the invoices and the exports, which hold personal data and
+// are only downloaded through their signed URLs. It's the S3_PRIVATE_BUCKET bucket when BLOB_STORE is
+// "s3", which must not be the public bucket of the covers, or else the BLOB_PRIVATE_DIR directory which
+// isn't served. The directory has no default, it must be kept across deployments as invoices and credit
+// notes aren't rendered again.
+func documentStore() storage.BlobStore {
+	if os.Getenv("BLOB_STORE") == "s3" {
+		bucket := os.Getenv("S3_PRIVATE_BUCKET")
+		if bucket == "" || bucket == os.Getenv("S3_BUCKET") {
+			log.Fatalf("S3_PRIVATE_BUCKET must be set to a private bucket other than S3_BUCKET")
+		}
+		return storage.NewS3Store(
+			os.Getenv("S3_ENDPOINT"),
+			os.Getenv("S3_REGION"),
+			bucket,
+			os.Getenv("S3_ACCESS_KEY_ID"),
+			os.Getenv("S3_SECRET_ACCESS_KEY"),
+			"",
+		)
+	}
+	dir := os.Getenv("BLOB_PRIVATE_DIR")
+	if dir == "" {
+		log.Fatalf("BLOB_PRIVATE_DIR must be set to a persistent directory for the invoices and the exports")
+	}
+	store, err := storage.NewLocalStore(dir, "")
+	if err != nil {
+		log.Fatalf("Error when creating the document directory: %v", err.Error())
+	}
+	return store
+}
+
+// taxCalculator returns the rules of the TAX_RULES_FILE JSON file, or else the built-in rules. The
+// catalog prices include the tax when PRICES_INCLUDE_TAX is true, and the tax is added to them otherwise.
+func taxCalculator() tax.Calculator {
+	path := os.Getenv("TAX_RULES_FILE")
+	inclusive := os.Getenv("PRICES_INCLUDE_TAX") == "true"
+	if path == "" {
+		table, err := tax.NewRuleTable(tax.DefaultRules, inclusive)
+		if err != nil {
+			log.Fatalf("Error in the built-in tax rules: %v", err.Error())
+		}
+		return table
+	}
+	table, err := tax.LoadRuleTable(path, inclusive)
+	if err != nil {
+		log.Fatalf("Error when loading the tax rules: %v", err.Error())
+	}
+	return table
+}
+
+// paymentGateway returns the gateway set with PAYMENT_GATEWAY: "http" for the payment service of
+// PAYMENT_REFUND_URL, or "manual" for payments taken outside the store whose refunds are only recorded.
+// Without a gateway refunds fail and stay to be retried.
+func paymentGateway() payment.Gateway {
+	switch os.Getenv("PAYMENT_GATEWAY") {
+	case "http":
+		url := os.Getenv("PAYMENT_REFUND_URL")
+		if url == "" {
+			log.Fatalf("PAYMENT_REFUND_URL must be set for the http payment gateway")
+		}
+		return payment.NewHTTPGateway(url, os.Getenv("PAYMENT_API_KEY"))
+	case "manual":
+		return payment.Manual{}
+	case "":
+		return nil
+	}
+	log.Fatalf("Unknown payment gateway %v", os.Getenv("PAYMENT_GATEWAY"))
+	return nil
+}
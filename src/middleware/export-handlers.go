@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"book-store/catalog"
+	"book-store/graph/model"
+	"book-store/graph/resolver"
+	"book-store/signedurl"
+	"book-store/storage"
+	"context"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ExportDownloadHandler serves the file of a completed export job to the holder of its signed URL.
+func ExportDownloadHandler(db *mongo.Database, documents storage.BlobStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		err := signedurl.Verify(os.Getenv("SIGNED_URL_SECRET"), c.Request.URL.Path, c.Request.URL.Query(), time.Now())
+		if err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		jobOID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Export doesn't exist"})
+			return
+		}
+		var job *model.ExportJob
+		err = db.Collection("export-jobs").FindOne(context.Background(), bson.M{"_id": jobOID}).Decode(&job)
+		if err != nil || job.Status != model.ExportJobStatusCompleted {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Export doesn't exist"})
+			return
+		}
+		format := string(job.Format)
+		reader, contentType, err := documents.Open(context.Background(), resolver.ExportBlobKey(job.ID, format))
+		if err == storage.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Export doesn't exist"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		defer reader.Close()
+		disposition := `attachment; filename="` + catalog.ExportFileName(job.ID, format) + `"`
+		c.DataFromReader(http.StatusOK, -1, contentType, reader, map[string]string{"Content-Disposition": disposition})
+	}
+}
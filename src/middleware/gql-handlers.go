@@ -3,17 +3,25 @@ package middleware
 import (
 	"book-store/graph/generated"
 	"book-store/graph/resolver"
+	"book-store/payment"
+	"book-store/storage"
+	"book-store/tax"
+	"context"
 
+	"github.com/99designs/gqlgen/graphql"
 	"github.com/99designs/gqlgen/graphql/handler"
 	"github.com/99designs/gqlgen/graphql/playground"
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
-func GraphqlHandler(db *mongo.Database) gin.HandlerFunc {
-	resolver := &resolver.Resolver{DB: db}
-	schema := generated.NewExecutableSchema(generated.Config{Resolvers: resolver})
+func GraphqlHandler(db *mongo.Database, blobs storage.BlobStore, documents storage.BlobStore, taxes tax.Calculator, payments payment.Gateway) gin.HandlerFunc {
+	resolver := &resolver.Resolver{DB: db, Blobs: blobs, Documents: documents, Tax: taxes, Payments: payments}
+	schema := generated.NewExecutableSchema(generated.Config{Resolvers: resolver, Directives: resolver.Directives()})
 	h := handler.NewDefaultServer(schema)
+	h.AroundOperations(func(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+		return next(resolver.WithLoaders(ctx))
+	})
 	return func(c *gin.Context) {
 		h.ServeHTTP(c.Writer, c.Request)
 	}
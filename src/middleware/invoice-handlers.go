@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"book-store/graph/model"
+	"book-store/graph/resolver"
+	"book-store/signedurl"
+	"book-store/storage"
+	"context"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// InvoiceDownloadHandler serves the PDF of an invoice or a credit note to the holder of its signed URL.
+func InvoiceDownloadHandler(db *mongo.Database, documents storage.BlobStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		err := signedurl.Verify(os.Getenv("SIGNED_URL_SECRET"), c.Request.URL.Path, c.Request.URL.Query(), time.Now())
+		if err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		invoiceOID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Invoice doesn't exist"})
+			return
+		}
+		var invoice *model.Invoice
+		err = db.Collection("invoices").FindOne(context.Background(), bson.M{"_id": invoiceOID}).Decode(&invoice)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Invoice doesn't exist"})
+			return
+		}
+		reader, contentType, err := documents.Open(context.Background(), resolver.InvoiceBlobKey(invoice.Number))
+		if err == storage.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Invoice doesn't exist"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		defer reader.Close()
+		disposition := `attachment; filename="` + invoice.Number + `.pdf"`
+		c.DataFromReader(http.StatusOK, -1, contentType, reader, map[string]string{"Content-Disposition": disposition})
+	}
+}
@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"book-store/graph/resolver"
+	"book-store/oidc"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	oidcCookie = "oidc_session"
+	// oidcSessionType tells the login session apart from the access tokens signed with the same secret
+	oidcSessionType = "oidc-session"
+)
+
+// ParseGroupRoles parses "group=ROLE,group=Staff Role" into a map of IdP groups to our roles.
+func ParseGroupRoles(value string) map[string]string {
+	groupRoles := map[string]string{}
+	for _, pair := range strings.Split(value, ",") {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		groupRoles[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	return groupRoles
+}
+
+func OIDCLoginHandler(provider *oidc.Provider) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		state, err := oidc.RandomString()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		nonce, err := oidc.RandomString()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		verifier, err := oidc.RandomString()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		// the state, nonce and verifier are kept in a signed cookie until the callback
+		session := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"typ":      oidcSessionType,
+			"state":    state,
+			"nonce":    nonce,
+			"verifier": verifier,
+			"exp":      time.Now().Add(time.Minute * 10).Unix(),
+		})
+		sessionString, err := session.SignedString([]byte(os.Getenv("JWT_SECRET")))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.SetCookie(oidcCookie, sessionString, 600, "/auth/oidc", "", c.Request.TLS != nil, true)
+		c.Redirect(http.StatusFound, provider.AuthCodeURL(state, nonce, verifier))
+	}
+}
+
+// OIDCCallbackHandler logs the user of the provider in, see LoginWithOIDC for syncRoles.
+func OIDCCallbackHandler(provider *oidc.Provider, db *mongo.Database, groupRoles map[string]string, syncRoles bool) gin.HandlerFunc {
+	r := &resolver.Resolver{DB: db}
+	return func(c *gin.Context) {
+		if errorCode := c.Query("error"); errorCode != "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": errorCode, "description": c.Query("error_description")})
+			return
+		}
+		sessionString, err := c.Cookie(oidcCookie)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Missing login session"})
+			return
+		}
+		c.SetCookie(oidcCookie, "", -1, "/auth/oidc", "", c.Request.TLS != nil, true)
+		session, err := jwt.Parse(sessionString, func(t *jwt.Token) (interface{}, error) {
+			if t.Method.Alg() != jwt.SigningMethodHS256.Name {
+				return nil, fmt.Errorf("Invalid signing method: %v", t.Method.Alg())
+			}
+			return []byte(os.Getenv("JWT_SECRET")), nil
+		})
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid login session"})
+			return
+		}
+		claims, ok := session.Claims.(jwt.MapClaims)
+		if !session.Valid || !ok || claims["typ"] != oidcSessionType || claims["state"] != c.Query("state") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid login session"})
+			return
+		}
+		nonce, _ := claims["nonce"].(string)
+		verifier, _ := claims["verifier"].(string)
+		identity, err := provider.Exchange(c.Request.Context(), c.Query("code"), verifier, nonce)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		result, err := r.LoginWithOIDC(identity, groupRoles, syncRoles)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		// like the login query, the token is a challenge for loginTwoFactor when twoFactorRequired is set
+		c.JSON(http.StatusOK, gin.H{"token": result.Token, "twoFactorRequired": result.TwoFactorRequired})
+	}
+}
@@ -0,0 +1,121 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// mockServer is a minimal OIDC provider which issues an ID token for a single authorization code.
+type mockServer struct {
+	*httptest.Server
+	key       *rsa.PrivateKey
+	code      string
+	challenge string
+	nonce     string
+}
+
+func newMockServer(t *testing.T) *mockServer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &mockServer{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 m.URL,
+			"authorization_endpoint": m.URL + "/authorize",
+			"token_endpoint":         m.URL + "/token",
+			"jwks_uri":               m.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kid": "test",
+				"kty": "RSA",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("code") != m.code || CodeChallenge(r.Form.Get("code_verifier")) != m.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"iss":            m.URL,
+			"aud":            "book-store",
+			"sub":            "user-1",
+			"email":          "staff@example.com",
+			"email_verified": true,
+			"groups":         []string{"catalog-editors"},
+			"amr":            []string{"pwd", "mfa"},
+			"nonce":          m.nonce,
+			"exp":            time.Now().Add(time.Minute).Unix(),
+		})
+		idToken.Header["kid"] = "test"
+		signed, _ := idToken.SignedString(key)
+		json.NewEncoder(w).Encode(map[string]string{"id_token": signed, "token_type": "Bearer"})
+	})
+	m.Server = httptest.NewServer(mux)
+	return m
+}
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	server := newMockServer(t)
+	defer server.Close()
+	provider, err := NewProvider(context.Background(), Config{
+		Issuer:      server.URL,
+		ClientID:    "book-store",
+		RedirectURL: "http://localhost:9090/auth/oidc/callback",
+	}, server.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verifier, _ := RandomString()
+	authURL, err := url.Parse(provider.AuthCodeURL("state", "nonce", verifier))
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := authURL.Query()
+	if query.Get("code_challenge_method") != "S256" || query.Get("state") != "state" {
+		t.Fatalf("unexpected authorization request %v", authURL)
+	}
+	server.code = "code"
+	server.challenge = query.Get("code_challenge")
+	server.nonce = query.Get("nonce")
+
+	identity, err := provider.Exchange(context.Background(), "code", verifier, "nonce")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if identity.Subject != "user-1" || identity.Email != "staff@example.com" || !identity.MFA {
+		t.Fatalf("unexpected identity %+v", identity)
+	}
+	if len(identity.Groups) != 1 || identity.Groups[0] != "catalog-editors" {
+		t.Fatalf("unexpected groups %v", identity.Groups)
+	}
+
+	if _, err := provider.Exchange(context.Background(), "code", "wrong-verifier", "nonce"); err == nil {
+		t.Fatal("expected the exchange to fail with a wrong PKCE verifier")
+	}
+	if _, err := provider.Exchange(context.Background(), "code", verifier, "other-nonce"); err == nil {
+		t.Fatal("expected the ID token to be rejected with a wrong nonce")
+	}
+}
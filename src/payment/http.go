@@ -0,0 +1,68 @@
+package payment
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// HTTPTimeout is how long a request to the payment service may take.
+const HTTPTimeout = 30 * time.Second
+
+// HTTPGateway posts refunds as JSON to the refund endpoint of a payment service, with the key of the
+// refund in the Idempotency-Key header. The service responds with the reference of the refund, {"id": "..."}.
+type HTTPGateway struct {
+	URL    string
+	APIKey string
+	Client *http.Client
+}
+
+func NewHTTPGateway(url string, apiKey string) *HTTPGateway {
+	return &HTTPGateway{URL: url, APIKey: apiKey, Client: &http.Client{Timeout: HTTPTimeout}}
+}
+
+func (g *HTTPGateway) Refund(ctx context.Context, refund Refund) (string, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"orderId":  refund.OrderID,
+		"amount":   refund.Amount,
+		"currency": refund.Currency,
+		"reason":   refund.Reason,
+	})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.URL, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", refund.Key)
+	if g.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+g.APIKey)
+	}
+	res, err := g.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusCreated {
+		message, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return "", fmt.Errorf("Payment service responded %v: %v", res.Status, strings.TrimSpace(string(message)))
+	}
+	var result struct {
+		ID string `json:"id"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&result)
+	if err != nil {
+		return "", err
+	}
+	if result.ID == "" {
+		return "", fmt.Errorf("Payment service didn't return a refund id")
+	}
+	return result.ID, nil
+}
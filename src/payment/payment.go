@@ -0,0 +1,28 @@
+package payment
+
+import "context"
+
+// Refund gives back part or all of what was paid for an order.
+type Refund struct {
+	OrderID string
+	// Amount is in minor units of the currency
+	Amount   int64
+	Currency string
+	// Key identifies the refund, a refund retried with the same key is made once
+	Key    string
+	Reason string
+}
+
+// Gateway makes refunds through a payment provider.
+type Gateway interface {
+	// Refund returns the reference of the refund at the payment provider.
+	Refund(ctx context.Context, refund Refund) (string, error)
+}
+
+// Manual is the gateway of payments taken outside the store, refunds are paid out by
+// hand and are only recorded. It has to be chosen explicitly since no money moves.
+type Manual struct{}
+
+func (Manual) Refund(ctx context.Context, refund Refund) (string, error) {
+	return "manual-" + refund.Key, nil
+}
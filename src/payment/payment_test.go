@@ -0,0 +1,49 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHTTPGateway(t *testing.T) {
+	refunds := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["amount"] != float64(1250) || body["currency"] != "EUR" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		key := r.Header.Get("Idempotency-Key")
+		if _, ok := refunds[key]; !ok {
+			refunds[key] = "re_" + key
+		}
+		json.NewEncoder(w).Encode(map[string]string{"id": refunds[key]})
+	}))
+	defer server.Close()
+	gateway := NewHTTPGateway(server.URL, "secret")
+	refund := Refund{OrderID: "order", Amount: 1250, Currency: "EUR", Key: "abc"}
+	id, err := gateway.Refund(context.Background(), refund)
+	if err != nil || id != "re_abc" {
+		t.Fatalf("expected refund re_abc, got %v %v", id, err)
+	}
+	id, _ = gateway.Refund(context.Background(), refund)
+	if id != "re_abc" || len(refunds) != 1 {
+		t.Fatal("expected a retried refund to be made once")
+	}
+	refund.Amount = 1
+	if _, err := gateway.Refund(context.Background(), refund); err == nil {
+		t.Fatal("expected an error for a refused refund")
+	}
+	gateway.APIKey = "wrong"
+	if _, err := gateway.Refund(context.Background(), refund); err == nil {
+		t.Fatal("expected an error for an unauthorized request")
+	}
+}
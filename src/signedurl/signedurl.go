@@ -0,0 +1,45 @@
+package signedurl
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+func signature(secret string, path string, expires string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(path + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Sign returns path with the expires and signature query parameters, anyone holding the
+// URL can use it until it expires.
+func Sign(secret string, path string, expires time.Time) string {
+	expiresParam := strconv.FormatInt(expires.Unix(), 10)
+	query := url.Values{
+		"expires":   {expiresParam},
+		"signature": {signature(secret, path, expiresParam)},
+	}
+	return path + "?" + query.Encode()
+}
+
+// Verify checks the expires and signature query parameters of a signed URL path.
+func Verify(secret string, path string, query url.Values, now time.Time) error {
+	expires := query.Get("expires")
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return fmt.Errorf("Invalid signed URL")
+	}
+	expected := signature(secret, path, expires)
+	if !hmac.Equal([]byte(expected), []byte(query.Get("signature"))) {
+		return fmt.Errorf("Invalid signed URL")
+	}
+	if now.Unix() > expiresAt {
+		return fmt.Errorf("Signed URL has expired")
+	}
+	return nil
+}
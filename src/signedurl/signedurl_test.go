@@ -0,0 +1,33 @@
+package signedurl
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	signed := Sign("secret", "/exports/abc", now.Add(time.Hour))
+	u, err := url.Parse(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Verify("secret", "/exports/abc", u.Query(), now); err != nil {
+		t.Fatalf("expected a valid signature: %v", err)
+	}
+	if err := Verify("secret", "/exports/other", u.Query(), now); err == nil {
+		t.Fatal("expected the signature of another path to be refused")
+	}
+	if err := Verify("other", "/exports/abc", u.Query(), now); err == nil {
+		t.Fatal("expected a signature with another secret to be refused")
+	}
+	if err := Verify("secret", "/exports/abc", u.Query(), now.Add(2*time.Hour)); err == nil {
+		t.Fatal("expected an expired URL to be refused")
+	}
+	tampered := u.Query()
+	tampered.Set("expires", "9999999999")
+	if err := Verify("secret", "/exports/abc", tampered, now); err == nil {
+		t.Fatal("expected a tampered expiry to be refused")
+	}
+}
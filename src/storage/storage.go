@@ -0,0 +1,25 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var ErrNotFound = errors.New("Blob not found")
+
+// BlobStore keeps binary objects such as cover images under slash separated keys.
+type BlobStore interface {
+	Put(ctx context.Context, key string, contentType string, data []byte) error
+	// PutStream stores the size bytes read from r, so a large blob never is all in memory.
+	PutStream(ctx context.Context, key string, contentType string, r io.Reader, size int64) error
+	// Get returns the content and the content type of a blob, or ErrNotFound.
+	Get(ctx context.Context, key string) ([]byte, string, error)
+	// Open returns a reader of the content and the content type of a blob, or ErrNotFound. The
+	// reader must be closed.
+	Open(ctx context.Context, key string) (io.ReadCloser, string, error)
+	// Delete removes a blob, deleting a missing blob is not an error.
+	Delete(ctx context.Context, key string) error
+	// URL returns the public URL of a blob.
+	URL(key string) string
+}
@@ -0,0 +1,204 @@
+package tax
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+)
+
+// ProductType is the tax category of a product, books and ebooks often have reduced rates.
+type ProductType string
+
+const (
+	ProductBook      ProductType = "BOOK"
+	ProductEbook     ProductType = "EBOOK"
+	ProductAudiobook ProductType = "AUDIOBOOK"
+	// ProductShipping is the shipping of an order, it has the standard rate unless a rule reduces it
+	ProductShipping ProductType = "SHIPPING"
+)
+
+// Location is where an order is shipped, Country is an ISO 3166-1 alpha-2 code and Region
+// the code of a subdivision of the country, e.g. "CA" in the US.
+type Location struct {
+	Country string
+	Region  string
+}
+
+// Line is a line of a cart or an order, Amount is its price in minor units, the unit price times the quantity.
+type Line struct {
+	ProductType ProductType
+	Amount      int64
+}
+
+// LineTax is the tax of a line, in the minor units of the line amount.
+type LineTax struct {
+	// Name is the name of the tax, e.g. "VAT", it is empty when the line isn't taxed
+	Name string
+	// Rate is a percentage, e.g. "20" or "8.875"
+	Rate      string
+	Inclusive bool
+	Net       int64
+	Tax       int64
+	Gross     int64
+}
+
+// Calculator computes the tax of every line of a cart or an order shipped to a location.
+type Calculator interface {
+	Calculate(location Location, lines []Line) ([]LineTax, error)
+}
+
+// Rule is the tax of a country, or of a region of a country.
+type Rule struct {
+	Country string `json:"country"`
+	// Region restricts the rule to a region, a rule without region applies to the rest of the country
+	Region string `json:"region,omitempty"`
+	Name   string `json:"name"`
+	// Rate is the standard rate in percent
+	Rate string `json:"rate"`
+	// ReducedRates replace the standard rate for some product types
+	ReducedRates map[ProductType]string `json:"reducedRates,omitempty"`
+}
+
+func (rule *Rule) rate(productType ProductType) string {
+	if rate, ok := rule.ReducedRates[productType]; ok {
+		return rate
+	}
+	return rule.Rate
+}
+
+// DefaultRules are the built-in rules, they cover the countries the store ships to the most and
+// can be replaced with a rules file. Lines shipped to a location without a rule aren't taxed.
+var DefaultRules = []Rule{
+	{Country: "GB", Name: "VAT", Rate: "20", ReducedRates: map[ProductType]string{ProductBook: "0", ProductEbook: "0", ProductAudiobook: "20"}},
+	{Country: "DE", Name: "MwSt", Rate: "19", ReducedRates: map[ProductType]string{ProductBook: "7", ProductEbook: "7", ProductAudiobook: "7"}},
+	{Country: "FR", Name: "TVA", Rate: "20", ReducedRates: map[ProductType]string{ProductBook: "5.5", ProductEbook: "5.5", ProductAudiobook: "5.5"}},
+	{Country: "IT", Name: "IVA", Rate: "22", ReducedRates: map[ProductType]string{ProductBook: "4", ProductEbook: "4", ProductAudiobook: "4"}},
+	{Country: "ES", Name: "IVA", Rate: "21", ReducedRates: map[ProductType]string{ProductBook: "4", ProductEbook: "4", ProductAudiobook: "4"}},
+	{Country: "NL", Name: "BTW", Rate: "21", ReducedRates: map[ProductType]string{ProductBook: "9", ProductEbook: "9", ProductAudiobook: "9"}},
+	{Country: "AU", Name: "GST", Rate: "10"},
+	{Country: "JP", Name: "Consumption tax", Rate: "10"},
+	{Country: "VN", Name: "VAT", Rate: "10", ReducedRates: map[ProductType]string{ProductBook: "0", ProductEbook: "0"}},
+	{Country: "CA", Name: "GST", Rate: "5"},
+	{Country: "CA", Region: "ON", Name: "HST", Rate: "13", ReducedRates: map[ProductType]string{ProductBook: "5", ProductEbook: "5", ProductAudiobook: "5"}},
+	{Country: "US", Region: "CA", Name: "Sales tax", Rate: "7.25", ReducedRates: map[ProductType]string{ProductEbook: "0", ProductAudiobook: "0"}},
+	{Country: "US", Region: "NY", Name: "Sales tax", Rate: "4"},
+	{Country: "US", Region: "TX", Name: "Sales tax", Rate: "6.25"},
+	{Country: "US", Region: "WA", Name: "Sales tax", Rate: "6.5"},
+}
+
+// RuleTable is a Calculator looking up the rule of the region of a location, or else of its country.
+// Inclusive is set when the catalog prices are entered with the tax included, the tax is then
+// extracted from the prices rather than added to them. It's the same for every destination, since a
+// catalog price means the same wherever the book is shipped.
+type RuleTable struct {
+	rules     map[string]*Rule
+	Inclusive bool
+}
+
+func NewRuleTable(rules []Rule, inclusive bool) (*RuleTable, error) {
+	table := &RuleTable{rules: map[string]*Rule{}, Inclusive: inclusive}
+	for i := range rules {
+		rule := rules[i]
+		rule.Country = strings.ToUpper(rule.Country)
+		rule.Region = strings.ToUpper(rule.Region)
+		rates := []string{rule.Rate}
+		for _, rate := range rule.ReducedRates {
+			rates = append(rates, rate)
+		}
+		for _, rate := range rates {
+			value, ok := new(big.Rat).SetString(rate)
+			if !ok || value.Sign() < 0 || value.Cmp(big.NewRat(100, 1)) >= 0 {
+				return nil, fmt.Errorf("Invalid tax rate %v for %v", rate, rule.key())
+			}
+		}
+		if _, ok := table.rules[rule.key()]; ok {
+			return nil, fmt.Errorf("Duplicate tax rule for %v", rule.key())
+		}
+		table.rules[rule.key()] = &rule
+	}
+	return table, nil
+}
+
+// LoadRuleTable reads the rules of a JSON file, an array of rules.
+func LoadRuleTable(path string, inclusive bool) (*RuleTable, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rules []Rule
+	err = json.Unmarshal(data, &rules)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid tax rules file %v: %v", path, err)
+	}
+	return NewRuleTable(rules, inclusive)
+}
+
+func (rule *Rule) key() string {
+	if rule.Region == "" {
+		return rule.Country
+	}
+	return rule.Country + "-" + rule.Region
+}
+
+// Rule returns the rule of a location, nil when the location isn't taxed.
+func (t *RuleTable) Rule(location Location) *Rule {
+	country := strings.ToUpper(strings.TrimSpace(location.Country))
+	region := strings.ToUpper(strings.TrimSpace(location.Region))
+	if rule, ok := t.rules[country+"-"+region]; ok && region != "" {
+		return rule
+	}
+	return t.rules[country]
+}
+
+func (t *RuleTable) Calculate(location Location, lines []Line) ([]LineTax, error) {
+	rule := t.Rule(location)
+	taxes := make([]LineTax, len(lines))
+	for i, line := range lines {
+		if rule == nil {
+			taxes[i] = LineTax{Rate: "0", Inclusive: t.Inclusive, Net: line.Amount, Gross: line.Amount}
+			continue
+		}
+		rate := rule.rate(line.ProductType)
+		taxes[i] = Compute(line.Amount, rate, t.Inclusive)
+		taxes[i].Name = rule.Name
+	}
+	return taxes, nil
+}
+
+// Compute returns the tax of an amount at a rate in percent, rounded half up to the minor unit.
+// An inclusive amount is the gross amount and an exclusive one the net amount.
+func Compute(amount int64, rate string, inclusive bool) LineTax {
+	percent, ok := new(big.Rat).SetString(rate)
+	if !ok {
+		percent = new(big.Rat)
+	}
+	ratio := new(big.Rat).Quo(percent, big.NewRat(100, 1))
+	line := LineTax{Rate: rate, Inclusive: inclusive}
+	if inclusive {
+		// the tax part of a gross amount is amount * rate / (1 + rate)
+		divisor := new(big.Rat).Add(ratio, big.NewRat(1, 1))
+		line.Gross = amount
+		line.Tax = roundHalfUp(new(big.Rat).Quo(new(big.Rat).Mul(big.NewRat(amount, 1), ratio), divisor))
+		line.Net = amount - line.Tax
+	} else {
+		line.Net = amount
+		line.Tax = roundHalfUp(new(big.Rat).Mul(big.NewRat(amount, 1), ratio))
+		line.Gross = amount + line.Tax
+	}
+	return line
+}
+
+func roundHalfUp(value *big.Rat) int64 {
+	negative := value.Sign() < 0
+	abs := new(big.Rat).Abs(value)
+	quotient, remainder := new(big.Int).QuoRem(abs.Num(), abs.Denom(), new(big.Int))
+	if new(big.Int).Mul(remainder, big.NewInt(2)).Cmp(abs.Denom()) >= 0 {
+		quotient.Add(quotient, big.NewInt(1))
+	}
+	if negative {
+		return -quotient.Int64()
+	}
+	return quotient.Int64()
+}
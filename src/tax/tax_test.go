@@ -0,0 +1,80 @@
+package tax
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCompute(t *testing.T) {
+	cases := []struct {
+		amount    int64
+		rate      string
+		inclusive bool
+		expected  LineTax
+	}{
+		{1999, "20", true, LineTax{Rate: "20", Inclusive: true, Net: 1666, Tax: 333, Gross: 1999}},
+		{1000, "8.875", false, LineTax{Rate: "8.875", Net: 1000, Tax: 89, Gross: 1089}},
+		{1000, "5.5", true, LineTax{Rate: "5.5", Inclusive: true, Net: 948, Tax: 52, Gross: 1000}},
+		{1000, "0", false, LineTax{Rate: "0", Net: 1000, Gross: 1000}},
+	}
+	for _, c := range cases {
+		if line := Compute(c.amount, c.rate, c.inclusive); line != c.expected {
+			t.Fatalf("Compute(%v, %v, %v) = %+v, expected %+v", c.amount, c.rate, c.inclusive, line, c.expected)
+		}
+	}
+}
+
+func TestRuleTable(t *testing.T) {
+	table, err := NewRuleTable(DefaultRules, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := []Line{{ProductType: ProductBook, Amount: 1000}, {ProductType: ProductAudiobook, Amount: 1000}}
+	taxes, _ := table.Calculate(Location{Country: "de"}, lines)
+	if taxes[0].Rate != "7" || taxes[0].Inclusive || taxes[0].Gross != 1070 || taxes[1].Name != "MwSt" {
+		t.Fatalf("unexpected German taxes %+v", taxes)
+	}
+	// a region falls back to the rule of its country
+	taxes, _ = table.Calculate(Location{Country: "CA", Region: "BC"}, lines)
+	if taxes[0].Name != "GST" || taxes[0].Tax != 50 || taxes[0].Gross != 1050 {
+		t.Fatalf("unexpected Canadian taxes %+v", taxes)
+	}
+	taxes, _ = table.Calculate(Location{Country: "US", Region: "ca"}, []Line{{ProductType: ProductEbook, Amount: 1000}})
+	if taxes[0].Tax != 0 || taxes[0].Name != "Sales tax" {
+		t.Fatalf("unexpected Californian taxes %+v", taxes)
+	}
+	taxes, _ = table.Calculate(Location{Country: "US", Region: "OR"}, lines)
+	if taxes[0].Tax != 0 || taxes[0].Name != "" || taxes[0].Gross != 1000 {
+		t.Fatalf("an untaxed location should have no tax, got %+v", taxes)
+	}
+}
+
+func TestInclusiveRuleTable(t *testing.T) {
+	table, err := NewRuleTable(DefaultRules, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the prices include the tax whatever the destination
+	for _, location := range []Location{{Country: "DE"}, {Country: "US", Region: "NY"}, {Country: "US", Region: "OR"}} {
+		taxes, _ := table.Calculate(location, []Line{{ProductType: ProductBook, Amount: 1070}})
+		if !taxes[0].Inclusive || taxes[0].Gross != 1070 {
+			t.Fatalf("unexpected taxes in %v: %+v", location, taxes)
+		}
+	}
+}
+
+func TestLoadRuleTable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.json")
+	os.WriteFile(path, []byte(`[{"country": "SE", "name": "Moms", "rate": "25", "reducedRates": {"BOOK": "6"}}]`), 0o644)
+	table, err := LoadRuleTable(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rule := table.Rule(Location{Country: "SE"}); rule == nil || rule.rate(ProductBook) != "6" {
+		t.Fatalf("unexpected rule %+v", rule)
+	}
+	if _, err := NewRuleTable([]Rule{{Country: "SE", Rate: "125"}}, false); err == nil {
+		t.Fatal("a rate of 125% should be refused")
+	}
+}
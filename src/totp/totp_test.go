@@ -0,0 +1,74 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA-1 key of the test vectors of RFC 6238, "12345678901234567890" in base32.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// TestCode checks the codes against the SHA-1 test vectors of RFC 6238, which have 8 digits
+// of which the last 6 are the codes.
+func TestCode(t *testing.T) {
+	tests := []struct {
+		time int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, test := range tests {
+		code, err := Code(rfcSecret, Step(time.Unix(test.time, 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code != test.code {
+			t.Fatalf("expected %v at %v, got %v", test.code, test.time, code)
+		}
+	}
+	if code, _ := Code("gezdgnbvgy3tqojqgezdgnbvgy3tqojq", Step(time.Unix(59, 0))); code != "287082" {
+		t.Fatalf("expected a lower case secret to be accepted, got %v", code)
+	}
+	if _, err := Code("not base32!", 1); err == nil {
+		t.Fatal("expected an invalid secret to be refused")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := Step(now)
+	tests := []struct {
+		name  string
+		step  int64
+		valid bool
+	}{
+		{"current period", current, true},
+		{"previous period", current - Skew, true},
+		{"next period", current + Skew, true},
+		{"too old", current - Skew - 1, false},
+		{"too early", current + Skew + 1, false},
+	}
+	for _, test := range tests {
+		code, _ := Code(rfcSecret, test.step)
+		step, ok := Validate(rfcSecret, code, now)
+		if ok != test.valid {
+			t.Fatalf("%v: expected valid to be %v", test.name, test.valid)
+		}
+		if ok && step != test.step {
+			t.Fatalf("%v: expected step %v, got %v", test.name, test.step, step)
+		}
+	}
+	if _, ok := Validate(rfcSecret, " 050471 ", now); !ok {
+		t.Fatal("expected the spaces around a code to be ignored")
+	}
+	for _, code := range []string{"", "05047", "0504711", "000000"} {
+		if _, ok := Validate(rfcSecret, code, now); ok {
+			t.Fatalf("expected %q to be refused", code)
+		}
+	}
+}